@@ -7,10 +7,12 @@ import "context"
 // Uses float32 for compatibility with vector databases (pgvector, Pinecone, Qdrant, etc.).
 type Vector []float32
 
-// Usage tracks token consumption for an embedding request.
+// Usage tracks token consumption for an embedding request. Counts are zero
+// when the provider does not report them.
 type Usage struct {
 	PromptTokens int
 	TotalTokens  int
+	ImagePixels  int // Image pixels processed, for providers that bill by them
 }
 
 // EmbeddingResponse contains the result of an embedding request.
@@ -19,6 +21,23 @@ type EmbeddingResponse struct {
 	Vectors    []Vector
 	Usage      Usage
 	Dimensions int
+	Truncated  int // Inputs shortened by the provider to fit the model's token limit
+
+	// Warnings are advisory messages returned by the provider, e.g. about
+	// truncated inputs or deprecated models. Nil if the provider reports none
+	// or does not support them.
+	Warnings []string
+
+	// Quantized embeddings, populated by providers that support them
+	// alongside or instead of float Vectors. BinaryVectors hold packed
+	// bits, eight dimensions per byte.
+	Int8Vectors   [][]int8
+	BinaryVectors [][]byte
+}
+
+// count returns the number of embeddings in the response of any kind.
+func (r *EmbeddingResponse) count() int {
+	return max(len(r.Vectors), len(r.Int8Vectors), len(r.BinaryVectors))
 }
 
 // Provider defines the interface for embedding backends.
@@ -33,6 +52,25 @@ type Provider interface {
 	Dimensions() int
 }
 
+// Embedder is the embedding surface of a Service without its builder methods.
+// Service implements it; Service.ReadOnly returns a view that exposes nothing else.
+type Embedder interface {
+	// Embed generates a document embedding for a single text.
+	Embed(ctx context.Context, text string) (Vector, error)
+
+	// EmbedQuery generates a query-optimized embedding for a single text.
+	EmbedQuery(ctx context.Context, text string) (Vector, error)
+
+	// Batch generates document embeddings for multiple texts.
+	Batch(ctx context.Context, texts []string) ([]Vector, error)
+
+	// BatchQuery generates query-optimized embeddings for multiple texts.
+	BatchQuery(ctx context.Context, texts []string) ([]Vector, error)
+
+	// Dimensions returns the output vector dimensionality.
+	Dimensions() int
+}
+
 // QueryProviderFactory is optionally implemented by providers that distinguish
 // query vs document embeddings. Providers implementing this interface can
 // generate query-optimized embeddings for improved retrieval quality.
@@ -42,6 +80,69 @@ type QueryProviderFactory interface {
 	ForQuery() Provider
 }
 
+// ModelReporter is optionally implemented by providers that can report the
+// model they request. The served model reported in EmbeddingResponse.Model
+// may differ (e.g. a dated snapshot), so both are surfaced in hooks.
+type ModelReporter interface {
+	// Model returns the requested model identifier.
+	Model() string
+}
+
+// TokenLimiter is optionally implemented by providers that report the
+// maximum number of input tokens their model accepts per text.
+type TokenLimiter interface {
+	// MaxTokens returns the model's per-input token limit.
+	MaxTokens() int
+}
+
+// BatchLimiter is optionally implemented by providers that reject requests
+// with more than a fixed number of inputs and do not split them themselves.
+// The Service sends larger requests, such as one long text chunked into many
+// pieces, as several pipeline calls and merges the results before pooling.
+type BatchLimiter interface {
+	// MaxBatchSize returns the maximum number of inputs per request.
+	MaxBatchSize() int
+}
+
+// ImageProvider is optionally implemented by providers that can embed images
+// into the same vector space as their text embeddings.
+type ImageProvider interface {
+	// EmbedImages generates embedding vectors for encoded images (PNG, JPEG,
+	// etc.), one per input in the same order.
+	EmbedImages(ctx context.Context, images [][]byte) (*EmbeddingResponse, error)
+}
+
+// Document is a text to embed together with an optional title, such as a page
+// heading or file name, that gives the text context.
+type Document struct {
+	Title string
+	Text  string
+}
+
+// TitledEmbedder is optionally implemented by providers whose API accepts a
+// title alongside each text, so Service.BatchDocuments can send titles
+// natively instead of prepending them.
+type TitledEmbedder interface {
+	// EmbedTitled generates embedding vectors for texts, where titles[i] is
+	// the title of texts[i] and may be empty.
+	EmbedTitled(ctx context.Context, texts, titles []string) (*EmbeddingResponse, error)
+}
+
+// RerankResult scores one document against a rerank query.
+type RerankResult struct {
+	Index          int     // Position of the document in the request
+	RelevanceScore float64 // Higher is more relevant; scale is model-specific
+	Document       string  // The document at Index, so results stand alone
+}
+
+// Reranker scores documents by relevance to a query, typically to reorder
+// candidates retrieved by vector similarity.
+type Reranker interface {
+	// Rerank returns the topK most relevant documents in descending order of
+	// relevance. A topK of zero or less returns every document.
+	Rerank(ctx context.Context, query string, documents []string, topK int) ([]RerankResult, error)
+}
+
 // SimilarityMetric defines how vectors are compared.
 type SimilarityMetric int
 
@@ -66,8 +167,28 @@ const (
 	ChunkParagraph
 	// ChunkFixed splits into fixed-size chunks.
 	ChunkFixed
+	// ChunkAuto picks one of the other strategies per text based on its
+	// length and structure; see AutoThresholds.
+	ChunkAuto
 )
 
+// String returns the strategy name.
+func (s ChunkStrategy) String() string {
+	switch s {
+	case ChunkNone:
+		return "none"
+	case ChunkSentence:
+		return "sentence"
+	case ChunkParagraph:
+		return "paragraph"
+	case ChunkFixed:
+		return "fixed"
+	case ChunkAuto:
+		return "auto"
+	}
+	return "unknown"
+}
+
 // PoolingMode defines how multiple chunk vectors are combined.
 type PoolingMode int
 
@@ -78,4 +199,66 @@ const (
 	PoolFirst
 	// PoolMax takes element-wise maximum.
 	PoolMax
+	// PoolAdaptive averages up to a threshold number of vectors and takes
+	// the element-wise maximum above it, where averaging many chunks of a
+	// long document dilutes the passages that matter. The threshold is
+	// DefaultAdaptivePoolThreshold unless set with WithAdaptivePooling.
+	PoolAdaptive
+)
+
+// DefaultAdaptivePoolThreshold is the largest chunk count PoolAdaptive
+// mean-pools.
+const DefaultAdaptivePoolThreshold = 8
+
+// TitleHandling defines how Document titles reach the provider.
+type TitleHandling int
+
+const (
+	// TitleNative sends titles through TitledEmbedder when the provider
+	// implements it and prepends them like TitlePrepend otherwise.
+	TitleNative TitleHandling = iota
+	// TitlePrepend prepends "Title\n\n" to each text before chunking, so
+	// the title lands in the first chunk, or a chunk of its own depending on
+	// the chunker.
+	TitlePrepend
+	// TitlePrependChunks prepends "Title\n\n" to every chunk of a text.
+	TitlePrependChunks
+	// TitleIgnore drops titles.
+	TitleIgnore
+)
+
+// ZeroVectorPolicy defines how all-zero vectors returned by a provider are
+// handled.
+type ZeroVectorPolicy int
+
+const (
+	// ZeroAllow returns zero vectors like any other.
+	ZeroAllow ZeroVectorPolicy = iota
+	// ZeroError fails the request with ErrZeroVector naming the input.
+	ZeroError
+	// ZeroDrop returns a nil vector for the input.
+	ZeroDrop
+)
+
+// TruncationMode defines how inputs over the maximum length are handled.
+type TruncationMode int
+
+const (
+	// TruncateError rejects the request with ErrInputTooLong.
+	TruncateError TruncationMode = iota
+	// TruncateTail keeps the beginning of the input and drops the end.
+	TruncateTail
+	// TruncateHead keeps the end of the input and drops the beginning.
+	TruncateHead
+)
+
+// SimilarityPrecision defines the accumulator type for vector arithmetic.
+type SimilarityPrecision int
+
+const (
+	// PrecisionFloat64 accumulates in float64 for accuracy.
+	PrecisionFloat64 SimilarityPrecision = iota
+	// PrecisionFloat32 accumulates in float32, trading accuracy for speed
+	// when scoring many vectors.
+	PrecisionFloat32
 )
@@ -0,0 +1,71 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/zoobzio/pipz"
+)
+
+// attemptTimeout bounds each pass through its processor, which is meant to
+// sit inside a retry option so that every attempt gets the full duration.
+// Unlike pipz.Timeout it waits for the processor to return rather than
+// abandoning it, so a late attempt can never write to a request that a
+// retry is already reusing; providers return promptly once their context
+// expires.
+type attemptTimeout struct {
+	identity  pipz.Identity
+	processor pipz.Chainable[*EmbedRequest]
+	duration  time.Duration
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newAttemptTimeout(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], duration time.Duration) *attemptTimeout {
+	return &attemptTimeout{
+		identity:  identity,
+		processor: processor,
+		duration:  duration,
+	}
+}
+
+// Process implements pipz.Chainable.
+func (a *attemptTimeout) Process(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, a.duration)
+	defer cancel()
+
+	out, err := a.processor.Process(attemptCtx, req)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		// This attempt was cut short on purpose, so a surrounding retry
+		// may re-send it.
+		err = markRetryable(err)
+	}
+	return out, err
+}
+
+// Identity implements pipz.Chainable.
+func (a *attemptTimeout) Identity() pipz.Identity {
+	return a.identity
+}
+
+// Schema implements pipz.Chainable.
+func (a *attemptTimeout) Schema() pipz.Node {
+	return pipz.Node{
+		Identity: a.identity,
+		Type:     "attempt-timeout",
+		Flow:     pipz.TimeoutFlow{Processor: a.processor.Schema()},
+		Metadata: map[string]any{
+			"duration": a.duration.String(),
+		},
+	}
+}
+
+// Close implements pipz.Chainable.
+func (a *attemptTimeout) Close() error {
+	a.closeOnce.Do(func() {
+		a.closeErr = a.processor.Close()
+	})
+	return a.closeErr
+}
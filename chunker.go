@@ -1,40 +1,150 @@
 package vex
 
 import (
+	"slices"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Chunker splits text into smaller pieces for embedding.
 type Chunker struct {
-	Strategy    ChunkStrategy
-	MaxSize     int  // Maximum chunk size in characters (for ChunkFixed)
-	Overlap     int  // Overlap between chunks (for ChunkFixed)
-	TrimSpace   bool // Trim whitespace from chunks
+	Abbreviations []string // Tokens that never end a sentence, e.g. "Dr." (for ChunkSentence)
+	Strategy      ChunkStrategy
+	MaxSize       int  // Maximum chunk size in characters (for ChunkFixed)
+	Overlap       int  // Overlap between chunks (for ChunkFixed)
+	TrimSpace     bool // Trim whitespace from chunks
+
+	// UnitOverlap prepends up to this many preceding sentences or paragraphs
+	// to each chunk for context at chunk edges; the first chunk has none
+	// (for ChunkSentence and ChunkParagraph).
+	UnitOverlap int
+
+	// DropIncompleteTrailing discards a final sentence lacking terminal
+	// punctuation, e.g. for streaming text (for ChunkSentence). A text with
+	// no complete sentence yet is held back entirely: it yields no chunks, and
+	// Service returns a nil vector for it, as for an empty text.
+	DropIncompleteTrailing bool
+
+	// PoolExcludeOverlap discounts each overlapping chunk during mean pooling
+	// by its overlap fraction (Overlap/MaxSize), so overlapping content is not
+	// double-counted (for ChunkFixed).
+	PoolExcludeOverlap bool
+
+	// Auto tunes strategy selection; zero fields use DefaultAutoThresholds
+	// (for ChunkAuto).
+	Auto AutoThresholds
+}
+
+// AutoThresholds controls how ChunkAuto routes each text:
+//   - texts of at most ShortLength characters are not chunked;
+//   - texts over LongLength characters with at least MinParagraphs
+//     blank-line separated paragraphs are split by paragraph;
+//   - other texts with more than one sentence are split by sentence;
+//   - the rest, e.g. unpunctuated transcripts, are split into fixed-size
+//     chunks of MaxSize.
+type AutoThresholds struct {
+	ShortLength   int `json:"short_length"`
+	LongLength    int `json:"long_length"`
+	MinParagraphs int `json:"min_paragraphs"`
 }
 
-// DefaultChunker returns a chunker with sensible defaults.
+// DefaultAutoThresholds are the ChunkAuto thresholds used for zero fields.
+var DefaultAutoThresholds = AutoThresholds{
+	ShortLength:   512,
+	LongLength:    2000,
+	MinParagraphs: 3,
+}
+
+// DefaultAbbreviations are common abbreviations that should not end a sentence.
+var DefaultAbbreviations = []string{
+	"Mr.", "Mrs.", "Ms.", "Dr.", "Prof.", "Sr.", "Jr.", "St.",
+	"vs.", "e.g.", "i.e.", "etc.", "U.S.", "U.S.A.", "U.K.",
+}
+
+// DefaultChunker returns a chunker with sensible defaults. Its
+// Abbreviations are a copy of DefaultAbbreviations, so editing them does not
+// affect other chunkers.
 func DefaultChunker() *Chunker {
 	return &Chunker{
-		Strategy:  ChunkNone,
-		MaxSize:   512,
-		Overlap:   50,
-		TrimSpace: true,
+		Abbreviations: slices.Clone(DefaultAbbreviations),
+		Strategy:      ChunkNone,
+		MaxSize:       512,
+		Overlap:       50,
+		TrimSpace:     true,
+	}
+}
+
+// defaultSuggestedChunkTokens is suggested when a provider reports no limit.
+const defaultSuggestedChunkTokens = 256
+
+// SuggestChunkSize returns a recommended chunk size in tokens for provider.
+// For providers implementing TokenLimiter this is half the model's context,
+// leaving headroom for tokenizer variance while keeping chunks focused.
+// Otherwise a conservative default is returned.
+func SuggestChunkSize(provider Provider) int {
+	if tl, ok := provider.(TokenLimiter); ok && tl.MaxTokens() > 0 {
+		return tl.MaxTokens() / 2
 	}
+	return defaultSuggestedChunkTokens
 }
 
 // Chunk splits text according to the configured strategy.
 func (c *Chunker) Chunk(text string) []string {
-	if c.Strategy == ChunkNone {
-		return []string{text}
+	chunks, _ := c.chunk(text)
+	return chunks
+}
+
+// StrategyFor returns the strategy Chunk applies to text: the configured
+// strategy, or for ChunkAuto the one selected from the text's length and
+// structure.
+func (c *Chunker) StrategyFor(text string) ChunkStrategy {
+	if c.Strategy != ChunkAuto {
+		return c.Strategy
+	}
+
+	t := c.thresholds()
+	length := utf8.RuneCountInString(text)
+	switch {
+	case length <= t.ShortLength:
+		return ChunkNone
+	case length > t.LongLength && len(c.chunkByParagraph(text)) >= t.MinParagraphs:
+		return ChunkParagraph
+	case len(c.chunkBySentence(text)) > 1:
+		return ChunkSentence
+	}
+	return ChunkFixed
+}
+
+// thresholds returns the Auto thresholds with defaults applied.
+func (c *Chunker) thresholds() AutoThresholds {
+	t := c.Auto
+	if t.ShortLength <= 0 {
+		t.ShortLength = DefaultAutoThresholds.ShortLength
+	}
+	if t.LongLength <= 0 {
+		t.LongLength = DefaultAutoThresholds.LongLength
+	}
+	if t.MinParagraphs <= 0 {
+		t.MinParagraphs = DefaultAutoThresholds.MinParagraphs
+	}
+	return t
+}
+
+// chunk splits text and reports the strategy used.
+func (c *Chunker) chunk(text string) ([]string, ChunkStrategy) {
+	strategy := c.StrategyFor(text)
+	if strategy == ChunkNone {
+		return []string{text}, strategy
 	}
 
 	var chunks []string
-	switch c.Strategy {
+	switch strategy {
 	case ChunkSentence:
-		chunks = c.chunkBySentence(text)
+		// Sentences keep their leading whitespace, so they join as-is.
+		chunks = withUnitOverlap(c.chunkBySentence(text), c.UnitOverlap, "")
 	case ChunkParagraph:
-		chunks = c.chunkByParagraph(text)
+		chunks = withUnitOverlap(c.chunkByParagraph(text), c.UnitOverlap, "\n\n")
 	case ChunkFixed:
 		chunks = c.chunkByFixed(text)
 	default:
@@ -54,34 +164,80 @@ func (c *Chunker) Chunk(text string) []string {
 			result = append(result, chunk)
 		}
 	}
-	return result
+	return result, strategy
 }
 
-func (*Chunker) chunkBySentence(text string) []string {
+func (c *Chunker) chunkBySentence(text string) []string {
 	var chunks []string
 	var current strings.Builder
 
+	abbreviations := make(map[string]bool, len(c.Abbreviations))
+	for _, abbr := range c.Abbreviations {
+		abbreviations[strings.ToLower(abbr)] = true
+	}
+
 	runes := []rune(text)
 	for i := 0; i < len(runes); i++ {
 		current.WriteRune(runes[i])
 
-		// Check for sentence-ending punctuation followed by space or end
+		// Check for sentence-ending punctuation followed by space or end.
+		// Requiring whitespace after the mark also keeps decimals like 3.14 intact.
 		if isSentenceEnd(runes[i]) {
 			if i+1 >= len(runes) || unicode.IsSpace(runes[i+1]) {
+				if runes[i] == '.' && abbreviations[strings.ToLower(precedingToken(runes, i))] {
+					continue
+				}
 				chunks = append(chunks, current.String())
 				current.Reset()
 			}
 		}
 	}
 
-	// Don't forget remaining text
+	// Don't forget remaining text, unless it is an incomplete sentence to hold back
 	if current.Len() > 0 {
-		chunks = append(chunks, current.String())
+		rest := current.String()
+		if !c.DropIncompleteTrailing || endsSentence(rest) {
+			chunks = append(chunks, rest)
+		}
 	}
 
 	return chunks
 }
 
+// withUnitOverlap prefixes each unit with up to n preceding units, joined by
+// sep. Blank units are dropped first so they neither count as context nor
+// repeat the previous chunk.
+func withUnitOverlap(units []string, n int, sep string) []string {
+	if n <= 0 {
+		return units
+	}
+	kept := make([]string, 0, len(units))
+	for _, u := range units {
+		if strings.TrimSpace(u) != "" {
+			kept = append(kept, u)
+		}
+	}
+	chunks := make([]string, len(kept))
+	for i := range kept {
+		chunks[i] = strings.Join(kept[max(i-n, 0):i+1], sep)
+	}
+	return chunks
+}
+
+// poolWeight returns the mean-pooling weight for the chunk at position index
+// within a text chunked with strategy. Every fixed-size chunk after the first
+// repeats Overlap characters of its predecessor, so it is discounted by that
+// fraction when PoolExcludeOverlap is set.
+func (c *Chunker) poolWeight(strategy ChunkStrategy, index int) float64 {
+	if !c.PoolExcludeOverlap || strategy != ChunkFixed || index == 0 {
+		return 1
+	}
+	if c.MaxSize <= 0 || c.Overlap <= 0 || c.Overlap >= c.MaxSize {
+		return 1
+	}
+	return 1 - float64(c.Overlap)/float64(c.MaxSize)
+}
+
 func (*Chunker) chunkByParagraph(text string) []string {
 	// Split on double newlines
 	paragraphs := strings.Split(text, "\n\n")
@@ -125,6 +281,22 @@ func (c *Chunker) chunkByFixed(text string) []string {
 	return chunks
 }
 
+// precedingToken returns the whitespace-delimited token ending at index end.
+func precedingToken(runes []rune, end int) string {
+	start := end
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start--
+	}
+	return string(runes[start : end+1])
+}
+
+// endsSentence reports whether text ends with sentence-ending punctuation,
+// ignoring trailing whitespace.
+func endsSentence(text string) bool {
+	runes := []rune(strings.TrimRightFunc(text, unicode.IsSpace))
+	return len(runes) > 0 && isSentenceEnd(runes[len(runes)-1])
+}
+
 func isSentenceEnd(r rune) bool {
 	return r == '.' || r == '!' || r == '?'
 }
@@ -1,6 +1,9 @@
 package vex
 
 import (
+	"context"
+	"math"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -64,6 +67,106 @@ func TestChunker_ChunkSentence(t *testing.T) {
 	})
 }
 
+func TestChunker_ChunkSentence_Abbreviations(t *testing.T) {
+	chunker := &Chunker{
+		Strategy:      ChunkSentence,
+		Abbreviations: DefaultAbbreviations,
+		TrimSpace:     true,
+	}
+
+	t.Run("does not split on titles", func(t *testing.T) {
+		text := "Dr. Smith met Mrs. Jones. They talked."
+		chunks := chunker.Chunk(text)
+
+		if len(chunks) != 2 {
+			t.Fatalf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+		}
+		if chunks[0] != "Dr. Smith met Mrs. Jones." {
+			t.Errorf("unexpected first chunk: %q", chunks[0])
+		}
+	})
+
+	t.Run("does not split on dotted abbreviations", func(t *testing.T) {
+		text := "Fruit, e.g. apples, grows in the U.S.A. and elsewhere. Done."
+		chunks := chunker.Chunk(text)
+
+		if len(chunks) != 2 {
+			t.Errorf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+		}
+	})
+
+	t.Run("matches case-insensitively", func(t *testing.T) {
+		chunks := chunker.Chunk("See DR. Who. Then leave.")
+
+		if len(chunks) != 2 {
+			t.Errorf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+		}
+	})
+
+	t.Run("does not split on decimals", func(t *testing.T) {
+		chunks := chunker.Chunk("Pi is 3.14 roughly. Next.")
+
+		if len(chunks) != 2 {
+			t.Fatalf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+		}
+		if chunks[0] != "Pi is 3.14 roughly." {
+			t.Errorf("unexpected first chunk: %q", chunks[0])
+		}
+	})
+
+	t.Run("empty list keeps simple behavior", func(t *testing.T) {
+		simple := &Chunker{Strategy: ChunkSentence, TrimSpace: true}
+		chunks := simple.Chunk("Dr. Smith left.")
+
+		if len(chunks) != 2 {
+			t.Errorf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+		}
+	})
+}
+
+func TestChunker_DropIncompleteTrailing(t *testing.T) {
+	t.Run("drops trailing fragment when enabled", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true, DropIncompleteTrailing: true}
+		chunks := chunker.Chunk("First sentence. Incomplete trailing")
+
+		if len(chunks) != 1 || chunks[0] != "First sentence." {
+			t.Errorf("expected only the complete sentence, got %q", chunks)
+		}
+	})
+
+	t.Run("holds back a sole incomplete sentence", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true, DropIncompleteTrailing: true}
+		chunks := chunker.Chunk("Incomplete only")
+
+		if len(chunks) != 0 {
+			t.Errorf("expected no chunks, got %q", chunks)
+		}
+	})
+
+	t.Run("keeps trailing fragment by default", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true}
+		chunks := chunker.Chunk("First sentence. Incomplete trailing")
+
+		if len(chunks) != 2 {
+			t.Errorf("expected 2 chunks, got %q", chunks)
+		}
+	})
+
+	t.Run("keeps trailing abbreviation", func(t *testing.T) {
+		chunker := &Chunker{
+			Strategy:               ChunkSentence,
+			Abbreviations:          DefaultAbbreviations,
+			TrimSpace:              true,
+			DropIncompleteTrailing: true,
+		}
+		chunks := chunker.Chunk("First sentence. Apples, pears, etc.")
+
+		if len(chunks) != 2 {
+			t.Errorf("expected 2 chunks, got %q", chunks)
+		}
+	})
+}
+
 func TestChunker_ChunkParagraph(t *testing.T) {
 	chunker := &Chunker{
 		Strategy:  ChunkParagraph,
@@ -167,6 +270,44 @@ func TestChunker_ChunkFixed(t *testing.T) {
 	})
 }
 
+func TestChunker_UnitOverlap(t *testing.T) {
+	t.Run("sentences", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true, UnitOverlap: 1}
+		chunks := chunker.Chunk("One. Two. Three. ")
+
+		want := []string{"One.", "One. Two.", "Two. Three."}
+		if !slices.Equal(chunks, want) {
+			t.Errorf("expected %q, got %q", want, chunks)
+		}
+	})
+
+	t.Run("paragraphs", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkParagraph, TrimSpace: true, UnitOverlap: 2}
+		chunks := chunker.Chunk("A.\n\nB.\n\n\n\nC.\n\nD.")
+
+		want := []string{"A.", "A.\n\nB.", "A.\n\nB.\n\nC.", "B.\n\nC.\n\nD."}
+		if !slices.Equal(chunks, want) {
+			t.Errorf("expected %q, got %q", want, chunks)
+		}
+	})
+
+	t.Run("first chunk has no context", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true, UnitOverlap: 3}
+		chunks := chunker.Chunk("Alpha. Beta.")
+		if chunks[0] != "Alpha." {
+			t.Errorf("expected first chunk unchanged, got %q", chunks[0])
+		}
+	})
+
+	t.Run("does not affect fixed chunks", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkFixed, MaxSize: 4, UnitOverlap: 1}
+		chunks := chunker.Chunk("abcdefgh")
+		if !slices.Equal(chunks, []string{"abcd", "efgh"}) {
+			t.Errorf("unexpected chunks %q", chunks)
+		}
+	})
+}
+
 func TestChunker_TrimSpace(t *testing.T) {
 	t.Run("trims whitespace when enabled", func(t *testing.T) {
 		chunker := &Chunker{
@@ -219,4 +360,148 @@ func TestDefaultChunker(t *testing.T) {
 	if !chunker.TrimSpace {
 		t.Error("expected TrimSpace to be true")
 	}
+
+	chunker.Abbreviations[0] = "changed"
+	if DefaultAbbreviations[0] == "changed" || DefaultChunker().Abbreviations[0] == "changed" {
+		t.Error("expected each default chunker to own its abbreviations")
+	}
+}
+
+// firstRuneProvider embeds each text as a 1-dim vector of its first rune.
+type firstRuneProvider struct{}
+
+func (firstRuneProvider) Name() string    { return "first-rune" }
+func (firstRuneProvider) Dimensions() int { return 1 }
+
+func (firstRuneProvider) Embed(_ context.Context, texts []string) (*EmbeddingResponse, error) {
+	vecs := make([]Vector, len(texts))
+	for i, text := range texts {
+		vecs[i] = Vector{float32([]rune(text)[0])}
+	}
+	return &EmbeddingResponse{Vectors: vecs, Model: "first-rune", Dimensions: 1}, nil
+}
+
+func TestChunker_PoolExcludeOverlap(t *testing.T) {
+	// Chunks: "aaaaabbbbb", "bbbbbccccc", "cccccddddd"
+	text := "aaaaabbbbbcccccddddd"
+	chunker := &Chunker{Strategy: ChunkFixed, MaxSize: 10, Overlap: 5}
+
+	t.Run("discounts overlapping chunks", func(t *testing.T) {
+		overlapChunker := *chunker
+		overlapChunker.PoolExcludeOverlap = true
+		svc := NewService(firstRuneProvider{}).WithChunker(&overlapChunker).WithNormalize(false)
+
+		vec, err := svc.Embed(context.Background(), text)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// Manually de-overlapped: first chunk counts fully, later chunks by half
+		expected := (97*1.0 + 98*0.5 + 99*0.5) / 2.0
+		if math.Abs(float64(vec[0])-expected) > 0.0001 {
+			t.Errorf("expected %f, got %f", expected, vec[0])
+		}
+	})
+
+	t.Run("plain mean when disabled", func(t *testing.T) {
+		svc := NewService(firstRuneProvider{}).WithChunker(chunker).WithNormalize(false)
+
+		vec, err := svc.Embed(context.Background(), text)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vec[0] != 98 {
+			t.Errorf("expected 98, got %f", vec[0])
+		}
+	})
+
+	t.Run("weights ignored without overlap", func(t *testing.T) {
+		c := &Chunker{Strategy: ChunkFixed, MaxSize: 10, PoolExcludeOverlap: true}
+		for i := 0; i < 3; i++ {
+			if c.poolWeight(c.Strategy, i) != 1 {
+				t.Errorf("expected weight 1 for chunk %d", i)
+			}
+		}
+	})
+}
+
+// tokenLimitedProvider reports a fixed token limit.
+type tokenLimitedProvider struct {
+	*mockProvider
+	maxTokens int
+}
+
+func (p *tokenLimitedProvider) MaxTokens() int { return p.maxTokens }
+
+func TestChunker_ChunkAuto(t *testing.T) {
+	tweet := "Just shipped the new release. Feedback welcome!"
+	article := strings.Repeat("Embeddings map text to vectors. Similar texts land close together. ", 10)
+	document := strings.Repeat(strings.Repeat("A paragraph of a structured document. ", 20)+"\n\n", 4)
+	transcript := strings.Repeat("so um yeah we were talking about the roadmap and ", 20)
+
+	tests := []struct {
+		name     string
+		text     string
+		expected ChunkStrategy
+	}{
+		{"short text is not chunked", tweet, ChunkNone},
+		{"mid-length prose splits by sentence", article, ChunkSentence},
+		{"long structured document splits by paragraph", document, ChunkParagraph},
+		{"unpunctuated text splits by size", transcript, ChunkFixed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auto := &Chunker{Strategy: ChunkAuto, MaxSize: 200, Overlap: 20, TrimSpace: true}
+			if got := auto.StrategyFor(tt.text); got != tt.expected {
+				t.Fatalf("expected %s, got %s", tt.expected, got)
+			}
+
+			direct := &Chunker{Strategy: tt.expected, MaxSize: 200, Overlap: 20, TrimSpace: true}
+			got, want := auto.Chunk(tt.text), direct.Chunk(tt.text)
+			if len(got) != len(want) {
+				t.Fatalf("expected %d chunks, got %d", len(want), len(got))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("chunk %d: expected %q, got %q", i, want[i], got[i])
+				}
+			}
+		})
+	}
+
+	t.Run("uses configured thresholds", func(t *testing.T) {
+		c := &Chunker{Strategy: ChunkAuto, Auto: AutoThresholds{ShortLength: 10}}
+		if got := c.StrategyFor(tweet); got != ChunkSentence {
+			t.Errorf("expected sentence for text over short length, got %s", got)
+		}
+
+		c.Auto = AutoThresholds{LongLength: 100, MinParagraphs: 2}
+		text := strings.Repeat("First paragraph sentence. ", 20) + "\n\n" + strings.Repeat("Second one. ", 20)
+		if got := c.StrategyFor(text); got != ChunkParagraph {
+			t.Errorf("expected paragraph with lowered thresholds, got %s", got)
+		}
+	})
+
+	t.Run("reports configured strategy when not auto", func(t *testing.T) {
+		c := &Chunker{Strategy: ChunkFixed}
+		if got := c.StrategyFor(tweet); got != ChunkFixed {
+			t.Errorf("expected fixed, got %s", got)
+		}
+	})
+}
+
+func TestSuggestChunkSize(t *testing.T) {
+	t.Run("uses half of provider context", func(t *testing.T) {
+		provider := &tokenLimitedProvider{mockProvider: newMockProvider(8), maxTokens: 8192}
+		if size := SuggestChunkSize(provider); size != 4096 {
+			t.Errorf("expected 4096, got %d", size)
+		}
+	})
+
+	t.Run("falls back without token limit", func(t *testing.T) {
+		if size := SuggestChunkSize(newMockProvider(8)); size != defaultSuggestedChunkTokens {
+			t.Errorf("expected %d, got %d", defaultSuggestedChunkTokens, size)
+		}
+	})
 }
@@ -0,0 +1,64 @@
+package vex
+
+import (
+	"cmp"
+	"slices"
+)
+
+// ChunkEmbedding is one chunk of a document and its vector, as returned in
+// pairs by Service.EmbedChunks.
+type ChunkEmbedding struct {
+	Text   string
+	Vector Vector
+}
+
+// ChunkMatch is the result of matching a query against a document's chunks.
+type ChunkMatch struct {
+	Index  int       // Best-matching chunk, or -1 for a document without chunks
+	Score  float64   // Similarity of the best-matching chunk
+	Scores []float64 // Similarity of every chunk, in document order
+}
+
+// MatchChunks scores query against each of a document's chunks under metric
+// and reports the best one. Scoring a document by its best chunk rather than
+// its pooled vector keeps a relevant passage from being averaged away among
+// unrelated ones, at the cost of storing every chunk vector.
+func MatchChunks(query Vector, doc []ChunkEmbedding, metric SimilarityMetric) ChunkMatch {
+	match := ChunkMatch{Index: -1, Scores: make([]float64, len(doc))}
+	for i, chunk := range doc {
+		score := query.Similarity(chunk.Vector, metric)
+		match.Scores[i] = score
+		if match.Index < 0 || score > match.Score {
+			match.Index = i
+			match.Score = score
+		}
+	}
+	return match
+}
+
+// ChunkedDocument is a document stored as per-chunk vectors.
+type ChunkedDocument struct {
+	ID     string
+	Chunks []ChunkEmbedding
+}
+
+// RankChunked orders docs by their best chunk's similarity to query, most
+// similar first, returning at most k matches (all when k <= 0). Documents
+// without chunks are left out.
+func RankChunked(query Vector, docs []ChunkedDocument, metric SimilarityMetric, k int) []Match {
+	matches := make([]Match, 0, len(docs))
+	for _, doc := range docs {
+		best := MatchChunks(query, doc.Chunks, metric)
+		if best.Index < 0 {
+			continue
+		}
+		matches = append(matches, Match{ID: doc.ID, Score: best.Score})
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
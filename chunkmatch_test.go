@@ -0,0 +1,68 @@
+package vex
+
+import "testing"
+
+func TestMatchChunks(t *testing.T) {
+	t.Run("finds the best chunk", func(t *testing.T) {
+		doc := []ChunkEmbedding{
+			{Text: "intro", Vector: Vector{0, 1}},
+			{Text: "middle", Vector: Vector{0.6, 0.8}},
+			{Text: "answer", Vector: Vector{1, 0}},
+		}
+		match := MatchChunks(Vector{1, 0}, doc, Cosine)
+		if match.Index != 2 || match.Score < 0.999 {
+			t.Errorf("expected chunk 2 with score 1, got %d with %v", match.Index, match.Score)
+		}
+		if len(match.Scores) != 3 || match.Scores[0] != 0 {
+			t.Errorf("unexpected per-chunk scores %v", match.Scores)
+		}
+	})
+
+	t.Run("empty document", func(t *testing.T) {
+		match := MatchChunks(Vector{1, 0}, nil, Cosine)
+		if match.Index != -1 || len(match.Scores) != 0 {
+			t.Errorf("expected no match, got %+v", match)
+		}
+	})
+}
+
+func TestRankChunked(t *testing.T) {
+	query := Vector{1, 0, 0}
+
+	// The relevant passage is the last of several unrelated chunks, so
+	// pooling dilutes it below a document that is uniformly lukewarm.
+	long := ChunkedDocument{ID: "long", Chunks: []ChunkEmbedding{
+		{Vector: Vector{0, 1, 0}},
+		{Vector: Vector{0, 0, 1}},
+		{Vector: Vector{0, 1, 0}},
+		{Vector: Vector{0, 0, 1}},
+		{Vector: Vector{1, 0, 0}},
+	}}
+	lukewarm := ChunkedDocument{ID: "lukewarm", Chunks: []ChunkEmbedding{
+		{Vector: Vector{0.6, 0.8, 0}},
+	}}
+	docs := []ChunkedDocument{lukewarm, long, {ID: "empty"}}
+
+	pooled := func(doc ChunkedDocument) float64 {
+		vecs := make([]Vector, len(doc.Chunks))
+		for i, c := range doc.Chunks {
+			vecs[i] = c.Vector
+		}
+		return query.Similarity(Pool(vecs, PoolMean), Cosine)
+	}
+	if pooled(long) >= pooled(lukewarm) {
+		t.Fatalf("test setup: expected pooled scoring to miss the late chunk")
+	}
+
+	matches := RankChunked(query, docs, Cosine, 0)
+	if len(matches) != 2 {
+		t.Fatalf("expected empty document to be skipped, got %v", matches)
+	}
+	if matches[0].ID != "long" || matches[1].ID != "lukewarm" {
+		t.Errorf("expected max-chunk scoring to rank the late passage first, got %v", matches)
+	}
+
+	if top := RankChunked(query, docs, Cosine, 1); len(top) != 1 || top[0].ID != "long" {
+		t.Errorf("expected top-1 to be long, got %v", top)
+	}
+}
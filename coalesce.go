@@ -0,0 +1,202 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zoobzio/pipz"
+)
+
+// coalescer merges small requests arriving within a window into one pass
+// through its processor and hands each caller its own slice of the result.
+// It works like MicroBatchingProvider but at the pipeline level, so options
+// inside it (retries, timeouts, rate limits) apply to the merged request.
+type coalescer struct {
+	identity  pipz.Identity
+	processor pipz.Chainable[*EmbedRequest]
+	timer     *time.Timer
+	pending   []*coalescedCall
+	window    time.Duration
+	maxBatch  int
+	size      int
+	mu        sync.Mutex
+	closeOnce sync.Once
+	closeErr  error
+}
+
+type coalescedCall struct {
+	ctx    context.Context
+	req    *EmbedRequest
+	result chan microBatchResult
+}
+
+func newCoalescer(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], window time.Duration, maxBatch int) *coalescer {
+	if maxBatch <= 0 {
+		maxBatch = 1
+	}
+	return &coalescer{
+		identity:  identity,
+		processor: processor,
+		window:    window,
+		maxBatch:  maxBatch,
+	}
+}
+
+// Process implements pipz.Chainable.
+func (c *coalescer) Process(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+	// Titled requests carry per-text metadata the merge would have to
+	// thread through; they are rare enough to send on their own. Tagged
+	// requests are too, since processors may treat them differently, and
+	// so are requests routed to another provider with WithProviderOverride,
+	// which the merged request would not honor.
+	if len(req.Texts) == 0 || len(req.Texts) >= c.maxBatch || req.Titles != nil || req.Tags != nil || hasProviderOverride(ctx) {
+		return c.processor.Process(ctx, req)
+	}
+
+	call := &coalescedCall{
+		ctx:    ctx,
+		req:    req,
+		result: make(chan microBatchResult, 1),
+	}
+
+	c.mu.Lock()
+	if c.size+len(req.Texts) > c.maxBatch {
+		c.flushLocked()
+	}
+	c.pending = append(c.pending, call)
+	c.size += len(req.Texts)
+	if c.size >= c.maxBatch {
+		c.flushLocked()
+	} else if len(c.pending) == 1 {
+		c.timer = time.AfterFunc(c.window, c.flush)
+	}
+	c.mu.Unlock()
+
+	// The dispatcher never touches req, so a caller that gives up can
+	// return it safely while the merged request is still in flight.
+	select {
+	case res := <-call.result:
+		req.Response = res.resp
+		req.Error = res.err
+		return req, res.err
+	case <-ctx.Done():
+		req.Error = ctx.Err()
+		return req, req.Error
+	}
+}
+
+// flush dispatches pending calls when the window expires.
+func (c *coalescer) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+}
+
+// flushLocked detaches the pending calls and dispatches them. Caller holds mu.
+func (c *coalescer) flushLocked() {
+	if len(c.pending) == 0 {
+		return
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	calls := c.pending
+	c.pending = nil
+	c.size = 0
+	go c.dispatch(calls)
+}
+
+// dispatch runs one merged request for calls and fans out the results.
+func (c *coalescer) dispatch(calls []*coalescedCall) {
+	if len(calls) == 1 {
+		calls[0].result <- c.processAlone(calls[0])
+		return
+	}
+
+	// The merged request gets its own ID so that it is not reported, or
+	// sent to the provider, as any one caller's request.
+	first := calls[0].req
+	merged := &EmbedRequest{
+		RequestID:     uuid.New().String(),
+		Provider:      first.Provider,
+		stats:         first.stats,
+		retryTimeouts: first.retryTimeouts,
+	}
+	sizes := make([]int, len(calls))
+	for i, call := range calls {
+		merged.Texts = append(merged.Texts, call.req.Texts...)
+		sizes[i] = len(call.req.Texts)
+	}
+
+	ctxs := make([]context.Context, len(calls))
+	for i, call := range calls {
+		ctxs[i] = call.ctx
+	}
+	ctx, cancel := mergedContext(ctxs)
+	defer cancel()
+	ctx = context.WithValue(ctx, requestIDKey{}, merged.RequestID)
+	out, err := c.processor.Process(ctx, merged)
+	if err == nil && (out == nil || out.Response == nil || out.Response.count() != len(merged.Texts)) {
+		err = fmt.Errorf("coalesce: expected %d vectors from provider", len(merged.Texts))
+	}
+	if err != nil {
+		// A permanent rejection is usually caused by one caller's input,
+		// so each caller is retried alone to confine the failure to it.
+		var perr *ProviderError
+		isolate := errors.As(err, &perr) && !perr.Retryable()
+		for _, call := range calls {
+			if isolate {
+				go func() { call.result <- c.processAlone(call) }()
+				continue
+			}
+			call.result <- microBatchResult{err: err}
+		}
+		return
+	}
+
+	for i, part := range splitResponse(out.Response, sizes) {
+		calls[i].result <- microBatchResult{resp: part}
+	}
+}
+
+// processAlone sends call's request through the processor by itself, on a
+// copy so the caller's request is never written concurrently.
+func (c *coalescer) processAlone(call *coalescedCall) microBatchResult {
+	req := *call.req
+	out, err := c.processor.Process(call.ctx, &req)
+	if err != nil {
+		return microBatchResult{err: err}
+	}
+	return microBatchResult{resp: out.Response}
+}
+
+// Identity implements pipz.Chainable.
+func (c *coalescer) Identity() pipz.Identity {
+	return c.identity
+}
+
+// Schema implements pipz.Chainable.
+func (c *coalescer) Schema() pipz.Node {
+	return pipz.Node{
+		Identity: c.identity,
+		Type:     "coalesce",
+		Flow:     pipz.ConcurrentFlow{Tasks: []pipz.Node{c.processor.Schema()}},
+		Metadata: map[string]any{
+			"window":    c.window.String(),
+			"max_batch": c.maxBatch,
+		},
+	}
+}
+
+// Close implements pipz.Chainable.
+func (c *coalescer) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.processor.Close()
+	})
+	return c.closeErr
+}
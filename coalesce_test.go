@@ -0,0 +1,260 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pickyProvider rejects any batch containing "bad" with a 400.
+type pickyProvider struct {
+	calls atomic.Int32
+}
+
+func (*pickyProvider) Name() string    { return "picky" }
+func (*pickyProvider) Dimensions() int { return 1 }
+func (p *pickyProvider) Embed(_ context.Context, texts []string) (*EmbeddingResponse, error) {
+	p.calls.Add(1)
+	vecs := make([]Vector, len(texts))
+	for i, text := range texts {
+		if text == "bad" {
+			return nil, &ProviderError{Provider: "picky", StatusCode: 400, Message: "invalid input"}
+		}
+		vecs[i] = Vector{float32(len(text))}
+	}
+	return &EmbeddingResponse{Vectors: vecs, Dimensions: 1}, nil
+}
+
+// idProvider records the request ID each call was sent under and returns
+// quantized embeddings alongside a warning.
+type idProvider struct {
+	name string
+	ids  []string
+	mu   sync.Mutex
+}
+
+func (p *idProvider) Name() string  { return p.name }
+func (*idProvider) Dimensions() int { return 1 }
+func (p *idProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	p.mu.Lock()
+	p.ids = append(p.ids, RequestIDFromContext(ctx))
+	p.mu.Unlock()
+	resp := &EmbeddingResponse{Dimensions: 1, Truncated: 1, Warnings: []string{"input truncated"}}
+	for _, text := range texts {
+		resp.Vectors = append(resp.Vectors, Vector{float32(len(text))})
+		resp.Int8Vectors = append(resp.Int8Vectors, []int8{int8(len(text))})
+	}
+	return resp, nil
+}
+
+func TestWithCoalescing(t *testing.T) {
+	t.Run("merges concurrent embeds", func(t *testing.T) {
+		provider := &recordingProvider{dims: 2}
+		svc := NewService(provider, WithCoalescing(20*time.Millisecond, 64)).WithNormalize(false)
+
+		const callers = 50
+		results := make([]Vector, callers)
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				vec, err := svc.Embed(context.Background(), strings.Repeat("x", i+1))
+				if err != nil {
+					t.Errorf("caller %d: unexpected error: %v", i, err)
+					return
+				}
+				results[i] = vec
+			}()
+		}
+		wg.Wait()
+
+		if calls := len(provider.calls()); calls > 10 {
+			t.Errorf("expected far fewer than %d provider calls, got %d", callers, calls)
+		}
+		for i, vec := range results {
+			if vec == nil || vec[0] != float32(i+1) {
+				t.Errorf("caller %d: expected its own vector, got %v", i, vec)
+			}
+		}
+		stats := svc.Stats()
+		if stats.TotalTokens != callers {
+			t.Errorf("expected usage to be apportioned across callers, got %d tokens", stats.TotalTokens)
+		}
+	})
+
+	t.Run("sends after the window", func(t *testing.T) {
+		provider := &recordingProvider{dims: 1}
+		svc := NewService(provider, WithCoalescing(30*time.Millisecond, 64))
+
+		start := time.Now()
+		if _, err := svc.Embed(context.Background(), "alone"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("expected a lone request to be sent after the window, took %v", elapsed)
+		}
+	})
+
+	t.Run("isolates a rejected input", func(t *testing.T) {
+		provider := &pickyProvider{}
+		svc := NewService(provider, WithCoalescing(20*time.Millisecond, 64)).WithNormalize(false)
+
+		texts := []string{"a", "bb", "bad", "cccc"}
+		errs := make([]error, len(texts))
+		var wg sync.WaitGroup
+		for i, text := range texts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = svc.Embed(context.Background(), text)
+			}()
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			var perr *ProviderError
+			if texts[i] == "bad" {
+				if !errors.As(err, &perr) || perr.StatusCode != 400 {
+					t.Errorf("expected bad input to fail with its 400, got %v", err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("caller %q: expected success despite a bad neighbor, got %v", texts[i], err)
+			}
+		}
+	})
+
+	t.Run("does not merge provider overrides", func(t *testing.T) {
+		bound := &idProvider{name: "free"}
+		premium := &idProvider{name: "premium"}
+		svc := NewService(bound, WithCoalescing(20*time.Millisecond, 64)).WithNormalize(false)
+
+		var wg sync.WaitGroup
+		for i := range 6 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := context.Background()
+				if i%2 == 1 {
+					ctx = WithProviderOverride(ctx, premium)
+				}
+				if _, err := svc.Embed(ctx, "text"); err != nil {
+					t.Errorf("caller %d: unexpected error: %v", i, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if n := len(premium.ids); n != 3 {
+			t.Errorf("expected each override caller to reach its provider, got %d calls", n)
+		}
+		if n := len(bound.ids); n == 0 || n > 2 {
+			t.Errorf("expected the other callers to be merged, got %d calls", n)
+		}
+	})
+
+	t.Run("merged request has its own ID", func(t *testing.T) {
+		provider := &idProvider{name: "ids"}
+		svc := NewService(provider, WithCoalescing(20*time.Millisecond, 64))
+
+		started := recordEvents(t, EmbedStarted, "ids")
+
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := svc.Embed(context.Background(), "text"); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		var callerIDs []string
+		for _, e := range started.Events(t) {
+			id, _ := RequestIDKey.From(e)
+			callerIDs = append(callerIDs, id)
+		}
+		if len(provider.ids) != 1 {
+			t.Fatalf("expected one merged call, got %d", len(provider.ids))
+		}
+		if provider.ids[0] == "" || slices.Contains(callerIDs, provider.ids[0]) {
+			t.Errorf("expected a request ID of its own, got %q for callers %v", provider.ids[0], callerIDs)
+		}
+	})
+
+	t.Run("passes quantized vectors and warnings to each caller", func(t *testing.T) {
+		provider := &idProvider{name: "ids"}
+		svc := NewService(provider, WithCoalescing(20*time.Millisecond, 64))
+
+		resps := make([]*EmbeddingResponse, 3)
+		var wg sync.WaitGroup
+		for i := range resps {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := svc.BatchResponse(context.Background(), []string{strings.Repeat("x", i+1)})
+				if err != nil {
+					t.Errorf("caller %d: unexpected error: %v", i, err)
+				}
+				resps[i] = resp
+			}()
+		}
+		wg.Wait()
+
+		if len(provider.ids) != 1 {
+			t.Fatalf("expected one merged call, got %d", len(provider.ids))
+		}
+		for i, resp := range resps {
+			if resp == nil {
+				continue
+			}
+			if len(resp.Int8Vectors) != 1 || resp.Int8Vectors[0][0] != int8(i+1) {
+				t.Errorf("caller %d: expected its own int8 vector, got %v", i, resp.Int8Vectors)
+			}
+			if resp.Truncated != 1 || len(resp.Warnings) != 1 {
+				t.Errorf("caller %d: expected truncation and warnings passed through, got %d, %v", i, resp.Truncated, resp.Warnings)
+			}
+		}
+	})
+
+	t.Run("cancels the merged request once every caller is done", func(t *testing.T) {
+		provider := &blockingProvider{seen: make(chan context.Context, 1)}
+		svc := NewService(provider, WithCoalescing(time.Hour, 2))
+
+		ctx1, cancel1 := context.WithCancel(context.Background())
+		ctx2, cancel2 := context.WithCancel(context.Background())
+		for _, ctx := range []context.Context{ctx1, ctx2} {
+			go svc.Embed(ctx, "x") //nolint:errcheck // canceled below
+		}
+
+		merged := <-provider.seen
+		cancel1()
+		cancel2()
+		select {
+		case <-merged.Done():
+		case <-time.After(time.Second):
+			t.Error("expected the merged request to be canceled")
+		}
+	})
+
+	t.Run("passes large batches through", func(t *testing.T) {
+		provider := &recordingProvider{dims: 1}
+		svc := NewService(provider, WithCoalescing(time.Hour, 2))
+
+		if _, err := svc.Batch(context.Background(), []string{"a", "b", "c"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls := provider.calls(); len(calls) != 1 || calls[0] != 3 {
+			t.Errorf("expected one direct call with 3 texts, got %v", calls)
+		}
+	})
+}
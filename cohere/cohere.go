@@ -4,13 +4,20 @@ package cohere
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/zoobzio/vex"
+	"github.com/zoobzio/vex/internal/batching"
+	"github.com/zoobzio/vex/internal/httputil"
 )
 
 // Default dimensions for Cohere models.
@@ -19,6 +26,16 @@ const (
 	DimensionsEmbedMultiV3   = 1024
 )
 
+// MaxTokensEmbedV3 is the input token limit for Cohere v3 embedding models.
+const MaxTokensEmbedV3 = 512
+
+// DefaultMaxBatchSize is the maximum number of texts per embed request.
+const DefaultMaxBatchSize = 96
+
+// DefaultImageConcurrency is the number of image requests EmbedImages keeps
+// in flight. The API accepts one image per request.
+const DefaultImageConcurrency = 4
+
 // InputType specifies the type of text being embedded.
 type InputType string
 
@@ -28,16 +45,44 @@ const (
 	InputTypeSearchQuery    InputType = "search_query"
 	InputTypeClassification InputType = "classification"
 	InputTypeClustering     InputType = "clustering"
+	InputTypeImage          InputType = "image"
+)
+
+// Embedding type constants for Config.EmbeddingTypes.
+const (
+	EmbeddingTypeFloat   = "float"
+	EmbeddingTypeInt8    = "int8"
+	EmbeddingTypeUint8   = "uint8"
+	EmbeddingTypeBinary  = "binary"
+	EmbeddingTypeUbinary = "ubinary"
+)
+
+// TruncateMode specifies how inputs longer than the model's context are handled.
+type TruncateMode string
+
+// Truncate mode constants.
+const (
+	TruncateNone  TruncateMode = "NONE"  // Reject overlong inputs with vex.ErrContextLengthExceeded
+	TruncateStart TruncateMode = "START" // Discard the start of overlong inputs
+	TruncateEnd   TruncateMode = "END"   // Discard the end of overlong inputs
 )
 
 // Provider implements vex.Provider for Cohere embeddings API.
 type Provider struct {
-	httpClient *http.Client
-	apiKey     string
-	model      string
-	baseURL    string
-	inputType  InputType
-	dimensions int
+	httpClient     *http.Client
+	apiKey         string
+	model          string
+	baseURL        string
+	inputType      InputType
+	truncate       TruncateMode
+	embeddingTypes []string
+	configErr      error
+	dimensions     int
+	maxBatchSize   int
+	imageWorkers   int
+	extra          map[string]any
+	headers        map[string]string
+	v2             bool
 }
 
 // Config holds configuration for the Cohere embedding provider.
@@ -48,6 +93,41 @@ type Config struct {
 	InputType  InputType
 	Dimensions int
 	Timeout    time.Duration
+
+	// EmbeddingTypes selects the embedding formats to return, e.g.
+	// EmbeddingTypeInt8 for cheaper storage. Float results populate
+	// EmbeddingResponse.Vectors, int8 and uint8 populate Int8Vectors (uint8
+	// shifted by -128), and binary and ubinary populate BinaryVectors.
+	// Optional, defaults to float only.
+	EmbeddingTypes []string
+
+	// Truncate controls how inputs longer than the model's context are
+	// handled: TruncateStart or TruncateEnd, or TruncateNone to reject them
+	// with a ProviderError wrapping vex.ErrContextLengthExceeded. Optional;
+	// when empty the API default applies. Other values make Embed fail.
+	Truncate TruncateMode
+
+	// MaxBatchSize caps texts per API request; larger batches are split
+	// into sequential sub-requests. Optional, defaults to DefaultMaxBatchSize.
+	MaxBatchSize int
+
+	// ImageConcurrency caps the image requests EmbedImages runs in parallel.
+	// Optional, defaults to DefaultImageConcurrency.
+	ImageConcurrency int
+
+	// Extra holds additional request body fields, e.g. for newly released
+	// API parameters, merged into every embed request. Fields the provider
+	// sets itself are not overridden. Optional.
+	Extra map[string]any
+
+	// Headers are added to every request, e.g. for a gateway that needs
+	// tenant or trace headers. They are applied last and win over the
+	// provider's own headers. Optional.
+	Headers map[string]string
+
+	// HTTPClient is used for all requests when set, e.g. for proxies, mTLS,
+	// or instrumented transports. Timeout is applied if the client has none.
+	HTTPClient *http.Client
 }
 
 // New creates a new Cohere embedding provider.
@@ -67,19 +147,48 @@ func New(config Config) *Provider {
 	if config.InputType == "" {
 		config.InputType = InputTypeSearchDocument
 	}
+	if config.MaxBatchSize == 0 {
+		config.MaxBatchSize = DefaultMaxBatchSize
+	}
+	if config.ImageConcurrency == 0 {
+		config.ImageConcurrency = DefaultImageConcurrency
+	}
+
+	var configErr error
+	switch config.Truncate {
+	case "", TruncateNone, TruncateStart, TruncateEnd:
+	default:
+		configErr = fmt.Errorf("cohere: invalid truncate %q, must be NONE, START, or END", config.Truncate)
+	}
 
 	return &Provider{
-		apiKey:     config.APIKey,
-		model:      config.Model,
-		baseURL:    config.BaseURL,
-		dimensions: config.Dimensions,
-		inputType:  config.InputType,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+		configErr:      configErr,
+		truncate:       config.Truncate,
+		apiKey:         config.APIKey,
+		model:          config.Model,
+		baseURL:        config.BaseURL,
+		dimensions:     config.Dimensions,
+		inputType:      config.InputType,
+		embeddingTypes: config.EmbeddingTypes,
+		maxBatchSize:   config.MaxBatchSize,
+		imageWorkers:   config.ImageConcurrency,
+		extra:          maps.Clone(config.Extra),
+		headers:        maps.Clone(config.Headers),
+		httpClient:     httputil.NewClient(config.HTTPClient, config.Timeout),
 	}
 }
 
+// NewV2 creates a Cohere embedding provider using the v2 embed endpoint.
+// BaseURL defaults to "https://api.cohere.com/v2"; other defaults match New.
+func NewV2(config Config) *Provider {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.cohere.com/v2"
+	}
+	p := New(config)
+	p.v2 = true
+	return p
+}
+
 // Name returns the provider identifier.
 func (*Provider) Name() string {
 	return "cohere"
@@ -90,6 +199,18 @@ func (p *Provider) Dimensions() int {
 	return p.dimensions
 }
 
+// MaxTokens returns the per-input token limit.
+// Implements vex.TokenLimiter.
+func (*Provider) MaxTokens() int {
+	return MaxTokensEmbedV3
+}
+
+// Model returns the requested model identifier.
+// Implements vex.ModelReporter.
+func (p *Provider) Model() string {
+	return p.model
+}
+
 // WithInputType returns a new provider with the specified input type.
 func (p *Provider) WithInputType(inputType InputType) *Provider {
 	newP := *p
@@ -104,7 +225,11 @@ func (p *Provider) ForQuery() vex.Provider {
 }
 
 // Embed generates embeddings for the given texts.
+// Batches larger than the configured MaxBatchSize are split transparently.
 func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+	if p.configErr != nil {
+		return nil, p.configErr
+	}
 	if len(texts) == 0 {
 		return &vex.EmbeddingResponse{
 			Vectors:    nil,
@@ -112,14 +237,123 @@ func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingRes
 			Dimensions: p.dimensions,
 		}, nil
 	}
+	return batching.Embed(ctx, texts, p.maxBatchSize, p.embed)
+}
+
+// EmbedImages generates embeddings for the given encoded images (PNG, JPEG,
+// GIF or WebP), returned in input order. Each image is sent as a data URI in
+// its own request with input type "image"; at most ImageConcurrency requests
+// run at once. Implements vex.ImageProvider.
+func (p *Provider) EmbedImages(ctx context.Context, images [][]byte) (*vex.EmbeddingResponse, error) {
+	if p.configErr != nil {
+		return nil, p.configErr
+	}
+	result := &vex.EmbeddingResponse{
+		Model:      p.model,
+		Dimensions: p.dimensions,
+	}
+	if len(images) == 0 {
+		return result, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]*vex.EmbeddingResponse, len(images))
+	errs := make([]error, len(images))
+	sem := make(chan struct{}, p.imageWorkers)
+	var wg sync.WaitGroup
+	for i, img := range images {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+		if errs[i] != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := p.embedImage(ctx, img)
+			if err != nil {
+				errs[i] = fmt.Errorf("image %d: %w", i, err)
+				cancel()
+				return
+			}
+			responses[i] = resp
+		}()
+	}
+	wg.Wait()
+
+	// Report the first failure by index; requests cancelled in its wake
+	// come later or carry only the context error.
+	var firstErr error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if firstErr == nil || (errors.Is(firstErr, context.Canceled) && !errors.Is(err, context.Canceled)) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	for _, resp := range responses {
+		result.Vectors = append(result.Vectors, resp.Vectors...)
+		result.Int8Vectors = append(result.Int8Vectors, resp.Int8Vectors...)
+		result.BinaryVectors = append(result.BinaryVectors, resp.BinaryVectors...)
+		result.Usage.PromptTokens += resp.Usage.PromptTokens
+		result.Usage.TotalTokens += resp.Usage.TotalTokens
+		result.Warnings = append(result.Warnings, resp.Warnings...)
+		result.Dimensions = resp.Dimensions
+	}
+	return result, nil
+}
+
+// embedImage issues a single embed request for one image.
+func (p *Provider) embedImage(ctx context.Context, img []byte) (*vex.EmbeddingResponse, error) {
+	uri := "data:" + http.DetectContentType(img) + ";base64," + base64.StdEncoding.EncodeToString(img)
+	return p.send(ctx, p.request(nil, []string{uri}, InputTypeImage))
+}
 
-	reqBody := embeddingRequest{
-		Model:     p.model,
-		Texts:     texts,
-		InputType: string(p.inputType),
+// embed issues a single embed request.
+func (p *Provider) embed(ctx context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+	return p.send(ctx, p.request(texts, nil, p.inputType))
+}
+
+// request builds the embed request body for the configured API version.
+func (p *Provider) request(texts, images []string, inputType InputType) interface{} {
+	if p.v2 {
+		types := p.embeddingTypes
+		if len(types) == 0 {
+			types = []string{EmbeddingTypeFloat}
+		}
+		return embeddingRequestV2{
+			Model:          p.model,
+			Texts:          texts,
+			Images:         images,
+			InputType:      string(inputType),
+			EmbeddingTypes: types,
+			Truncate:       p.truncate,
+		}
+	}
+	return embeddingRequest{
+		Model:          p.model,
+		Texts:          texts,
+		Images:         images,
+		InputType:      string(inputType),
+		EmbeddingTypes: p.embeddingTypes,
+		Truncate:       p.truncate,
 	}
+}
 
-	jsonBody, err := json.Marshal(reqBody)
+// send posts reqBody to the embed endpoint and parses the result.
+func (p *Provider) send(ctx context.Context, reqBody interface{}) (*vex.EmbeddingResponse, error) {
+	jsonBody, err := httputil.MarshalBody(reqBody, p.extra)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -130,7 +364,9 @@ func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingRes
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	httputil.SetRequestID(req)
 	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	httputil.SetHeaders(req, p.headers)
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
@@ -138,43 +374,83 @@ func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingRes
 	}
 	defer resp.Body.Close()
 
+	if err := httputil.CheckRedirect(req, resp); err != nil {
+		return nil, err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		perr := &vex.ProviderError{
+			Provider:   "cohere",
+			StatusCode: resp.StatusCode,
+			RetryAfter: httputil.RetryAfter(resp.Header, time.Now()),
+		}
 		var errResp errorResponse
-		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
-			return nil, fmt.Errorf("cohere error (%d): %s", resp.StatusCode, errResp.Message)
+		if err := json.Unmarshal(body, &errResp); err == nil {
+			perr.Message = errResp.Message
 		}
-		return nil, fmt.Errorf("cohere error: status %d", resp.StatusCode)
+		if isContextLengthError(perr) {
+			perr.Err = vex.ErrContextLengthExceeded
+		}
+		return nil, perr
 	}
 
-	var embResp embeddingResponse
-	if err := json.Unmarshal(body, &embResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	result := &vex.EmbeddingResponse{
+		Model:      p.model,
+		Dimensions: p.dimensions,
 	}
-
-	vectors := make([]vex.Vector, len(embResp.Embeddings))
-	for i, emb := range embResp.Embeddings {
-		vectors[i] = toFloat32(emb)
+	var usage meta
+	if p.v2 || len(p.embeddingTypes) > 0 {
+		// Requests with embedding_types get embeddings keyed by type in both API versions.
+		var embResp embeddingResponseV2
+		if err := json.Unmarshal(body, &embResp); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		embResp.Embeddings.fill(result)
+		usage = embResp.Meta
+	} else {
+		var embResp embeddingResponse
+		if err := json.Unmarshal(body, &embResp); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		result.Vectors = toVectors(embResp.Embeddings)
+		usage = embResp.Meta
 	}
 
-	dims := p.dimensions
-	if len(vectors) > 0 && len(vectors[0]) > 0 {
-		dims = len(vectors[0])
+	if err := vex.ValidateVectors("cohere", result.Vectors); err != nil {
+		return nil, err
+	}
+	if len(result.Vectors) > 0 {
+		result.Dimensions = len(result.Vectors[0])
+	}
+	result.Usage = vex.Usage{
+		PromptTokens: usage.inputTokens(),
+		TotalTokens:  usage.inputTokens(),
 	}
+	result.Warnings = usage.Warnings
+	return result, nil
+}
 
-	return &vex.EmbeddingResponse{
-		Vectors:    vectors,
-		Model:      p.model,
-		Dimensions: dims,
-		Usage: vex.Usage{
-			PromptTokens: embResp.Meta.BilledUnits.InputTokens,
-			TotalTokens:  embResp.Meta.BilledUnits.InputTokens,
-		},
-	}, nil
+// isContextLengthError reports whether perr is Cohere's rejection of an
+// input longer than the model's context, returned when truncate is NONE.
+func isContextLengthError(perr *vex.ProviderError) bool {
+	return perr.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(perr.Message), "too many tokens")
+}
+
+// toVectors converts float64 embeddings to vex.Vectors.
+func toVectors(embeddings [][]float64) []vex.Vector {
+	if embeddings == nil {
+		return nil
+	}
+	vectors := make([]vex.Vector, len(embeddings))
+	for i, emb := range embeddings {
+		vectors[i] = toFloat32(emb)
+	}
+	return vectors
 }
 
 // toFloat32 converts a float64 slice to a vex.Vector (float32).
@@ -189,9 +465,12 @@ func toFloat32(f64 []float64) vex.Vector {
 // API types
 
 type embeddingRequest struct {
-	Model     string   `json:"model"`
-	InputType string   `json:"input_type"`
-	Texts     []string `json:"texts"`
+	Model          string       `json:"model"`
+	InputType      string       `json:"input_type"`
+	Texts          []string     `json:"texts,omitempty"`
+	Images         []string     `json:"images,omitempty"`
+	EmbeddingTypes []string     `json:"embedding_types,omitempty"`
+	Truncate       TruncateMode `json:"truncate,omitempty"`
 }
 
 type embeddingResponse struct {
@@ -200,8 +479,82 @@ type embeddingResponse struct {
 	Meta       meta        `json:"meta"`
 }
 
+type embeddingRequestV2 struct {
+	Model          string       `json:"model"`
+	InputType      string       `json:"input_type"`
+	Texts          []string     `json:"texts,omitempty"`
+	Images         []string     `json:"images,omitempty"`
+	EmbeddingTypes []string     `json:"embedding_types"`
+	Truncate       TruncateMode `json:"truncate,omitempty"`
+}
+
+type embeddingResponseV2 struct {
+	ID         string             `json:"id"`
+	Embeddings embeddingsByTypeV2 `json:"embeddings"`
+	Meta       meta               `json:"meta"`
+}
+
+type embeddingsByTypeV2 struct {
+	Float   [][]float64 `json:"float"`
+	Int8    [][]int8    `json:"int8"`
+	Uint8   [][]uint8   `json:"uint8"`
+	Binary  [][]int8    `json:"binary"`
+	Ubinary [][]uint8   `json:"ubinary"`
+}
+
+// fill copies each embedding type into its response field. Cohere encodes
+// the signed types as the unsigned value minus 128, so uint8 results are
+// shifted down to int8 and binary results shifted up to recover the packed
+// bits. The types that need no conversion win when both are present.
+func (e *embeddingsByTypeV2) fill(resp *vex.EmbeddingResponse) {
+	resp.Vectors = toVectors(e.Float)
+
+	switch {
+	case e.Int8 != nil:
+		resp.Int8Vectors = e.Int8
+	case e.Uint8 != nil:
+		resp.Int8Vectors = make([][]int8, len(e.Uint8))
+		for i, emb := range e.Uint8 {
+			v := make([]int8, len(emb))
+			for j, u := range emb {
+				v[j] = int8(int(u) - 128)
+			}
+			resp.Int8Vectors[i] = v
+		}
+	}
+
+	switch {
+	case e.Ubinary != nil:
+		resp.BinaryVectors = e.Ubinary
+	case e.Binary != nil:
+		resp.BinaryVectors = make([][]byte, len(e.Binary))
+		for i, emb := range e.Binary {
+			v := make([]byte, len(emb))
+			for j, b := range emb {
+				v[j] = byte(int(b) + 128)
+			}
+			resp.BinaryVectors[i] = v
+		}
+	}
+}
+
 type meta struct {
 	BilledUnits billedUnits `json:"billed_units"`
+	Tokens      tokens      `json:"tokens"`
+	Warnings    []string    `json:"warnings"`
+}
+
+// inputTokens returns billed input tokens, falling back to the raw token
+// count that v2 reports alongside billed units.
+func (m meta) inputTokens() int {
+	if m.BilledUnits.InputTokens > 0 {
+		return m.BilledUnits.InputTokens
+	}
+	return m.Tokens.InputTokens
+}
+
+type tokens struct {
+	InputTokens int `json:"input_tokens"`
 }
 
 type billedUnits struct {
@@ -1,13 +1,23 @@
 package cohere
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"github.com/zoobzio/vex"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
-
-	"github.com/zoobzio/vex"
+	"time"
 )
 
 func TestProvider_Name(t *testing.T) {
@@ -102,10 +112,496 @@ func TestProvider_Embed(t *testing.T) {
 		})
 
 		_, err := p.Embed(context.Background(), []string{"test"})
-		if err == nil {
-			t.Error("expected error for invalid API key")
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected ProviderError, got %v", err)
+		}
+		if perr.Provider != "cohere" || perr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected provider error fields: %+v", perr)
+		}
+		if perr.Message != "Invalid API key" {
+			t.Errorf("expected message 'Invalid API key', got %q", perr.Message)
+		}
+		if perr.Retryable() {
+			t.Error("expected 401 to be non-retryable")
+		}
+	})
+}
+
+func TestProviderV2_Embed(t *testing.T) {
+	t.Run("parses nested float embeddings", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/embed" {
+				t.Errorf("expected /embed, got %s", r.URL.Path)
+			}
+
+			var req embeddingRequestV2
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			if len(req.EmbeddingTypes) != 1 || req.EmbeddingTypes[0] != "float" {
+				t.Errorf("expected embedding_types [float], got %v", req.EmbeddingTypes)
+			}
+			if req.InputType != string(InputTypeSearchQuery) {
+				t.Errorf("expected search_query input type, got %q", req.InputType)
+			}
+
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{
+				"id": "test-id",
+				"embeddings": {"float": [[0.1, 0.2, 0.3], [0.4, 0.5, 0.6]]},
+				"texts": ["hello", "world"],
+				"meta": {"billed_units": {"input_tokens": 7}}
+			}`))
+		}))
+		defer server.Close()
+
+		p := NewV2(Config{APIKey: "test-key", BaseURL: server.URL}).ForQuery()
+		resp, err := p.Embed(context.Background(), []string{"hello", "world"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(resp.Vectors) != 2 || resp.Vectors[1][2] != float32(0.6) {
+			t.Errorf("unexpected vectors: %v", resp.Vectors)
+		}
+		if resp.Dimensions != 3 {
+			t.Errorf("expected 3 dimensions, got %d", resp.Dimensions)
+		}
+		if resp.Usage.PromptTokens != 7 || resp.Usage.TotalTokens != 7 {
+			t.Errorf("expected 7 billed tokens, got %+v", resp.Usage)
+		}
+	})
+
+	t.Run("falls back to token count without billed units", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"embeddings": {"float": [[1]]}, "meta": {"tokens": {"input_tokens": 3}}}`))
+		}))
+		defer server.Close()
+
+		resp, err := NewV2(Config{APIKey: "test-key", BaseURL: server.URL}).Embed(context.Background(), []string{"a"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Usage.TotalTokens != 3 {
+			t.Errorf("expected 3 tokens, got %d", resp.Usage.TotalTokens)
+		}
+	})
+
+	t.Run("reports warnings", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"embeddings": {"float": [[1]]}, "meta": {"warnings": ["input 0 was truncated"]}}`))
+		}))
+		defer server.Close()
+
+		resp, err := NewV2(Config{APIKey: "test-key", BaseURL: server.URL}).Embed(context.Background(), []string{"a"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Warnings) != 1 || resp.Warnings[0] != "input 0 was truncated" {
+			t.Errorf("expected truncation warning, got %q", resp.Warnings)
+		}
+	})
+
+	t.Run("defaults to v2 base URL", func(t *testing.T) {
+		if p := NewV2(Config{APIKey: "test"}); p.baseURL != "https://api.cohere.com/v2" {
+			t.Errorf("unexpected base URL %q", p.baseURL)
+		}
+	})
+}
+
+func TestProvider_EmbeddingTypes(t *testing.T) {
+	t.Run("requests types and parses quantized results", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req embeddingRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			want := []string{EmbeddingTypeFloat, EmbeddingTypeInt8, EmbeddingTypeUbinary}
+			if len(req.EmbeddingTypes) != len(want) {
+				t.Fatalf("expected embedding_types %v, got %v", want, req.EmbeddingTypes)
+			}
+			for i := range want {
+				if req.EmbeddingTypes[i] != want[i] {
+					t.Errorf("expected embedding_types %v, got %v", want, req.EmbeddingTypes)
+				}
+			}
+
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{
+				"embeddings": {
+					"float": [[0.1, 0.2]],
+					"int8": [[-128, 127]],
+					"ubinary": [[165]]
+				},
+				"meta": {"billed_units": {"input_tokens": 2}}
+			}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{
+			APIKey:         "test-key",
+			BaseURL:        server.URL,
+			EmbeddingTypes: []string{EmbeddingTypeFloat, EmbeddingTypeInt8, EmbeddingTypeUbinary},
+		})
+		resp, err := p.Embed(context.Background(), []string{"hello"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(resp.Vectors) != 1 || resp.Dimensions != 2 {
+			t.Errorf("unexpected float vectors: %v", resp.Vectors)
+		}
+		if len(resp.Int8Vectors) != 1 || resp.Int8Vectors[0][0] != -128 || resp.Int8Vectors[0][1] != 127 {
+			t.Errorf("unexpected int8 vectors: %v", resp.Int8Vectors)
+		}
+		if len(resp.BinaryVectors) != 1 || resp.BinaryVectors[0][0] != 165 {
+			t.Errorf("unexpected binary vectors: %v", resp.BinaryVectors)
+		}
+	})
+
+	t.Run("converts unsigned int8 and signed binary", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req embeddingRequestV2
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			if len(req.EmbeddingTypes) != 2 || req.EmbeddingTypes[0] != EmbeddingTypeUint8 {
+				t.Errorf("unexpected embedding_types %v", req.EmbeddingTypes)
+			}
+
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"embeddings": {"uint8": [[0, 255]], "binary": [[37]]}}`))
+		}))
+		defer server.Close()
+
+		p := NewV2(Config{
+			APIKey:         "test-key",
+			BaseURL:        server.URL,
+			Dimensions:     8,
+			EmbeddingTypes: []string{EmbeddingTypeUint8, EmbeddingTypeBinary},
+		})
+		resp, err := p.Embed(context.Background(), []string{"hello"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if resp.Vectors != nil {
+			t.Errorf("expected no float vectors, got %v", resp.Vectors)
+		}
+		if resp.Dimensions != 8 {
+			t.Errorf("expected configured dimensions, got %d", resp.Dimensions)
+		}
+		if len(resp.Int8Vectors) != 1 || resp.Int8Vectors[0][0] != -128 || resp.Int8Vectors[0][1] != 127 {
+			t.Errorf("expected uint8 shifted to int8, got %v", resp.Int8Vectors)
+		}
+		if len(resp.BinaryVectors) != 1 || resp.BinaryVectors[0][0] != 165 {
+			t.Errorf("expected signed binary shifted to packed bits, got %v", resp.BinaryVectors)
+		}
+	})
+}
+
+func TestProvider_Truncate(t *testing.T) {
+	for _, mode := range []TruncateMode{"", TruncateNone, TruncateStart, TruncateEnd} {
+		t.Run("sends mode "+string(mode), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var raw map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+					t.Fatalf("failed to decode request: %v", err)
+				}
+				got, ok := raw["truncate"]
+				if mode == "" && ok {
+					t.Errorf("expected truncate to be omitted, got %v", got)
+				}
+				if mode != "" && got != string(mode) {
+					t.Errorf("expected truncate %q, got %v", mode, got)
+				}
+				//nolint:errcheck // test helper
+				w.Write([]byte(`{"embeddings": [[0.1, 0.2]]}`))
+			}))
+			defer server.Close()
+
+			p := New(Config{APIKey: "test-key", BaseURL: server.URL, Truncate: mode})
+			if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("rejects invalid mode", func(t *testing.T) {
+		p := New(Config{APIKey: "test", Truncate: "MIDDLE"})
+		if _, err := p.Embed(context.Background(), []string{"hello"}); err == nil {
+			t.Error("expected error for invalid truncate mode")
+		}
+	})
+
+	t.Run("classifies context length error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"message": "too many tokens: total number of tokens in the prompt cannot exceed 512 - received 900"}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL, Truncate: TruncateNone})
+		_, err := p.Embed(context.Background(), []string{"long"})
+		if !errors.Is(err, vex.ErrContextLengthExceeded) {
+			t.Fatalf("expected ErrContextLengthExceeded, got %v", err)
+		}
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) || perr.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400 ProviderError, got %v", err)
+		}
+	})
+
+	t.Run("leaves other errors unclassified", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"message": "invalid input_type"}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+		if _, err := p.Embed(context.Background(), []string{"a"}); errors.Is(err, vex.ErrContextLengthExceeded) {
+			t.Errorf("expected unclassified error, got %v", err)
+		}
+	})
+}
+
+func TestProvider_MaxBatchSize(t *testing.T) {
+	t.Run("splits at the API limit", func(t *testing.T) {
+		var sizes []int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req embeddingRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			sizes = append(sizes, len(req.Texts))
+			if len(req.Texts) > DefaultMaxBatchSize {
+				w.WriteHeader(http.StatusBadRequest)
+				//nolint:errcheck // test helper
+				w.Write([]byte(`{"message": "invalid request: total number of texts must be at most 96"}`))
+				return
+			}
+
+			embeddings := make([][]float64, len(req.Texts))
+			for i, text := range req.Texts {
+				embeddings[i] = []float64{float64(len(text))}
+			}
+			//nolint:errcheck // test helper
+			json.NewEncoder(w).Encode(embeddingResponse{
+				Embeddings: embeddings,
+				Meta:       meta{BilledUnits: billedUnits{InputTokens: len(req.Texts)}},
+			})
+		}))
+		defer server.Close()
+
+		texts := make([]string, 200)
+		for i := range texts {
+			texts[i] = strings.Repeat("x", i+1)
+		}
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+		resp, err := p.Embed(context.Background(), texts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(sizes) != 3 || sizes[0] != 96 || sizes[1] != 96 || sizes[2] != 8 {
+			t.Errorf("expected sub-requests [96 96 8], got %v", sizes)
+		}
+		if len(resp.Vectors) != len(texts) {
+			t.Fatalf("expected %d vectors, got %d", len(texts), len(resp.Vectors))
+		}
+		for i, vec := range resp.Vectors {
+			if vec[0] != float32(i+1) {
+				t.Errorf("vector %d out of order: %v", i, vec)
+			}
+		}
+		if resp.Usage.PromptTokens != 200 || resp.Usage.TotalTokens != 200 {
+			t.Errorf("expected billed tokens summed to 200, got %+v", resp.Usage)
 		}
 	})
+
+	t.Run("honors override", func(t *testing.T) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls++
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"embeddings": [[0.1], [0.2]]}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL, MaxBatchSize: 2})
+		if _, err := p.Embed(context.Background(), []string{"a", "b", "c", "d"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("expected 2 sub-requests, got %d", calls)
+		}
+	})
+}
+
+// tinyPNG encodes a 1x1 PNG whose red channel is shade.
+func tinyPNG(t *testing.T, shade uint8) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.RGBA{R: shade, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// imageServer embeds each requested image as its pixel's red channel.
+func imageServer(t *testing.T, handle func(req embeddingRequest)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			return
+		}
+		if handle != nil {
+			handle(req)
+		}
+		embeddings := make([][]float64, len(req.Images))
+		for i, uri := range req.Images {
+			data, ok := strings.CutPrefix(uri, "data:image/png;base64,")
+			if !ok {
+				t.Errorf("expected png data URI, got %.40q", uri)
+				return
+			}
+			raw, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				t.Errorf("failed to decode base64: %v", err)
+				return
+			}
+			img, err := png.Decode(bytes.NewReader(raw))
+			if err != nil {
+				t.Errorf("failed to decode png: %v", err)
+				return
+			}
+			r, _, _, _ := img.At(0, 0).RGBA()
+			embeddings[i] = []float64{float64(r >> 8)}
+		}
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(embeddingResponse{
+			Embeddings: embeddings,
+			Meta:       meta{BilledUnits: billedUnits{InputTokens: 1}},
+		})
+	}))
+}
+
+func TestProvider_EmbedImages(t *testing.T) {
+	t.Run("one request per image in order", func(t *testing.T) {
+		var mu sync.Mutex
+		var requests []embeddingRequest
+		server := imageServer(t, func(req embeddingRequest) {
+			mu.Lock()
+			requests = append(requests, req)
+			mu.Unlock()
+		})
+		defer server.Close()
+
+		images := make([][]byte, 10)
+		for i := range images {
+			images[i] = tinyPNG(t, uint8(i*10))
+		}
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+		resp, err := p.EmbedImages(context.Background(), images)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(requests) != len(images) {
+			t.Errorf("expected %d requests, got %d", len(images), len(requests))
+		}
+		for _, req := range requests {
+			if len(req.Images) != 1 || len(req.Texts) != 0 {
+				t.Errorf("expected one image and no texts, got %d images and %d texts", len(req.Images), len(req.Texts))
+			}
+			if req.InputType != "image" {
+				t.Errorf("expected input_type 'image', got %q", req.InputType)
+			}
+		}
+		if len(resp.Vectors) != len(images) {
+			t.Fatalf("expected %d vectors, got %d", len(images), len(resp.Vectors))
+		}
+		for i, vec := range resp.Vectors {
+			if vec[0] != float32(i*10) {
+				t.Errorf("vector %d out of order: %v", i, vec)
+			}
+		}
+		if resp.Usage.PromptTokens != len(images) {
+			t.Errorf("expected usage summed to %d, got %d", len(images), resp.Usage.PromptTokens)
+		}
+	})
+
+	t.Run("bounds concurrency", func(t *testing.T) {
+		var inFlight, peak atomic.Int32
+		server := imageServer(t, func(_ embeddingRequest) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		})
+		defer server.Close()
+
+		images := make([][]byte, 8)
+		for i := range images {
+			images[i] = tinyPNG(t, uint8(i))
+		}
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL, ImageConcurrency: 2})
+		if _, err := p.EmbedImages(context.Background(), images); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := peak.Load(); got > 2 {
+			t.Errorf("expected at most 2 requests in flight, got %d", got)
+		}
+	})
+
+	t.Run("returns provider error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"message": "invalid image"}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+		_, err := p.EmbedImages(context.Background(), [][]byte{tinyPNG(t, 1), tinyPNG(t, 2)})
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) || perr.Message != "invalid image" {
+			t.Errorf("expected ProviderError 'invalid image', got %v", err)
+		}
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		p := New(Config{APIKey: "test-key"})
+		resp, err := p.EmbedImages(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Vectors) != 0 {
+			t.Errorf("expected no vectors, got %d", len(resp.Vectors))
+		}
+	})
+}
+
+func TestProvider_ImplementsImageProvider(_ *testing.T) {
+	var _ vex.ImageProvider = New(Config{APIKey: "test"})
 }
 
 func TestProvider_WithInputType(t *testing.T) {
@@ -149,6 +645,90 @@ func TestProvider_ImplementsQueryProviderFactory(_ *testing.T) {
 	var _ vex.QueryProviderFactory = p
 }
 
+func TestProvider_Model(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+	if p.Model() != "embed-english-v3.0" {
+		t.Errorf("expected model 'embed-english-v3.0', got %q", p.Model())
+	}
+}
+
+// countingTransport records round trips and fails them without network access.
+type countingTransport struct {
+	calls int
+}
+
+var errIntercepted = errors.New("intercepted")
+
+func (c *countingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	c.calls++
+	return nil, errIntercepted
+}
+
+func TestConfig_HTTPClient(t *testing.T) {
+	t.Run("uses custom round tripper", func(t *testing.T) {
+		transport := &countingTransport{}
+		p := New(Config{
+			APIKey:     "test",
+			HTTPClient: &http.Client{Transport: transport},
+		})
+
+		_, err := p.Embed(context.Background(), []string{"test"})
+		if !errors.Is(err, errIntercepted) {
+			t.Errorf("expected intercepted error, got %v", err)
+		}
+		if transport.calls != 1 {
+			t.Errorf("expected 1 round trip, got %d", transport.calls)
+		}
+	})
+
+	t.Run("applies timeout when client has none", func(t *testing.T) {
+		p := New(Config{
+			APIKey:     "test",
+			Timeout:    5 * time.Second,
+			HTTPClient: &http.Client{},
+		})
+		if p.httpClient.Timeout != 5*time.Second {
+			t.Errorf("expected 5s timeout, got %v", p.httpClient.Timeout)
+		}
+	})
+}
+
+func TestConfig_Headers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Tenant-ID"); got != "acme" {
+			t.Errorf("expected custom header, got %q", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("expected custom header to override Content-Type, got %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("expected provider authentication to be kept, got %q", got)
+		}
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"embeddings": [[0.1, 0.2]]}`))
+	}))
+	defer server.Close()
+
+	p := New(Config{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+		Headers: map[string]string{
+			"X-Tenant-ID":  "acme",
+			"Content-Type": "application/json; charset=utf-8",
+		},
+	})
+	if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestProvider_MaxTokens(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+	if p.MaxTokens() != 512 {
+		t.Errorf("expected 512 max tokens, got %d", p.MaxTokens())
+	}
+}
+
 func TestConfig_Defaults(t *testing.T) {
 	p := New(Config{APIKey: "test"})
 
@@ -161,6 +741,9 @@ func TestConfig_Defaults(t *testing.T) {
 	if p.inputType != InputTypeSearchDocument {
 		t.Errorf("expected default input type 'search_document'")
 	}
+	if p.maxBatchSize != DefaultMaxBatchSize {
+		t.Errorf("expected default max batch size %d, got %d", DefaultMaxBatchSize, p.maxBatchSize)
+	}
 }
 
 func TestInputTypes(t *testing.T) {
@@ -177,3 +760,39 @@ func TestInputTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestProvider_RetryResendsBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+		bodies = append(bodies, string(body))
+
+		switch len(bodies) {
+		case 1:
+			// A 307 redirect must replay the body on the same attempt.
+			http.Redirect(w, r, r.URL.String(), http.StatusTemporaryRedirect)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"embeddings":[[0.6,0.8]]}`)) //nolint:errcheck // test helper
+		}
+	}))
+	defer server.Close()
+
+	svc := vex.NewService(New(Config{APIKey: "test", BaseURL: server.URL}), vex.WithRetry(2))
+	if _, err := svc.Embed(context.Background(), "hello world"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(bodies))
+	}
+	for i, body := range bodies {
+		if body == "" || body != bodies[0] {
+			t.Errorf("request %d: expected full body %q, got %q", i, bodies[0], body)
+		}
+	}
+}
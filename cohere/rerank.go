@@ -0,0 +1,172 @@
+package cohere
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/zoobzio/vex"
+	"github.com/zoobzio/vex/internal/httputil"
+)
+
+// Rerank model identifiers.
+const (
+	RerankModelV35            = "rerank-v3.5"
+	RerankModelEnglishV3      = "rerank-english-v3.0"
+	RerankModelMultilingualV3 = "rerank-multilingual-v3.0"
+)
+
+// Reranker implements vex.Reranker for the Cohere rerank API.
+type Reranker struct {
+	httpClient *http.Client
+	apiKey     string
+	model      string
+	baseURL    string
+}
+
+// RerankerConfig holds configuration for the Cohere reranker.
+type RerankerConfig struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+	Timeout time.Duration
+
+	// HTTPClient is used for all requests when set, e.g. for proxies, mTLS,
+	// or instrumented transports. Timeout is applied if the client has none.
+	HTTPClient *http.Client
+}
+
+// NewReranker creates a new Cohere reranker.
+func NewReranker(config RerankerConfig) *Reranker {
+	if config.Model == "" {
+		config.Model = RerankModelV35
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.cohere.ai/v1"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &Reranker{
+		apiKey:     config.APIKey,
+		model:      config.Model,
+		baseURL:    config.BaseURL,
+		httpClient: httputil.NewClient(config.HTTPClient, config.Timeout),
+	}
+}
+
+// Model returns the rerank model identifier.
+func (r *Reranker) Model() string {
+	return r.model
+}
+
+// Rerank scores documents against query and returns the topN most relevant
+// in descending order of relevance score. Implements vex.Reranker.
+func (r *Reranker) Rerank(ctx context.Context, query string, documents []string, topN int) ([]vex.RerankResult, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	reqBody := rerankRequest{
+		Model:     r.model,
+		Query:     query,
+		Documents: documents,
+	}
+	if topN > 0 && topN < len(documents) {
+		reqBody.TopN = topN
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.baseURL+"/rerank", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	httputil.SetRequestID(req)
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := httputil.CheckRedirect(req, resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		perr := &vex.ProviderError{
+			Provider:   "cohere",
+			StatusCode: resp.StatusCode,
+			RetryAfter: httputil.RetryAfter(resp.Header, time.Now()),
+		}
+		var errResp errorResponse
+		if err := json.Unmarshal(body, &errResp); err == nil {
+			perr.Message = errResp.Message
+		}
+		return nil, perr
+	}
+
+	var rerankResp rerankResponse
+	if err := json.Unmarshal(body, &rerankResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	results := make([]vex.RerankResult, 0, len(rerankResp.Results))
+	for _, res := range rerankResp.Results {
+		if res.Index < 0 || res.Index >= len(documents) {
+			return nil, fmt.Errorf("invalid index %d from API", res.Index)
+		}
+		results = append(results, vex.RerankResult{
+			Index:          res.Index,
+			RelevanceScore: res.RelevanceScore,
+			Document:       documents[res.Index],
+		})
+	}
+
+	slices.SortStableFunc(results, func(a, b vex.RerankResult) int {
+		return cmp.Compare(b.RelevanceScore, a.RelevanceScore)
+	})
+	if topN > 0 && len(results) > topN {
+		results = results[:topN]
+	}
+	return results, nil
+}
+
+// API types
+
+type rerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"`
+}
+
+type rerankResponse struct {
+	ID      string         `json:"id"`
+	Results []rerankResult `json:"results"`
+	Meta    meta           `json:"meta"`
+}
+
+type rerankResult struct {
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+}
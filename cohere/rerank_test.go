@@ -0,0 +1,150 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zoobzio/vex"
+)
+
+func TestReranker_Rerank(t *testing.T) {
+	t.Run("orders by score and maps indices", func(t *testing.T) {
+		var req rerankRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/rerank" {
+				t.Errorf("expected /rerank, got %s", r.URL.Path)
+			}
+			if r.Header.Get("Authorization") != "Bearer test-key" {
+				t.Errorf("unexpected authorization header %q", r.Header.Get("Authorization"))
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{
+				"id": "rr-1",
+				"results": [
+					{"index": 1, "relevance_score": 0.35},
+					{"index": 0, "relevance_score": 0.02},
+					{"index": 2, "relevance_score": 0.88}
+				],
+				"meta": {"billed_units": {"search_units": 1}}
+			}`))
+		}))
+		defer server.Close()
+
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: server.URL})
+		results, err := r.Rerank(context.Background(), "vector search", []string{"cats", "dogs", "embeddings"}, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if req.Query != "vector search" || len(req.Documents) != 3 || req.Model != RerankModelV35 {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		if req.TopN != 0 {
+			t.Errorf("expected top_n omitted, got %d", req.TopN)
+		}
+
+		want := []vex.RerankResult{
+			{Index: 2, RelevanceScore: 0.88, Document: "embeddings"},
+			{Index: 1, RelevanceScore: 0.35, Document: "dogs"},
+			{Index: 0, RelevanceScore: 0.02, Document: "cats"},
+		}
+		if len(results) != len(want) {
+			t.Fatalf("expected %d results, got %d", len(want), len(results))
+		}
+		for i := range want {
+			if results[i] != want[i] {
+				t.Errorf("result %d: expected %+v, got %+v", i, want[i], results[i])
+			}
+		}
+	})
+
+	t.Run("sends and enforces top_n", func(t *testing.T) {
+		var req rerankRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"results": [
+				{"index": 2, "relevance_score": 0.9},
+				{"index": 0, "relevance_score": 0.4},
+				{"index": 1, "relevance_score": 0.3}
+			]}`))
+		}))
+		defer server.Close()
+
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: server.URL, Model: RerankModelMultilingualV3})
+		results, err := r.Rerank(context.Background(), "q", []string{"a", "b", "c"}, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if req.TopN != 2 || req.Model != RerankModelMultilingualV3 {
+			t.Errorf("expected top_n 2 with %s, got %d with %q", RerankModelMultilingualV3, req.TopN, req.Model)
+		}
+		if len(results) != 2 || results[0].Index != 2 || results[1].Index != 0 {
+			t.Errorf("unexpected results: %+v", results)
+		}
+		if results[0].Document != "c" || results[1].Document != "a" {
+			t.Errorf("expected documents to follow indices, got %+v", results)
+		}
+	})
+
+	t.Run("rejects out of range index", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"results": [{"index": -1, "relevance_score": 0.8}]}`))
+		}))
+		defer server.Close()
+
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: server.URL})
+		if _, err := r.Rerank(context.Background(), "q", []string{"a"}, 0); err == nil {
+			t.Error("expected error for invalid index")
+		}
+	})
+
+	t.Run("returns provider error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"message": "invalid request: query must not be empty"}`))
+		}))
+		defer server.Close()
+
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: server.URL})
+		_, err := r.Rerank(context.Background(), "", []string{"a"}, 0)
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected ProviderError, got %v", err)
+		}
+		if perr.StatusCode != http.StatusBadRequest || perr.Message != "invalid request: query must not be empty" {
+			t.Errorf("unexpected error: %+v", perr)
+		}
+	})
+
+	t.Run("empty documents", func(t *testing.T) {
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: "http://unused"})
+		results, err := r.Rerank(context.Background(), "q", nil, 3)
+		if err != nil || results != nil {
+			t.Errorf("expected nil results, got %v, %v", results, err)
+		}
+	})
+}
+
+func TestReranker_Defaults(t *testing.T) {
+	r := NewReranker(RerankerConfig{APIKey: "test"})
+	if r.Model() != RerankModelV35 {
+		t.Errorf("expected default model %q, got %q", RerankModelV35, r.Model())
+	}
+	if r.baseURL != "https://api.cohere.ai/v1" {
+		t.Errorf("expected default base URL, got %q", r.baseURL)
+	}
+
+	var _ vex.Reranker = r
+}
@@ -0,0 +1,68 @@
+package vex
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zoobzio/pipz"
+)
+
+// concurrencyLimit caps the number of requests in flight through its
+// processor. Callers beyond the limit block until a slot frees up or their
+// context is done.
+type concurrencyLimit struct {
+	identity  pipz.Identity
+	processor pipz.Chainable[*EmbedRequest]
+	sem       chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newConcurrencyLimit(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], n int) *concurrencyLimit {
+	if n <= 0 {
+		n = 1
+	}
+	return &concurrencyLimit{
+		identity:  identity,
+		processor: processor,
+		sem:       make(chan struct{}, n),
+	}
+}
+
+// Process implements pipz.Chainable.
+func (c *concurrencyLimit) Process(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		req.Error = ctx.Err()
+		return req, req.Error
+	}
+	defer func() { <-c.sem }()
+
+	return c.processor.Process(ctx, req)
+}
+
+// Identity implements pipz.Chainable.
+func (c *concurrencyLimit) Identity() pipz.Identity {
+	return c.identity
+}
+
+// Schema implements pipz.Chainable.
+func (c *concurrencyLimit) Schema() pipz.Node {
+	return pipz.Node{
+		Identity: c.identity,
+		Type:     "concurrency-limit",
+		Flow:     pipz.WorkerpoolFlow{Processors: []pipz.Node{c.processor.Schema()}},
+		Metadata: map[string]any{
+			"limit": cap(c.sem),
+		},
+	}
+}
+
+// Close implements pipz.Chainable.
+func (c *concurrencyLimit) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.processor.Close()
+	})
+	return c.closeErr
+}
@@ -0,0 +1,192 @@
+package vex
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// giantTokenLength is the length in characters above which a single
+// whitespace-free token is flagged; such tokens are usually base64 blobs,
+// URLs with payloads, or minified code, and tokenize poorly.
+const giantTokenLength = 100
+
+// CorpusIssue identifies a problem with a document in a corpus.
+type CorpusIssue string
+
+// Corpus issues reported by AnalyzeCorpus.
+const (
+	IssueEmpty       CorpusIssue = "empty"        // Empty or whitespace only
+	IssueGiantToken  CorpusIssue = "giant_token"  // Contains a token over 100 characters
+	IssueInvalidUTF8 CorpusIssue = "invalid_utf8" // Contains bytes that are not valid UTF-8
+)
+
+// DocumentIssue flags a problem with the document at Index.
+type DocumentIssue struct {
+	Index int         `json:"index"`
+	Issue CorpusIssue `json:"issue"`
+}
+
+// Distribution summarizes a set of measurements. Percentiles use the
+// nearest-rank method.
+type Distribution struct {
+	Count int     `json:"count"`
+	Min   int     `json:"min"`
+	Max   int     `json:"max"`
+	Mean  float64 `json:"mean"`
+	P50   int     `json:"p50"`
+	P90   int     `json:"p90"`
+	P99   int     `json:"p99"`
+}
+
+// CorpusReport describes a corpus and how a chunker splits it. Lengths and
+// sizes are in characters; token counts are estimates.
+type CorpusReport struct {
+	Documents       int             `json:"documents"`
+	Strategy        string          `json:"strategy"`
+	TextLength      Distribution    `json:"text_length"`
+	Sentences       Distribution    `json:"sentences"`
+	ChunksPerText   Distribution    `json:"chunks_per_text"`
+	ChunkSize       Distribution    `json:"chunk_size"`
+	TokensPerText   Distribution    `json:"tokens_per_text"`
+	EstimatedTokens int             `json:"estimated_tokens"`
+	Issues          []DocumentIssue `json:"issues,omitempty"`
+}
+
+// AnalyzeCorpus computes statistics for texts as chunked by chunker, to guide
+// the choice of strategy and MaxSize before embedding anything. A nil chunker
+// uses DefaultChunker. Tokens are estimated at four bytes per token, which is
+// close for English text with common tokenizers.
+func AnalyzeCorpus(texts []string, chunker *Chunker) CorpusReport {
+	if chunker == nil {
+		chunker = DefaultChunker()
+	}
+
+	report := CorpusReport{
+		Documents: len(texts),
+		Strategy:  chunker.Strategy.String(),
+	}
+
+	lengths := make([]int, 0, len(texts))
+	sentences := make([]int, 0, len(texts))
+	chunkCounts := make([]int, 0, len(texts))
+	tokens := make([]int, 0, len(texts))
+	var chunkSizes []int
+
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			report.Issues = append(report.Issues, DocumentIssue{Index: i, Issue: IssueEmpty})
+		}
+		if !utf8.ValidString(text) {
+			report.Issues = append(report.Issues, DocumentIssue{Index: i, Issue: IssueInvalidUTF8})
+		}
+		if hasGiantToken(text) {
+			report.Issues = append(report.Issues, DocumentIssue{Index: i, Issue: IssueGiantToken})
+		}
+
+		lengths = append(lengths, utf8.RuneCountInString(text))
+		sentences = append(sentences, countSentences(chunker, text))
+		est := estimateTokens(text)
+		tokens = append(tokens, est)
+		report.EstimatedTokens += est
+
+		chunks, _ := chunker.chunk(text)
+		chunkCounts = append(chunkCounts, len(chunks))
+		for _, chunk := range chunks {
+			chunkSizes = append(chunkSizes, utf8.RuneCountInString(chunk))
+		}
+	}
+
+	report.TextLength = distribution(lengths)
+	report.Sentences = distribution(sentences)
+	report.ChunksPerText = distribution(chunkCounts)
+	report.ChunkSize = distribution(chunkSizes)
+	report.TokensPerText = distribution(tokens)
+	return report
+}
+
+// String returns a human-readable summary of the report.
+func (r CorpusReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d documents, ~%d tokens, strategy %s\n", r.Documents, r.EstimatedTokens, r.Strategy)
+	fmt.Fprintf(&b, "  text length:     %s\n", r.TextLength)
+	fmt.Fprintf(&b, "  sentences:       %s\n", r.Sentences)
+	fmt.Fprintf(&b, "  chunks per text: %s\n", r.ChunksPerText)
+	fmt.Fprintf(&b, "  chunk size:      %s\n", r.ChunkSize)
+	fmt.Fprintf(&b, "  tokens per text: %s\n", r.TokensPerText)
+	if len(r.Issues) == 0 {
+		b.WriteString("  no issues\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "  %d issues:\n", len(r.Issues))
+	for _, issue := range r.Issues {
+		fmt.Fprintf(&b, "    document %d: %s\n", issue.Index, issue.Issue)
+	}
+	return b.String()
+}
+
+// String returns the distribution on one line.
+func (d Distribution) String() string {
+	if d.Count == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("min %d, p50 %d, p90 %d, p99 %d, max %d, mean %.1f", d.Min, d.P50, d.P90, d.P99, d.Max, d.Mean)
+}
+
+// distribution summarizes values, which it sorts in place.
+func distribution(values []int) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	slices.Sort(values)
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+	return Distribution{
+		Count: len(values),
+		Min:   values[0],
+		Max:   values[len(values)-1],
+		Mean:  float64(sum) / float64(len(values)),
+		P50:   percentile(values, 50),
+		P90:   percentile(values, 90),
+		P99:   percentile(values, 99),
+	}
+}
+
+// percentile returns the nearest-rank pth percentile of sorted values.
+func percentile(sorted []int, p int) int {
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// countSentences counts the non-blank sentences in text using the chunker's
+// sentence rules.
+func countSentences(chunker *Chunker, text string) int {
+	n := 0
+	for _, s := range chunker.chunkBySentence(text) {
+		if strings.TrimSpace(s) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// estimateTokens estimates the token count of text at four bytes per token.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// hasGiantToken reports whether text contains a whitespace-free run longer
+// than giantTokenLength characters.
+func hasGiantToken(text string) bool {
+	for _, field := range strings.FieldsFunc(text, unicode.IsSpace) {
+		if utf8.RuneCountInString(field) > giantTokenLength {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package vex
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func corpusFixture() []string {
+	return []string{
+		"One. Two. Three.",
+		"Hello world.",
+		"",
+		strings.Repeat("a", 150),
+		"bad \xff byte.",
+	}
+}
+
+func TestAnalyzeCorpus(t *testing.T) {
+	chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true}
+	report := AnalyzeCorpus(corpusFixture(), chunker)
+
+	if report.Documents != 5 {
+		t.Errorf("expected 5 documents, got %d", report.Documents)
+	}
+	if report.Strategy != "sentence" {
+		t.Errorf("expected strategy 'sentence', got %q", report.Strategy)
+	}
+
+	tests := []struct {
+		name string
+		got  Distribution
+		want Distribution
+	}{
+		{"text length", report.TextLength, Distribution{Count: 5, Min: 0, Max: 150, Mean: 37.8, P50: 12, P90: 150, P99: 150}},
+		{"sentences", report.Sentences, Distribution{Count: 5, Min: 0, Max: 3, Mean: 1.2, P50: 1, P90: 3, P99: 3}},
+		{"chunks per text", report.ChunksPerText, Distribution{Count: 5, Min: 0, Max: 3, Mean: 1.2, P50: 1, P90: 3, P99: 3}},
+		{"chunk size", report.ChunkSize, Distribution{Count: 6, Min: 4, Max: 150, Mean: 31.166666666666668, P50: 6, P90: 150, P99: 150}},
+		{"tokens per text", report.TokensPerText, Distribution{Count: 5, Min: 0, Max: 38, Mean: 9.6, P50: 3, P90: 38, P99: 38}},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, tt.got)
+		}
+	}
+
+	if report.EstimatedTokens != 48 {
+		t.Errorf("expected 48 estimated tokens, got %d", report.EstimatedTokens)
+	}
+}
+
+func TestAnalyzeCorpus_Issues(t *testing.T) {
+	report := AnalyzeCorpus(corpusFixture(), nil)
+
+	want := []DocumentIssue{
+		{Index: 2, Issue: IssueEmpty},
+		{Index: 3, Issue: IssueGiantToken},
+		{Index: 4, Issue: IssueInvalidUTF8},
+	}
+	if !reflect.DeepEqual(report.Issues, want) {
+		t.Errorf("expected issues %v, got %v", want, report.Issues)
+	}
+
+	clean := AnalyzeCorpus([]string{"Fine text.", strings.Repeat("word ", 50)}, nil)
+	if len(clean.Issues) != 0 {
+		t.Errorf("expected no issues, got %v", clean.Issues)
+	}
+}
+
+func TestAnalyzeCorpus_Empty(t *testing.T) {
+	report := AnalyzeCorpus(nil, nil)
+	if report.Documents != 0 || report.TextLength.Count != 0 || report.EstimatedTokens != 0 {
+		t.Errorf("expected empty report, got %+v", report)
+	}
+	if !strings.Contains(report.String(), "n/a") {
+		t.Errorf("expected empty distributions rendered as n/a, got %q", report.String())
+	}
+}
+
+func TestCorpusReport_JSON(t *testing.T) {
+	report := AnalyzeCorpus(corpusFixture(), nil)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var decoded CorpusReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, report) {
+		t.Errorf("round trip mismatch:\n%+v\n%+v", report, decoded)
+	}
+	if !strings.Contains(string(data), `"issue":"giant_token"`) {
+		t.Errorf("expected issue in JSON, got %s", data)
+	}
+}
+
+func TestCorpusReport_String(t *testing.T) {
+	s := AnalyzeCorpus(corpusFixture(), nil).String()
+	for _, want := range []string{"5 documents", "strategy none", "text length:", "min 0, p50 12", "document 4: invalid_utf8"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expected %q in:\n%s", want, s)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package vex
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrMalformedVector is returned by ParseVector for input that is not a
+// pgvector text literal.
+var ErrMalformedVector = errors.New("malformed vector literal")
+
+// vectorBufs holds scratch buffers for binary vector encoding so that
+// streaming many vectors does not allocate a buffer per vector.
+var vectorBufs = sync.Pool{
+	New: func() any { return new([]byte) },
+}
+
+func getVectorBuf(size int) *[]byte {
+	buf := vectorBufs.Get().(*[]byte) //nolint:errcheck // pool only holds *[]byte
+	if cap(*buf) < size {
+		*buf = make([]byte, size)
+	}
+	*buf = (*buf)[:size]
+	return buf
+}
+
+// WriteTo writes the vector to w as little-endian float32 values, without a
+// length prefix. Implements io.WriterTo.
+func (v Vector) WriteTo(w io.Writer) (int64, error) {
+	buf := getVectorBuf(4 * len(v))
+	defer vectorBufs.Put(buf)
+
+	b := *buf
+	for i, val := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(val))
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// ReadVector reads a vector of dim little-endian float32 values from r, as
+// written by Vector.WriteTo. It returns io.EOF if r is exhausted before any
+// bytes are read, and io.ErrUnexpectedEOF if it ends mid-vector.
+func ReadVector(r io.Reader, dim int) (Vector, error) {
+	buf := getVectorBuf(4 * dim)
+	defer vectorBufs.Put(buf)
+
+	b := *buf
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	v := make(Vector, dim)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v, nil
+}
+
+// String formats the vector as a pgvector text literal, e.g. "[0.1,-2,3e-05]",
+// with no spaces and the shortest representation that round-trips float32.
+// Implements fmt.Stringer.
+func (v Vector) String() string {
+	b := make([]byte, 0, 2+12*len(v))
+	b = append(b, '[')
+	for i, val := range v {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendFloat(b, float64(val), 'g', -1, 32)
+	}
+	b = append(b, ']')
+	return string(b)
+}
+
+// ParseVector parses a pgvector text literal as produced by Vector.String.
+// Whitespace around elements is permitted. "[]" yields an empty vector.
+func ParseVector(s string) (Vector, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("%w: %q must be enclosed in brackets", ErrMalformedVector, s)
+	}
+	body := strings.TrimSpace(s[1 : len(s)-1])
+	if body == "" {
+		return Vector{}, nil
+	}
+
+	parts := strings.Split(body, ",")
+	v := make(Vector, len(parts))
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: element %d: %q", ErrMalformedVector, i, part)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
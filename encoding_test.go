@@ -0,0 +1,120 @@
+package vex
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestVector_WriteTo(t *testing.T) {
+	t.Run("round trips a sequence of vectors", func(t *testing.T) {
+		vectors := []Vector{
+			{1, 2, 3},
+			{-0.5, float32(math.Pi), 0},
+			{math.MaxFloat32, math.SmallestNonzeroFloat32, -1},
+		}
+
+		var buf bytes.Buffer
+		for _, v := range vectors {
+			n, err := v.WriteTo(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n != 12 {
+				t.Errorf("expected 12 bytes written, got %d", n)
+			}
+		}
+
+		for i, expected := range vectors {
+			v, err := ReadVector(&buf, 3)
+			if err != nil {
+				t.Fatalf("vector %d: unexpected error: %v", i, err)
+			}
+			if !v.Equal(expected) {
+				t.Errorf("vector %d: expected %v, got %v", i, expected, v)
+			}
+		}
+
+		if _, err := ReadVector(&buf, 3); !errors.Is(err, io.EOF) {
+			t.Errorf("expected io.EOF after last vector, got %v", err)
+		}
+	})
+
+	t.Run("reports truncated input", func(t *testing.T) {
+		var buf bytes.Buffer
+		Vector{1, 2}.WriteTo(&buf) //nolint:errcheck // bytes.Buffer never fails
+
+		if _, err := ReadVector(&buf, 3); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+		}
+	})
+}
+
+func BenchmarkVector_WriteTo(b *testing.B) {
+	v := make(Vector, 1536)
+	b.ReportAllocs()
+	for b.Loop() {
+		v.WriteTo(io.Discard) //nolint:errcheck // benchmark
+	}
+}
+
+func TestVector_String(t *testing.T) {
+	tests := []struct {
+		vec      Vector
+		expected string
+	}{
+		{Vector{}, "[]"},
+		{Vector{1, 2, 3}, "[1,2,3]"},
+		{Vector{0.1, -0.25, 0.00001}, "[0.1,-0.25,1e-05]"},
+		{Vector{float32(math.Pi)}, "[3.1415927]"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.vec.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestParseVector(t *testing.T) {
+	t.Run("round trips String output", func(t *testing.T) {
+		vec := Vector{0.1, -0.25, float32(math.Pi), math.MaxFloat32, math.SmallestNonzeroFloat32}
+		parsed, err := ParseVector(vec.String())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !parsed.Equal(vec) {
+			t.Errorf("expected %v, got %v", vec, parsed)
+		}
+	})
+
+	t.Run("accepts whitespace", func(t *testing.T) {
+		parsed, err := ParseVector(" [ 1, 2 ,3 ] ")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(parsed) != 3 || parsed[2] != 3 {
+			t.Errorf("expected [1 2 3], got %v", parsed)
+		}
+	})
+
+	t.Run("parses empty vector", func(t *testing.T) {
+		parsed, err := ParseVector("[]")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if parsed == nil || len(parsed) != 0 {
+			t.Errorf("expected empty non-nil vector, got %v", parsed)
+		}
+	})
+
+	t.Run("rejects malformed input", func(t *testing.T) {
+		for _, input := range []string{"", "1,2,3", "[1,2", "[1,,2]", "[1,a]", "[1,2,]"} {
+			if _, err := ParseVector(input); !errors.Is(err, ErrMalformedVector) {
+				t.Errorf("%q: expected ErrMalformedVector, got %v", input, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrInputTooLong is returned when an input exceeds the service's maximum
+// length and the truncation mode is TruncateError.
+var ErrInputTooLong = errors.New("input exceeds maximum length")
+
+// ErrContextLengthExceeded is wrapped by a ProviderError when the provider
+// rejected an input as longer than the model's context window, e.g. so the
+// caller can re-chunk it smaller and try again.
+var ErrContextLengthExceeded = errors.New("input exceeds model context length")
+
+// ErrRedirectAuthStripped is returned when a provider's base URL redirected
+// to another host and the HTTP client dropped the Authorization header on the
+// way, which otherwise surfaces as a confusing 401.
+var ErrRedirectAuthStripped = errors.New("redirect dropped authorization")
+
+// ErrDimensionDrift is returned when a provider's vector size changes during
+// a service's lifetime and WithFailOnDimensionDrift is set.
+var ErrDimensionDrift = errors.New("embedding dimensions changed")
+
+// ErrZeroVector is returned under ZeroError when a provider returns an
+// all-zero vector, typically for malformed input.
+var ErrZeroVector = errors.New("provider returned a zero vector")
+
+// ErrEmptyProviderResponse is returned under WithStrictResults when the
+// provider answered a non-empty request with no embeddings at all.
+var ErrEmptyProviderResponse = errors.New("provider returned no embeddings")
+
+// ErrSpendLimitExceeded is returned when a request would take the tokens
+// spent within a WithSpendLimit window past its ceiling.
+var ErrSpendLimitExceeded = errors.New("token spend limit exceeded")
+
+// ErrDimensionMismatch is returned when vectors that must share a
+// dimensionality, such as the chunks of one text being pooled, do not, or
+// when a provider returns vectors of a size other than its Dimensions.
+var ErrDimensionMismatch = errors.New("vector dimensions differ")
+
+// EmptyEmbeddingError is returned by providers when a response holds an
+// empty or missing embedding, e.g. from a misbehaving gateway, instead of
+// passing on a zero-length vector.
+type EmptyEmbeddingError struct {
+	Provider string // Provider name (e.g. "openai")
+	Index    int    // Position of the empty embedding in the request
+}
+
+// Error implements the error interface.
+func (e *EmptyEmbeddingError) Error() string {
+	return fmt.Sprintf("%s returned an empty embedding at index %d", e.Provider, e.Index)
+}
+
+// ValidateVectors returns an *EmptyEmbeddingError for the first empty vector
+// in a provider response, or nil if every vector has values. Provider
+// implementations call it before returning float vectors.
+func ValidateVectors(provider string, vectors []Vector) error {
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return &EmptyEmbeddingError{Provider: provider, Index: i}
+		}
+	}
+	return nil
+}
+
+// ProviderError is returned by providers when the embedding API responds
+// with a non-success status. Use errors.As to inspect it.
+type ProviderError struct {
+	Provider   string        // Provider name (e.g. "openai")
+	StatusCode int           // HTTP status code
+	Message    string        // Error message from the API, if any
+	Type       string        // Provider-specific error type or status, if any
+	RetryAfter time.Duration // Server-requested delay from Retry-After, if any
+	Err        error         // Classified cause, e.g. ErrContextLengthExceeded, if any
+}
+
+// Error implements the error interface.
+func (e *ProviderError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s error: status %d", e.Provider, e.StatusCode)
+	}
+	return fmt.Sprintf("%s error (%d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// Unwrap returns the classified cause, so errors.Is matches sentinels such
+// as ErrContextLengthExceeded.
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the request may succeed if retried.
+// Rate limits, timeouts, and server errors are retryable; other client
+// errors such as invalid credentials or malformed input are not.
+func (e *ProviderError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return e.StatusCode >= 500
+}
+
+// isTimeout reports whether err is a timeout, after which the server may or
+// may not have processed the request. Connection failures are not timeouts.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryableTimeout marks a timeout that retry stages may re-send, because
+// WithRetryTimeouts or WithAttemptTimeout asked for it. It carries the
+// decision in the error itself, since the request a timed-out attempt was
+// working on may still be written by its abandoned call.
+type retryableTimeout struct {
+	err error
+}
+
+func (e *retryableTimeout) Error() string { return e.err.Error() }
+func (e *retryableTimeout) Unwrap() error { return e.err }
+
+// markRetryable wraps err as a retryableTimeout if it is a timeout.
+func markRetryable(err error) error {
+	if err == nil || !isTimeout(err) {
+		return err
+	}
+	return &retryableTimeout{err: err}
+}
+
+// BatchError is returned when some sub-batches of a split request fail.
+// It unwraps to every constituent error, so errors.Is and errors.As match
+// any of them.
+type BatchError struct {
+	Failures []BatchFailure // Failed sub-batches, in input order
+	partial  []Vector
+}
+
+// BatchFailure describes one failed sub-batch covering texts[Start:End].
+type BatchFailure struct {
+	Err   error
+	Start int
+	End   int
+}
+
+// NewBatchError creates a BatchError from failures and the vectors that did
+// succeed, aligned with the original texts (nil where a text failed).
+func NewBatchError(failures []BatchFailure, partial []Vector) *BatchError {
+	return &BatchError{Failures: failures, partial: partial}
+}
+
+// Error implements the error interface.
+func (e *BatchError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d sub-batches failed", len(e.Failures))
+	for _, f := range e.Failures {
+		fmt.Fprintf(&b, "; [%d:%d]: %v", f.Start, f.End, f.Err)
+	}
+	return b.String()
+}
+
+// Unwrap returns the constituent errors.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// Partial returns the vectors that succeeded, aligned with the original
+// texts. Entries for texts in failed sub-batches are nil.
+func (e *BatchError) Partial() []Vector {
+	return e.partial
+}
@@ -0,0 +1,95 @@
+package vex
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestProviderError_Error(t *testing.T) {
+	t.Run("includes message", func(t *testing.T) {
+		err := &ProviderError{Provider: "openai", StatusCode: 401, Message: "Invalid API key"}
+		if got := err.Error(); got != "openai error (401): Invalid API key" {
+			t.Errorf("unexpected message %q", got)
+		}
+	})
+
+	t.Run("falls back to status", func(t *testing.T) {
+		err := &ProviderError{Provider: "cohere", StatusCode: 502}
+		if got := err.Error(); got != "cohere error: status 502" {
+			t.Errorf("unexpected message %q", got)
+		}
+	})
+}
+
+func TestProviderError_Retryable(t *testing.T) {
+	tests := []struct {
+		status    int
+		retryable bool
+	}{
+		{400, false},
+		{401, false},
+		{403, false},
+		{404, false},
+		{408, true},
+		{429, true},
+		{500, true},
+		{503, true},
+	}
+
+	for _, tt := range tests {
+		err := &ProviderError{Provider: "test", StatusCode: tt.status}
+		if err.Retryable() != tt.retryable {
+			t.Errorf("status %d: expected retryable %t", tt.status, tt.retryable)
+		}
+	}
+}
+
+func TestProviderError_As(t *testing.T) {
+	wrapped := fmt.Errorf("embedding failed: %w", &ProviderError{Provider: "voyage", StatusCode: 429})
+
+	var perr *ProviderError
+	if !errors.As(wrapped, &perr) {
+		t.Fatal("expected errors.As to find ProviderError")
+	}
+	if perr.StatusCode != 429 {
+		t.Errorf("expected status 429, got %d", perr.StatusCode)
+	}
+}
+
+func TestProviderError_Unwrap(t *testing.T) {
+	err := fmt.Errorf("embedding failed: %w", &ProviderError{
+		Provider:   "cohere",
+		StatusCode: 400,
+		Err:        ErrContextLengthExceeded,
+	})
+	if !errors.Is(err, ErrContextLengthExceeded) {
+		t.Error("expected errors.Is to match classified cause")
+	}
+	if errors.Is(&ProviderError{Provider: "cohere", StatusCode: 400}, ErrContextLengthExceeded) {
+		t.Error("expected unclassified error not to match")
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := &ProviderError{Provider: "test", StatusCode: 503}
+	err := NewBatchError([]BatchFailure{
+		{Err: errA, Start: 0, End: 2},
+		{Err: errB, Start: 4, End: 6},
+	}, []Vector{nil, nil, {1}, {2}, nil, nil})
+
+	if !errors.Is(err, errA) {
+		t.Error("expected errors.Is to match first failure")
+	}
+	var perr *ProviderError
+	if !errors.As(err, &perr) || perr.StatusCode != 503 {
+		t.Error("expected errors.As to find ProviderError")
+	}
+	if got := err.Error(); got != "2 sub-batches failed; [0:2]: a failed; [4:6]: test error: status 503" {
+		t.Errorf("unexpected message %q", got)
+	}
+	if len(err.Partial()) != 6 || err.Partial()[2][0] != 1 {
+		t.Errorf("unexpected partial results %v", err.Partial())
+	}
+}
@@ -0,0 +1,135 @@
+package vex_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zoobzio/vex"
+	vextesting "github.com/zoobzio/vex/testing"
+)
+
+// animals fixes the mock's vectors so the examples have known similarities:
+// the first axis is "feline", the second "canine", the third "vehicle".
+var animals = map[string]vex.Vector{
+	"cat":          {1, 0, 0},
+	"kitten":       {0.9, 0.1, 0},
+	"dog":          {0, 1, 0},
+	"truck":        {0, 0, 1},
+	"a small cat?": {0.8, 0.2, 0},
+}
+
+func ExampleNewService() {
+	provider := vextesting.NewMockProvider(vextesting.MockConfig{Dimensions: 8, Deterministic: true})
+
+	// Options wrap the provider call; the first listed is the outermost.
+	svc := vex.NewService(provider,
+		vex.WithTimeout(5*time.Second),
+		vex.WithRetry(3),
+	)
+
+	vec, err := svc.Embed(context.Background(), "hello world")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("dimensions: %d, norm: %.2f\n", len(vec), vec.Norm())
+	// Output: dimensions: 8, norm: 1.00
+}
+
+func ExampleService_Batch_withChunking() {
+	provider := vextesting.NewMockProvider(vextesting.MockConfig{Dimensions: 8, Deterministic: true})
+	chunker := &vex.Chunker{Strategy: vex.ChunkFixed, MaxSize: 20}
+	svc := vex.NewService(provider).WithChunker(chunker).WithPooling(vex.PoolMean)
+
+	long := strings.Repeat("a long document ", 5)
+	vectors, err := svc.Batch(context.Background(), []string{"short", long})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// The long text is embedded chunk by chunk and pooled back into one
+	// vector, so there is still one vector per input.
+	fmt.Printf("chunks: %d\n", len(chunker.Chunk(long)))
+	fmt.Printf("vectors: %d\n", len(vectors))
+	// Output:
+	// chunks: 4
+	// vectors: 2
+}
+
+func ExampleWithFallback() {
+	primary := vextesting.NewMockProvider(vextesting.MockConfig{
+		Dimensions: 3,
+		Error:      errors.New("primary unavailable"),
+	})
+	backup := vextesting.NewMockProvider(vextesting.MockConfig{Dimensions: 3, Vectors: animals})
+
+	svc := vex.NewService(primary, vex.WithFallback(vex.NewService(backup)))
+	vec, err := svc.Embed(context.Background(), "cat")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(vec)
+	fmt.Printf("primary calls: %d, backup calls: %d\n", primary.CallCount(), backup.CallCount())
+	// Output:
+	// [1,0,0]
+	// primary calls: 1, backup calls: 1
+}
+
+func ExampleTopK() {
+	provider := vextesting.NewMockProvider(vextesting.MockConfig{Dimensions: 3, Vectors: animals})
+	svc := vex.NewService(provider)
+	ctx := context.Background()
+
+	docs := []string{"cat", "kitten", "dog", "truck"}
+	vectors, err := svc.Batch(ctx, docs)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	corpus := make(map[string]vex.Vector, len(docs))
+	for i, doc := range docs {
+		corpus[doc] = vectors[i]
+	}
+
+	query, err := svc.EmbedQuery(ctx, "a small cat?")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, m := range vex.TopK(query, corpus, vex.Cosine, 2) {
+		fmt.Printf("%s %.3f\n", m.ID, m.Score)
+	}
+	// Output:
+	// kitten 0.991
+	// cat 0.970
+}
+
+func ExampleService_EmbedQuery() {
+	provider := vextesting.NewMockProvider(vextesting.MockConfig{Dimensions: 3, Vectors: animals})
+	svc := vex.NewService(provider)
+	ctx := context.Background()
+
+	// The mock has no separate query mode, so queries are embedded like
+	// documents; providers such as Voyage and Cohere embed them differently.
+	fmt.Println("query mode:", svc.SupportsQueryMode())
+
+	query, err := svc.EmbedQuery(ctx, "a small cat?")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	doc, err := svc.Embed(ctx, "dog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("similarity to dog: %.3f\n", query.CosineSimilarity(doc))
+	// Output:
+	// query mode: false
+	// similarity to dog: 0.243
+}
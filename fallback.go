@@ -0,0 +1,71 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/zoobzio/pipz"
+)
+
+// fallback runs a backup pipeline when the primary fails, unless the
+// failure came from the caller's own context. A canceled or expired caller
+// will never consume the backup's result, and counting the call against the
+// backup's circuit breaker would skew it.
+//
+// The context checked is the one handed to the fallback stage, so deadlines
+// from WithTimeout stages nested inside the primary, and provider-side
+// timeouts, still fail over.
+type fallback struct {
+	identity  pipz.Identity
+	primary   pipz.Chainable[*EmbedRequest]
+	backup    pipz.Chainable[*EmbedRequest]
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newFallback(identity pipz.Identity, primary, backup pipz.Chainable[*EmbedRequest]) *fallback {
+	return &fallback{
+		identity: identity,
+		primary:  primary,
+		backup:   backup,
+	}
+}
+
+// Process implements pipz.Chainable.
+func (f *fallback) Process(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+	// The backup gets a copy taken up front: a primary abandoned by a
+	// WithTimeout stage may still be writing to req.
+	backupReq := *req
+	out, err := f.primary.Process(ctx, req)
+	if err == nil || ctx.Err() != nil {
+		return out, err
+	}
+	// The backup service calls its own provider, not the caller's override.
+	return f.backup.Process(withoutProviderOverride(ctx), &backupReq)
+}
+
+// Identity implements pipz.Chainable.
+func (f *fallback) Identity() pipz.Identity {
+	return f.identity
+}
+
+// Schema implements pipz.Chainable.
+func (f *fallback) Schema() pipz.Node {
+	return pipz.Node{
+		Identity: f.identity,
+		Type:     "fallback",
+		Flow: pipz.FallbackFlow{
+			Primary: f.primary.Schema(),
+			Backups: []pipz.Node{f.backup.Schema()},
+		},
+	}
+}
+
+// Close implements pipz.Chainable.
+func (f *fallback) Close() error {
+	f.closeOnce.Do(func() {
+		f.closeErr = errors.Join(f.backup.Close(), f.primary.Close())
+	})
+	return f.closeErr
+}
@@ -0,0 +1,122 @@
+package vex
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// FingerprintDetails records every Service setting that affects the vectors
+// it produces, for reproducibility records alongside stored embeddings.
+type FingerprintDetails struct {
+	Provider          string          `json:"provider"`
+	Model             string          `json:"model,omitempty"` // Empty if the provider does not implement ModelReporter
+	QueryModel        string          `json:"query_model,omitempty"`
+	Dimensions        int             `json:"dimensions"`
+	Normalize         bool            `json:"normalize"`
+	Pooling           PoolingMode     `json:"pooling"`
+	AdaptiveThreshold int             `json:"adaptive_threshold,omitempty"` // Set only when Pooling is PoolAdaptive
+	MaxInputChars     int             `json:"max_input_chars,omitempty"`
+	Truncation        TruncationMode  `json:"truncation,omitempty"`
+	Chunking          ChunkingDetails `json:"chunking"`
+	TitleHandling     TitleHandling   `json:"title_handling,omitempty"` // How BatchDocuments passes titles
+
+	// Instruction and InstructionTemplate record WithInstruction; the
+	// template is empty when no instruction is set.
+	Instruction         string `json:"instruction,omitempty"`
+	InstructionTemplate string `json:"instruction_template,omitempty"`
+
+	// LibraryVersion records the vex Version that produced the vectors. It
+	// is informational and excluded from Fingerprint, so upgrading the
+	// library does not invalidate stored fingerprints.
+	LibraryVersion string `json:"library_version,omitempty"`
+}
+
+// ChunkingDetails records the chunker configuration within FingerprintDetails.
+type ChunkingDetails struct {
+	Abbreviations          []string        `json:"abbreviations,omitempty"`
+	Strategy               ChunkStrategy   `json:"strategy"`
+	MaxSize                int             `json:"max_size,omitempty"`
+	Overlap                int             `json:"overlap,omitempty"`
+	UnitOverlap            int             `json:"unit_overlap,omitempty"`
+	TrimSpace              bool            `json:"trim_space,omitempty"`
+	DropIncompleteTrailing bool            `json:"drop_incomplete_trailing,omitempty"`
+	PoolExcludeOverlap     bool            `json:"pool_exclude_overlap,omitempty"`
+	Auto                   *AutoThresholds `json:"auto,omitempty"`
+}
+
+// FingerprintDetails returns the service's current embedding configuration.
+// It is computed on each call, so it reflects any builder calls since.
+func (s *Service) FingerprintDetails() FingerprintDetails {
+	details := FingerprintDetails{
+		Provider:      s.provider.Name(),
+		Model:         requestedModel(s.provider),
+		Dimensions:    s.provider.Dimensions(),
+		Normalize:     s.normalize,
+		Pooling:       s.poolingMode,
+		TitleHandling: s.titleHandling,
+
+		LibraryVersion: Version(),
+	}
+	if s.queryProvider != nil {
+		details.QueryModel = requestedModel(s.queryProvider)
+	}
+	if s.instruction != "" {
+		details.Instruction = s.instruction
+		details.InstructionTemplate = s.instructionTemplate()
+	}
+	if s.poolingMode == PoolAdaptive {
+		details.AdaptiveThreshold = s.adaptiveLimit
+	}
+	if s.maxInputChars > 0 {
+		details.MaxInputChars = s.maxInputChars
+		details.Truncation = s.truncation
+	}
+	if c := s.chunker; c != nil {
+		details.Chunking = ChunkingDetails{
+			Abbreviations:          c.Abbreviations,
+			Strategy:               c.Strategy,
+			MaxSize:                c.MaxSize,
+			Overlap:                c.Overlap,
+			UnitOverlap:            c.UnitOverlap,
+			TrimSpace:              c.TrimSpace,
+			DropIncompleteTrailing: c.DropIncompleteTrailing,
+			PoolExcludeOverlap:     c.PoolExcludeOverlap,
+		}
+		if c.Strategy == ChunkAuto {
+			t := c.thresholds()
+			details.Chunking.Auto = &t
+		}
+	}
+	return details
+}
+
+// Fingerprint returns a stable hex-encoded SHA-256 hash of FingerprintDetails.
+// Identically configured services share a fingerprint; any change to a
+// setting that affects output vectors changes it. The hash is cached until
+// the next builder call, so fields of a Chunker changed in place after
+// WithChunker are not reflected until then.
+func (s *Service) Fingerprint() string {
+	if fp := s.fingerprint.Load(); fp != nil {
+		return *fp
+	}
+	details := s.FingerprintDetails()
+	fp := details.hash()
+	s.fingerprint.Store(&fp)
+	return fp
+}
+
+// hash returns the SHA-256 of the details' canonical JSON encoding, less
+// LibraryVersion. Struct fields encode in declaration order, so the encoding
+// is stable.
+func (d *FingerprintDetails) hash() string {
+	hashed := *d
+	hashed.LibraryVersion = ""
+	data, err := json.Marshal(&hashed)
+	if err != nil {
+		// Details contain only strings, numbers, and bools.
+		panic("vex: failed to encode fingerprint: " + err.Error())
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
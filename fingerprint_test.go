@@ -0,0 +1,115 @@
+package vex
+
+import (
+	"context"
+	"testing"
+)
+
+func TestService_Fingerprint(t *testing.T) {
+	newSvc := func() *Service {
+		return NewService(newMockProvider(8))
+	}
+
+	t.Run("identical configuration shares fingerprint", func(t *testing.T) {
+		a, b := newSvc(), newSvc()
+		if a.Fingerprint() != b.Fingerprint() {
+			t.Error("expected identical services to share a fingerprint")
+		}
+		if len(a.Fingerprint()) != 64 {
+			t.Errorf("expected 64 hex characters, got %d", len(a.Fingerprint()))
+		}
+	})
+
+	t.Run("any single change alters fingerprint", func(t *testing.T) {
+		base := newSvc().Fingerprint()
+		changes := map[string]*Service{
+			"provider":        NewService(&retryTestProvider{dims: 8}),
+			"dimensions":      NewService(newMockProvider(16)),
+			"normalize":       newSvc().WithNormalize(false),
+			"pooling":         newSvc().WithPooling(PoolMax),
+			"max input chars": newSvc().WithMaxInputChars(100, TruncateTail),
+			"chunk strategy":  newSvc().WithChunker(&Chunker{Strategy: ChunkParagraph, TrimSpace: true}),
+			"chunk size":      newSvc().WithChunker(&Chunker{Strategy: ChunkFixed, MaxSize: 100}),
+			"instruction":     newSvc().WithInstruction("Retrieve passages"),
+			"title handling":  newSvc().WithTitleHandling(TitlePrependChunks),
+		}
+		for name, svc := range changes {
+			if svc.Fingerprint() == base {
+				t.Errorf("%s: expected fingerprint to change", name)
+			}
+		}
+	})
+
+	t.Run("unit overlap alters fingerprint", func(t *testing.T) {
+		plain := newSvc().WithChunker(&Chunker{Strategy: ChunkSentence, MaxSize: 100})
+		overlapping := newSvc().WithChunker(&Chunker{Strategy: ChunkSentence, MaxSize: 100, UnitOverlap: 1})
+		if plain.Fingerprint() == overlapping.Fingerprint() {
+			t.Error("expected UnitOverlap to change the fingerprint")
+		}
+	})
+
+	t.Run("adaptive threshold alters fingerprint", func(t *testing.T) {
+		a := newSvc().WithPooling(PoolAdaptive)
+		b := newSvc().WithPooling(PoolAdaptive).WithAdaptivePooling(DefaultAdaptivePoolThreshold + 1)
+		if a.Fingerprint() == b.Fingerprint() {
+			t.Error("expected the adaptive threshold to change the fingerprint")
+		}
+		if got := a.FingerprintDetails().AdaptiveThreshold; got != DefaultAdaptivePoolThreshold {
+			t.Errorf("expected threshold %d, got %d", DefaultAdaptivePoolThreshold, got)
+		}
+		if got := newSvc().FingerprintDetails().AdaptiveThreshold; got != 0 {
+			t.Errorf("expected no threshold without PoolAdaptive, got %d", got)
+		}
+	})
+
+	t.Run("reflects mutations after construction", func(t *testing.T) {
+		svc := newSvc()
+		before := svc.Fingerprint()
+		svc.WithNormalize(false)
+		if svc.Fingerprint() == before {
+			t.Error("expected fingerprint to be recomputed")
+		}
+	})
+
+	t.Run("is cached until the next builder call", func(t *testing.T) {
+		svc := newSvc()
+		before := svc.Fingerprint()
+		svc.normalize = false
+		if svc.Fingerprint() != before {
+			t.Error("expected cached fingerprint")
+		}
+		svc.WithPooling(PoolMean)
+		if svc.Fingerprint() == before {
+			t.Error("expected builder call to clear the cache")
+		}
+	})
+
+	t.Run("details report configuration", func(t *testing.T) {
+		details := newSvc().WithPooling(PoolFirst).FingerprintDetails()
+		if details.Provider != "mock" || details.Dimensions != 8 || details.Pooling != PoolFirst {
+			t.Errorf("unexpected details: %+v", details)
+		}
+		if details.Chunking.Strategy != ChunkNone || details.Chunking.MaxSize != 512 {
+			t.Errorf("expected default chunker, got %+v", details.Chunking)
+		}
+	})
+}
+
+func TestEmbedCompleted_Fingerprint(t *testing.T) {
+	provider := newMockProvider(8)
+	provider.name = "fingerprinted"
+	recorder := recordEvents(t, EmbedCompleted, "fingerprinted")
+
+	svc := NewService(provider)
+	if _, err := svc.Embed(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := recorder.Events(t)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if fp, _ := FingerprintKey.From(events[0]); fp != svc.Fingerprint() {
+		t.Errorf("expected fingerprint %q, got %q", svc.Fingerprint(), fp)
+	}
+}
@@ -5,19 +5,35 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/zoobzio/vex"
+	"github.com/zoobzio/vex/internal/batching"
+	"github.com/zoobzio/vex/internal/httputil"
 )
 
 // Default dimensions for Gemini models.
 const (
-	DimensionsTextEmbedding004 = 768
+	DimensionsTextEmbedding004   = 768
+	DimensionsGeminiEmbedding001 = 3072
 )
 
+// Input token limits for Gemini models.
+const (
+	MaxTokensTextEmbedding004   = 2048
+	MaxTokensGeminiEmbedding001 = 2048
+)
+
+// DefaultMaxBatchSize is the maximum number of contents per
+// batchEmbedContents request.
+const DefaultMaxBatchSize = 100
+
 // TaskType specifies the downstream task for the embedding.
 type TaskType string
 
@@ -28,26 +44,101 @@ const (
 	TaskTypeSemantic          TaskType = "SEMANTIC_SIMILARITY"
 	TaskTypeClassification    TaskType = "CLASSIFICATION"
 	TaskTypeClustering        TaskType = "CLUSTERING"
+	TaskTypeQuestionAnswering TaskType = "QUESTION_ANSWERING"
+	TaskTypeFactVerification  TaskType = "FACT_VERIFICATION"
+
+	// TaskTypeCodeRetrievalQuery embeds natural-language queries for
+	// retrieving code blocks, which are embedded as RETRIEVAL_DOCUMENT.
+	// Supported by gemini-embedding-001 only.
+	TaskTypeCodeRetrievalQuery TaskType = "CODE_RETRIEVAL_QUERY"
 )
 
+// apiKeyHeader carries the API key, which is kept out of request URLs so it
+// cannot leak into error messages or proxy logs.
+const apiKeyHeader = "x-goog-api-key"
+
+// TokenSource supplies OAuth 2.0 access tokens, e.g. for a service account.
+// Token is called before every request, so implementations should cache the
+// token and refresh it before it expires. A golang.org/x/oauth2 TokenSource
+// does both and adapts with TokenSourceFunc:
+//
+//	gemini.TokenSourceFunc(func(context.Context) (string, error) {
+//		tok, err := ts.Token()
+//		if err != nil {
+//			return "", err
+//		}
+//		return tok.AccessToken, nil
+//	})
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to TokenSource.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token implements TokenSource.
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
 // Provider implements vex.Provider for Google Gemini embeddings API.
 type Provider struct {
-	httpClient *http.Client
-	apiKey     string
-	model      string
-	baseURL    string
-	taskType   TaskType
-	dimensions int
+	configErr    error
+	taskTypeErr  error // see checkTaskType; reset by WithTaskType
+	httpClient   *http.Client
+	tokenSource  TokenSource
+	apiKey       string
+	model        string
+	baseURL      string
+	taskType     TaskType
+	extra        map[string]any
+	headers      map[string]string
+	dimensions   int
+	outputDims   int // sent as outputDimensionality; zero for the model default
+	maxBatchSize int
 }
 
 // Config holds configuration for the Gemini embedding provider.
 type Config struct {
-	APIKey     string
-	Model      string
-	BaseURL    string
-	TaskType   TaskType
+	// APIKey authenticates requests. Exactly one of APIKey and TokenSource
+	// must be set.
+	APIKey   string
+	Model    string
+	BaseURL  string
+	TaskType TaskType
+	Timeout  time.Duration
+
+	// Dimensions requests reduced-size vectors via outputDimensionality when
+	// it differs from the model's default, e.g. 256 for text-embedding-004.
+	// Google recommends 768, 1536 or 3072 for gemini-embedding-001.
+	// Truncated vectors are not unit length; keep the Service's default
+	// normalization on when comparing them by dot product. Optional.
 	Dimensions int
-	Timeout    time.Duration
+
+	// Extra holds additional fields, e.g. for newly released parameters,
+	// merged into each text's entry of the batchEmbedContents request, where
+	// the API expects per-content parameters. Fields the provider sets itself
+	// are not overridden. Optional.
+	Extra map[string]any
+
+	// Headers are set on every request after Content-Type and
+	// authentication, overriding them when the names match. Optional.
+	Headers map[string]string
+
+	// MaxBatchSize caps contents per batchEmbedContents request; larger
+	// batches are split into sequential sub-requests. Lower it if long
+	// texts push requests past the API's payload limit. Optional, defaults
+	// to DefaultMaxBatchSize.
+	MaxBatchSize int
+
+	// TokenSource authenticates requests with OAuth bearer tokens instead
+	// of an API key, for organizations restricted to service-account
+	// credentials. Exactly one of APIKey and TokenSource must be set.
+	TokenSource TokenSource
+
+	// HTTPClient is used for all requests when set, e.g. for proxies, mTLS,
+	// or instrumented transports. Timeout is applied if the client has none.
+	HTTPClient *http.Client
 }
 
 // New creates a new Gemini embedding provider.
@@ -61,22 +152,41 @@ func New(config Config) *Provider {
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
+	var outputDims int
 	if config.Dimensions == 0 {
-		config.Dimensions = DimensionsTextEmbedding004
+		config.Dimensions = dimensionsForModel(config.Model)
+	} else if config.Dimensions != dimensionsForModel(config.Model) {
+		outputDims = config.Dimensions
 	}
 	if config.TaskType == "" {
 		config.TaskType = TaskTypeRetrievalDocument
 	}
+	if config.MaxBatchSize == 0 {
+		config.MaxBatchSize = DefaultMaxBatchSize
+	}
+
+	var configErr error
+	switch {
+	case config.APIKey != "" && config.TokenSource != nil:
+		configErr = errors.New("gemini: set either APIKey or TokenSource, not both")
+	case config.APIKey == "" && config.TokenSource == nil:
+		configErr = errors.New("gemini: APIKey or TokenSource is required")
+	}
 
 	return &Provider{
-		apiKey:     config.APIKey,
-		model:      config.Model,
-		baseURL:    config.BaseURL,
-		dimensions: config.Dimensions,
-		taskType:   config.TaskType,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+		configErr:    configErr,
+		taskTypeErr:  checkTaskType(config.Model, config.TaskType),
+		tokenSource:  config.TokenSource,
+		apiKey:       config.APIKey,
+		model:        config.Model,
+		baseURL:      config.BaseURL,
+		dimensions:   config.Dimensions,
+		outputDims:   outputDims,
+		taskType:     config.TaskType,
+		maxBatchSize: config.MaxBatchSize,
+		extra:        maps.Clone(config.Extra),
+		headers:      maps.Clone(config.Headers),
+		httpClient:   httputil.StripOnRedirect(httputil.NewClient(config.HTTPClient, config.Timeout), apiKeyHeader),
 	}
 }
 
@@ -90,10 +200,24 @@ func (p *Provider) Dimensions() int {
 	return p.dimensions
 }
 
+// MaxTokens returns the per-input token limit.
+// Implements vex.TokenLimiter.
+func (p *Provider) MaxTokens() int {
+	return maxTokensForModel(p.model)
+}
+
+// Model returns the requested model identifier.
+// Implements vex.ModelReporter.
+func (p *Provider) Model() string {
+	return p.model
+}
+
 // WithTaskType returns a new provider with the specified task type.
+// An unsupported task type is reported by the new provider's Embed.
 func (p *Provider) WithTaskType(taskType TaskType) *Provider {
 	newP := *p
 	newP.taskType = taskType
+	newP.taskTypeErr = checkTaskType(p.model, taskType)
 	return &newP
 }
 
@@ -103,8 +227,47 @@ func (p *Provider) ForQuery() vex.Provider {
 	return p.WithTaskType(TaskTypeRetrievalQuery)
 }
 
-// Embed generates embeddings for the given texts.
+// Embed generates embeddings for the given texts. A single text is sent to
+// embedContent; larger inputs go to batchEmbedContents, split into
+// sub-requests of at most the configured MaxBatchSize.
 func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+	return p.embed(ctx, texts, nil)
+}
+
+// EmbedTitled generates embeddings for texts with titles[i] sent as the
+// title of texts[i]. The API only accepts titles for RETRIEVAL_DOCUMENT
+// embeddings, so they are dropped for other task types.
+// Implements vex.TitledEmbedder.
+func (p *Provider) EmbedTitled(ctx context.Context, texts, titles []string) (*vex.EmbeddingResponse, error) {
+	if len(titles) != len(texts) {
+		return nil, fmt.Errorf("gemini: got %d titles for %d texts", len(titles), len(texts))
+	}
+	if p.taskType != TaskTypeRetrievalDocument {
+		titles = nil
+	}
+	return p.embed(ctx, texts, titles)
+}
+
+// EmbedDocuments generates embeddings for docs, sending each Title with its
+// Text under the same task type rule as EmbedTitled. Documents without
+// chunking or pooling can skip the Service and call this directly.
+func (p *Provider) EmbedDocuments(ctx context.Context, docs []vex.Document) (*vex.EmbeddingResponse, error) {
+	texts := make([]string, len(docs))
+	titles := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Text
+		titles[i] = doc.Title
+	}
+	return p.EmbedTitled(ctx, texts, titles)
+}
+
+func (p *Provider) embed(ctx context.Context, texts, titles []string) (*vex.EmbeddingResponse, error) {
+	if p.configErr != nil {
+		return nil, p.configErr
+	}
+	if p.taskTypeErr != nil {
+		return nil, p.taskTypeErr
+	}
 	if len(texts) == 0 {
 		return &vex.EmbeddingResponse{
 			Vectors:    nil,
@@ -112,64 +275,154 @@ func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingRes
 			Dimensions: p.dimensions,
 		}, nil
 	}
+	if len(texts) == 1 {
+		return p.embedOne(ctx, p.newContentRequest(texts, titles, 0))
+	}
 
-	// Gemini uses batch embedding endpoint
-	requests := make([]embedContentRequest, len(texts))
-	for i, text := range texts {
-		requests[i] = embedContentRequest{
-			Model: "models/" + p.model,
-			Content: content{
-				Parts: []part{{Text: text}},
-			},
-			TaskType: string(p.taskType),
+	// batching.Embed issues sub-batches in order and stops at the first
+	// failure, so a running offset keeps titles aligned.
+	offset := 0
+	return batching.Embed(ctx, texts, p.maxBatchSize, func(ctx context.Context, batch []string) (*vex.EmbeddingResponse, error) {
+		var batchTitles []string
+		if titles != nil {
+			batchTitles = titles[offset : offset+len(batch)]
 		}
+		offset += len(batch)
+		return p.embedBatch(ctx, batch, batchTitles)
+	})
+}
+
+// newContentRequest builds the request for texts[i], with its title if any.
+func (p *Provider) newContentRequest(texts, titles []string, i int) embedContentRequest {
+	req := embedContentRequest{
+		Model: "models/" + p.model,
+		Content: content{
+			Parts: []part{{Text: texts[i]}},
+		},
+		TaskType:             string(p.taskType),
+		OutputDimensionality: p.outputDims,
+		extra:                p.extra,
+	}
+	if titles != nil {
+		req.Title = titles[i]
 	}
+	return req
+}
 
-	reqBody := batchEmbedRequest{
-		Requests: requests,
+// embedOne issues a single embedContent request, which is cheaper than a
+// batch of one.
+func (p *Provider) embedOne(ctx context.Context, reqBody embedContentRequest) (*vex.EmbeddingResponse, error) {
+	body, err := p.post(ctx, "embedContent", reqBody)
+	if err != nil {
+		return nil, err
 	}
 
+	var embResp embedContentResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return p.toEmbeddingResponse([]embedding{embResp.Embedding})
+}
+
+// embedBatch issues a single batchEmbedContents request.
+func (p *Provider) embedBatch(ctx context.Context, texts, titles []string) (*vex.EmbeddingResponse, error) {
+	requests := make([]embedContentRequest, len(texts))
+	for i := range texts {
+		requests[i] = p.newContentRequest(texts, titles, i)
+	}
+
+	body, err := p.post(ctx, "batchEmbedContents", batchEmbedRequest{Requests: requests})
+	if err != nil {
+		return nil, err
+	}
+
+	var embResp batchEmbedResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return p.toEmbeddingResponse(embResp.Embeddings)
+}
+
+// post sends reqBody to the model's method endpoint and returns the
+// response body. Non-200 responses are returned as *vex.ProviderError.
+func (p *Provider) post(ctx context.Context, method string, reqBody any) ([]byte, error) {
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/models/%s:batchEmbedContents?key=%s", p.baseURL, p.model, p.apiKey)
+	url := fmt.Sprintf("%s/models/%s:%s", p.baseURL, p.model, method)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	httputil.SetRequestID(req)
+	authHeader, err := p.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	httputil.SetHeaders(req, p.headers)
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", httputil.RedactURL(err))
 	}
 	defer resp.Body.Close()
 
+	if err := httputil.CheckRedirectHeader(req, resp, authHeader); err != nil {
+		return nil, err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		perr := &vex.ProviderError{
+			Provider:   "gemini",
+			StatusCode: resp.StatusCode,
+			RetryAfter: httputil.RetryAfter(resp.Header, time.Now()),
+		}
 		var errResp errorResponse
-		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
-			return nil, fmt.Errorf("gemini error (%d): %s", resp.StatusCode, errResp.Error.Message)
+		if err := json.Unmarshal(body, &errResp); err == nil {
+			perr.Message = errResp.Error.Message
+			perr.Type = errResp.Error.Status
 		}
-		return nil, fmt.Errorf("gemini error: status %d", resp.StatusCode)
+		return nil, perr
 	}
+	return body, nil
+}
 
-	var embResp batchEmbedResponse
-	if err := json.Unmarshal(body, &embResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// authenticate sets req's credential, fetching a fresh token from the
+// TokenSource if one is configured, and returns the header it used.
+func (p *Provider) authenticate(ctx context.Context, req *http.Request) (string, error) {
+	if p.tokenSource == nil {
+		req.Header.Set(apiKeyHeader, p.apiKey)
+		return apiKeyHeader, nil
 	}
+	token, err := p.tokenSource.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("gemini: fetching access token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return "Authorization", nil
+}
 
-	vectors := make([]vex.Vector, len(embResp.Embeddings))
-	for i, emb := range embResp.Embeddings {
+// toEmbeddingResponse validates the returned embeddings and converts them.
+func (p *Provider) toEmbeddingResponse(embeddings []embedding) (*vex.EmbeddingResponse, error) {
+	vectors := make([]vex.Vector, len(embeddings))
+	for i, emb := range embeddings {
 		vectors[i] = toFloat32(emb.Values)
 	}
+	if err := vex.ValidateVectors("gemini", vectors); err != nil {
+		return nil, err
+	}
+	if p.outputDims > 0 && len(vectors) > 0 && len(vectors[0]) != p.outputDims {
+		return nil, fmt.Errorf("%w: gemini returned %d dimensions, requested %d", vex.ErrDimensionMismatch, len(vectors[0]), p.outputDims)
+	}
 
 	dims := p.dimensions
 	if len(vectors) > 0 && len(vectors[0]) > 0 {
@@ -180,13 +433,62 @@ func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingRes
 		Vectors:    vectors,
 		Model:      p.model,
 		Dimensions: dims,
-		Usage: vex.Usage{
-			PromptTokens: len(texts), // Gemini doesn't return token counts
-			TotalTokens:  len(texts),
-		},
+		// Usage is left zero: Gemini doesn't return token counts.
 	}, nil
 }
 
+// dimensionsForModel returns the default output dimensions for a model.
+func dimensionsForModel(model string) int {
+	switch model {
+	case "gemini-embedding-001":
+		return DimensionsGeminiEmbedding001
+	default:
+		return DimensionsTextEmbedding004
+	}
+}
+
+// maxTokensForModel returns the per-input token limit for a model.
+func maxTokensForModel(model string) int {
+	switch model {
+	case "gemini-embedding-001":
+		return MaxTokensGeminiEmbedding001
+	default:
+		return MaxTokensTextEmbedding004
+	}
+}
+
+// taskTypesForModel returns the task types a model accepts, or nil for
+// models this package doesn't know, which are not validated.
+func taskTypesForModel(model string) []TaskType {
+	common := []TaskType{
+		TaskTypeRetrievalQuery,
+		TaskTypeRetrievalDocument,
+		TaskTypeSemantic,
+		TaskTypeClassification,
+		TaskTypeClustering,
+		TaskTypeQuestionAnswering,
+		TaskTypeFactVerification,
+	}
+	switch model {
+	case "text-embedding-004":
+		return common
+	case "gemini-embedding-001":
+		return append(common, TaskTypeCodeRetrievalQuery)
+	default:
+		return nil
+	}
+}
+
+// checkTaskType returns an error if model is known not to accept taskType,
+// which the API would otherwise reject with a 400 on every request.
+func checkTaskType(model string, taskType TaskType) error {
+	supported := taskTypesForModel(model)
+	if supported == nil || slices.Contains(supported, taskType) {
+		return nil
+	}
+	return fmt.Errorf("gemini: task type %q is not supported by %s", taskType, model)
+}
+
 // toFloat32 converts a float64 slice to a vex.Vector (float32).
 func toFloat32(f64 []float64) vex.Vector {
 	result := make(vex.Vector, len(f64))
@@ -205,7 +507,18 @@ type batchEmbedRequest struct {
 type embedContentRequest struct {
 	Model    string  `json:"model"`
 	TaskType string  `json:"taskType,omitempty"`
+	Title    string  `json:"title,omitempty"`
 	Content  content `json:"content"`
+
+	OutputDimensionality int `json:"outputDimensionality,omitempty"`
+
+	extra map[string]any // Config.Extra, merged in by MarshalJSON
+}
+
+// MarshalJSON implements json.Marshaler, adding the provider's extra fields.
+func (r embedContentRequest) MarshalJSON() ([]byte, error) {
+	type plain embedContentRequest
+	return httputil.MarshalBody(plain(r), r.extra)
 }
 
 type content struct {
@@ -216,6 +529,10 @@ type part struct {
 	Text string `json:"text"`
 }
 
+type embedContentResponse struct {
+	Embedding embedding `json:"embedding"`
+}
+
 type batchEmbedResponse struct {
 	Embeddings []embedding `json:"embeddings"`
 }
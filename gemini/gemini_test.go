@@ -3,12 +3,16 @@ package gemini
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"github.com/zoobzio/vex"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
-
-	"github.com/zoobzio/vex"
+	"time"
 )
 
 func TestProvider_Name(t *testing.T) {
@@ -34,8 +38,11 @@ func TestProvider_Embed(t *testing.T) {
 			if !strings.Contains(r.URL.Path, "batchEmbedContents") {
 				t.Errorf("expected batchEmbedContents in path, got %s", r.URL.Path)
 			}
-			if !strings.Contains(r.URL.RawQuery, "key=test-key") {
-				t.Errorf("expected API key in query, got %s", r.URL.RawQuery)
+			if r.Header.Get("x-goog-api-key") != "test-key" {
+				t.Errorf("expected API key header, got %q", r.Header.Get("x-goog-api-key"))
+			}
+			if r.URL.RawQuery != "" {
+				t.Errorf("expected no query string, got %s", r.URL.RawQuery)
 			}
 
 			resp := batchEmbedResponse{
@@ -97,8 +104,21 @@ func TestProvider_Embed(t *testing.T) {
 		})
 
 		_, err := p.Embed(context.Background(), []string{"test"})
-		if err == nil {
-			t.Error("expected error for invalid API key")
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected ProviderError, got %v", err)
+		}
+		if perr.Provider != "gemini" || perr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected provider error fields: %+v", perr)
+		}
+		if perr.Message != "Invalid API key" {
+			t.Errorf("expected message 'Invalid API key', got %q", perr.Message)
+		}
+		if perr.Type != "UNAUTHENTICATED" {
+			t.Errorf("expected type 'UNAUTHENTICATED', got %q", perr.Type)
+		}
+		if perr.Retryable() {
+			t.Error("expected 401 to be non-retryable")
 		}
 	})
 
@@ -118,7 +138,7 @@ func TestProvider_Embed(t *testing.T) {
 			}
 
 			resp := batchEmbedResponse{
-				Embeddings: []embedding{{Values: []float64{0.1}}},
+				Embeddings: []embedding{{Values: []float64{0.1}}, {Values: []float64{0.2}}},
 			}
 			if err := json.NewEncoder(w).Encode(resp); err != nil {
 				t.Fatalf("failed to encode response: %v", err)
@@ -132,7 +152,7 @@ func TestProvider_Embed(t *testing.T) {
 			TaskType: TaskTypeRetrievalDocument,
 		})
 		//nolint:errcheck // test helper
-		p.Embed(context.Background(), []string{"test"})
+		p.Embed(context.Background(), []string{"test", "two"})
 	})
 }
 
@@ -176,6 +196,61 @@ func TestProvider_ImplementsQueryProviderFactory(_ *testing.T) {
 	var _ vex.QueryProviderFactory = p
 }
 
+func TestProvider_Model(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+	if p.Model() != "text-embedding-004" {
+		t.Errorf("expected model 'text-embedding-004', got %q", p.Model())
+	}
+}
+
+// countingTransport records round trips and fails them without network access.
+type countingTransport struct {
+	calls int
+}
+
+var errIntercepted = errors.New("intercepted")
+
+func (c *countingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	c.calls++
+	return nil, errIntercepted
+}
+
+func TestConfig_HTTPClient(t *testing.T) {
+	t.Run("uses custom round tripper", func(t *testing.T) {
+		transport := &countingTransport{}
+		p := New(Config{
+			APIKey:     "test",
+			HTTPClient: &http.Client{Transport: transport},
+		})
+
+		_, err := p.Embed(context.Background(), []string{"test"})
+		if !errors.Is(err, errIntercepted) {
+			t.Errorf("expected intercepted error, got %v", err)
+		}
+		if transport.calls != 1 {
+			t.Errorf("expected 1 round trip, got %d", transport.calls)
+		}
+	})
+
+	t.Run("applies timeout when client has none", func(t *testing.T) {
+		p := New(Config{
+			APIKey:     "test",
+			Timeout:    5 * time.Second,
+			HTTPClient: &http.Client{},
+		})
+		if p.httpClient.Timeout != 5*time.Second {
+			t.Errorf("expected 5s timeout, got %v", p.httpClient.Timeout)
+		}
+	})
+}
+
+func TestProvider_MaxTokens(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+	if p.MaxTokens() != 2048 {
+		t.Errorf("expected 2048 max tokens, got %d", p.MaxTokens())
+	}
+}
+
 func TestConfig_Defaults(t *testing.T) {
 	p := New(Config{APIKey: "test"})
 
@@ -197,6 +272,9 @@ func TestTaskTypes(t *testing.T) {
 		TaskTypeSemantic,
 		TaskTypeClassification,
 		TaskTypeClustering,
+		TaskTypeQuestionAnswering,
+		TaskTypeFactVerification,
+		TaskTypeCodeRetrievalQuery,
 	}
 
 	for _, tt := range types {
@@ -205,3 +283,543 @@ func TestTaskTypes(t *testing.T) {
 		}
 	}
 }
+
+func TestModelRegistry(t *testing.T) {
+	tests := []struct {
+		model      string
+		dimensions int
+		maxTokens  int
+	}{
+		{"text-embedding-004", DimensionsTextEmbedding004, MaxTokensTextEmbedding004},
+		{"gemini-embedding-001", DimensionsGeminiEmbedding001, MaxTokensGeminiEmbedding001},
+		{"embedding-future", DimensionsTextEmbedding004, MaxTokensTextEmbedding004},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			p := New(Config{APIKey: "test", Model: tt.model})
+			if p.Dimensions() != tt.dimensions {
+				t.Errorf("expected %d dimensions, got %d", tt.dimensions, p.Dimensions())
+			}
+			if p.MaxTokens() != tt.maxTokens {
+				t.Errorf("expected %d max tokens, got %d", tt.maxTokens, p.MaxTokens())
+			}
+			if p.outputDims != 0 {
+				t.Errorf("expected no outputDimensionality at the default size, got %d", p.outputDims)
+			}
+		})
+	}
+
+	t.Run("reduced gemini-embedding-001 dimensions", func(t *testing.T) {
+		p := New(Config{APIKey: "test", Model: "gemini-embedding-001", Dimensions: 1536})
+		if p.Dimensions() != 1536 || p.outputDims != 1536 {
+			t.Errorf("expected 1536 requested dimensions, got %d (output %d)", p.Dimensions(), p.outputDims)
+		}
+	})
+}
+
+func TestCheckTaskType(t *testing.T) {
+	tests := []struct {
+		model    string
+		taskType TaskType
+		wantErr  bool
+	}{
+		{"text-embedding-004", TaskTypeRetrievalDocument, false},
+		{"text-embedding-004", TaskTypeFactVerification, false},
+		{"text-embedding-004", TaskTypeCodeRetrievalQuery, true},
+		{"text-embedding-004", "RETRIEVAL_DOCUMNET", true},
+		{"gemini-embedding-001", TaskTypeCodeRetrievalQuery, false},
+		{"gemini-embedding-001", TaskTypeQuestionAnswering, false},
+		{"gemini-embedding-001", "CODE_RETRIEVAL", true},
+		{"embedding-future", "ANYTHING", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model+"/"+string(tt.taskType), func(t *testing.T) {
+			err := checkTaskType(tt.model, tt.taskType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("fails before sending", func(t *testing.T) {
+		transport := &countingTransport{}
+		p := New(Config{APIKey: "test", TaskType: TaskTypeCodeRetrievalQuery, HTTPClient: &http.Client{Transport: transport}})
+		if _, err := p.Embed(context.Background(), []string{"a"}); err == nil || errors.Is(err, errIntercepted) {
+			t.Errorf("expected task type error, got %v", err)
+		}
+		if transport.calls != 0 {
+			t.Errorf("expected no requests, got %d", transport.calls)
+		}
+	})
+
+	t.Run("WithTaskType revalidates", func(t *testing.T) {
+		p := New(Config{APIKey: "test", Model: "gemini-embedding-001"})
+		if p.WithTaskType(TaskTypeCodeRetrievalQuery).taskTypeErr != nil {
+			t.Error("expected CODE_RETRIEVAL_QUERY to be accepted")
+		}
+		if p.WithTaskType("BOGUS").taskTypeErr == nil {
+			t.Error("expected an unknown task type to be rejected")
+		}
+	})
+}
+
+func TestProvider_RetryResendsBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+		bodies = append(bodies, string(body))
+
+		switch len(bodies) {
+		case 1:
+			// A 307 redirect must replay the body on the same attempt.
+			http.Redirect(w, r, r.URL.String(), http.StatusTemporaryRedirect)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"embedding":{"values":[0.6,0.8]}}`)) //nolint:errcheck // test helper
+		}
+	}))
+	defer server.Close()
+
+	svc := vex.NewService(New(Config{APIKey: "test", BaseURL: server.URL}), vex.WithRetry(2))
+	if _, err := svc.Embed(context.Background(), "hello world"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(bodies))
+	}
+	for i, body := range bodies {
+		if body == "" || body != bodies[0] {
+			t.Errorf("request %d: expected full body %q, got %q", i, bodies[0], body)
+		}
+	}
+}
+
+func TestProvider_EmbedTitled(t *testing.T) {
+	var got batchEmbedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = batchEmbedRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		resp := batchEmbedResponse{Embeddings: make([]embedding, len(got.Requests))}
+		for i := range resp.Embeddings {
+			resp.Embeddings[i] = embedding{Values: []float64{0.1, 0.2}}
+		}
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+	texts := []string{"body one", "body two"}
+	titles := []string{"Guide", ""}
+
+	t.Run("sends titles for retrieval documents", func(t *testing.T) {
+		if _, err := p.EmbedTitled(context.Background(), texts, titles); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Requests[0].Title != "Guide" || got.Requests[1].Title != "" {
+			t.Errorf("expected titles %q, got %q and %q", titles, got.Requests[0].Title, got.Requests[1].Title)
+		}
+	})
+
+	t.Run("drops titles for other task types", func(t *testing.T) {
+		if _, err := p.WithTaskType(TaskTypeSemantic).EmbedTitled(context.Background(), texts, titles); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Requests[0].Title != "" {
+			t.Errorf("expected no title for %s, got %q", TaskTypeSemantic, got.Requests[0].Title)
+		}
+	})
+
+	t.Run("embeds documents", func(t *testing.T) {
+		docs := []vex.Document{{Title: "Guide", Text: "body one"}, {Text: "body two"}}
+		if _, err := p.EmbedDocuments(context.Background(), docs); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Requests[0].Title != "Guide" || got.Requests[1].Content.Parts[0].Text != "body two" {
+			t.Errorf("unexpected requests %+v", got.Requests)
+		}
+
+		if _, err := p.ForQuery().(*Provider).EmbedDocuments(context.Background(), docs); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Requests[0].Title != "" {
+			t.Errorf("expected no title for %s, got %q", TaskTypeRetrievalQuery, got.Requests[0].Title)
+		}
+	})
+
+	t.Run("serves the service's native path", func(t *testing.T) {
+		svc := vex.NewService(p)
+		_, err := svc.BatchDocuments(context.Background(), []vex.Document{{Title: "Guide", Text: "body one"}, {Text: "body two"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Requests[0].Title != "Guide" || got.Requests[0].Content.Parts[0].Text != "body one" {
+			t.Errorf("expected native title, got %+v", got.Requests[0])
+		}
+	})
+
+	t.Run("rejects mismatched titles", func(t *testing.T) {
+		if _, err := p.EmbedTitled(context.Background(), texts, titles[:1]); err == nil {
+			t.Error("expected error for mismatched titles")
+		}
+	})
+}
+
+func TestProvider_ImplementsTitledEmbedder(_ *testing.T) {
+	var _ vex.TitledEmbedder = New(Config{APIKey: "test"})
+}
+
+func TestProvider_Extra(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var raw struct {
+			Requests []map[string]interface{} `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		for i, entry := range raw.Requests {
+			if entry["autoTruncate"] != false {
+				t.Errorf("request %d: expected extra field, got %v", i, entry)
+			}
+		}
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"embeddings": [{"values": [0.1]}, {"values": [0.2]}]}`))
+	}))
+	defer server.Close()
+
+	p := New(Config{APIKey: "test-key", BaseURL: server.URL, Extra: map[string]any{"autoTruncate": false}})
+	if _, err := p.Embed(context.Background(), []string{"a", "b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConfig_Headers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Tenant-ID"); got != "acme" {
+			t.Errorf("expected custom header, got %q", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("expected custom header to override Content-Type, got %q", got)
+		}
+		if got := r.Header.Get("X-Goog-Api-Key"); got != "test-key" {
+			t.Errorf("expected provider authentication to be kept, got %q", got)
+		}
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"embedding": {"values": [0.1]}}`))
+	}))
+	defer server.Close()
+
+	p := New(Config{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+		Headers: map[string]string{
+			"X-Tenant-ID":  "acme",
+			"Content-Type": "application/json; charset=utf-8",
+		},
+	})
+	if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestProvider_OutputDimensionality(t *testing.T) {
+	newServer := func(t *testing.T, returned int, sent *[]embedContentRequest) *httptest.Server {
+		t.Helper()
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, ":embedContent") {
+				var req embedContentRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatalf("failed to decode request: %v", err)
+				}
+				*sent = []embedContentRequest{req}
+				values := make([]float64, returned)
+				values[0] = 1
+				//nolint:errcheck // test helper
+				json.NewEncoder(w).Encode(embedContentResponse{Embedding: embedding{Values: values}})
+				return
+			}
+			var req batchEmbedRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			*sent = req.Requests
+			resp := batchEmbedResponse{}
+			for range req.Requests {
+				resp.Embeddings = append(resp.Embeddings, embedding{Values: make([]float64, returned)})
+			}
+			for i := range resp.Embeddings {
+				resp.Embeddings[i].Values[0] = 1
+			}
+			//nolint:errcheck // test helper
+			json.NewEncoder(w).Encode(resp)
+		}))
+	}
+
+	t.Run("sends reduced dimensions", func(t *testing.T) {
+		var sent []embedContentRequest
+		server := newServer(t, 256, &sent)
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL, Dimensions: 256})
+		resp, err := p.Embed(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, req := range sent {
+			if req.OutputDimensionality != 256 {
+				t.Errorf("request %d: expected outputDimensionality 256, got %d", i, req.OutputDimensionality)
+			}
+		}
+		if p.Dimensions() != 256 || resp.Dimensions != 256 || len(resp.Vectors[0]) != p.Dimensions() {
+			t.Errorf("expected 256 dimensions, got Dimensions() %d, response %d, vector %d", p.Dimensions(), resp.Dimensions, len(resp.Vectors[0]))
+		}
+	})
+
+	t.Run("omits model default", func(t *testing.T) {
+		var sent []embedContentRequest
+		server := newServer(t, DimensionsTextEmbedding004, &sent)
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL, Dimensions: DimensionsTextEmbedding004})
+		if _, err := p.Embed(context.Background(), []string{"a"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sent[0].OutputDimensionality != 0 {
+			t.Errorf("expected outputDimensionality omitted, got %d", sent[0].OutputDimensionality)
+		}
+	})
+
+	t.Run("rejects ignored dimensions", func(t *testing.T) {
+		var sent []embedContentRequest
+		server := newServer(t, DimensionsTextEmbedding004, &sent)
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL, Dimensions: 256})
+		if _, err := p.Embed(context.Background(), []string{"a"}); !errors.Is(err, vex.ErrDimensionMismatch) {
+			t.Errorf("expected ErrDimensionMismatch, got %v", err)
+		}
+	})
+}
+
+func TestProvider_APIKeyNotInErrors(t *testing.T) {
+	t.Run("transport failure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		server.Close() // connections are refused
+
+		p := New(Config{APIKey: "super-secret-key", BaseURL: server.URL})
+		_, err := p.Embed(context.Background(), []string{"hello"})
+		if err == nil {
+			t.Fatal("expected transport error")
+		}
+		if strings.Contains(err.Error(), "super-secret-key") {
+			t.Errorf("API key leaked into error: %v", err)
+		}
+	})
+
+	t.Run("cross-host redirect", func(t *testing.T) {
+		var leaked bool
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			leaked = r.Header.Get("x-goog-api-key") != ""
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer target.Close()
+		crossHost := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+		origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, crossHost+r.URL.Path, http.StatusTemporaryRedirect)
+		}))
+		defer origin.Close()
+
+		p := New(Config{APIKey: "super-secret-key", BaseURL: origin.URL})
+		_, err := p.Embed(context.Background(), []string{"hello"})
+		if !errors.Is(err, vex.ErrRedirectAuthStripped) {
+			t.Errorf("expected ErrRedirectAuthStripped, got %v", err)
+		}
+		if leaked {
+			t.Error("API key was forwarded to another host")
+		}
+	})
+}
+
+func TestProvider_SplitsBatches(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req batchEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if len(req.Requests) > DefaultMaxBatchSize {
+			w.WriteHeader(http.StatusBadRequest)
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"error": {"message": "at most 100 requests can be in one batch", "status": "INVALID_ARGUMENT"}}`))
+			return
+		}
+		sizes = append(sizes, len(req.Requests))
+		resp := batchEmbedResponse{Embeddings: make([]embedding, len(req.Requests))}
+		for i, sub := range req.Requests {
+			// Echo the text's number and title presence so order and
+			// title alignment survive the merge.
+			n, err := strconv.Atoi(sub.Content.Parts[0].Text)
+			if err != nil {
+				t.Fatalf("unexpected text %q", sub.Content.Parts[0].Text)
+			}
+			hasTitle := 0.0
+			if sub.Title == "t"+sub.Content.Parts[0].Text {
+				hasTitle = 1
+			}
+			resp.Embeddings[i] = embedding{Values: []float64{float64(n) + 1, hasTitle}}
+		}
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	texts := make([]string, 250)
+	titles := make([]string, len(texts))
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+		titles[i] = "t" + texts[i]
+	}
+
+	p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+	resp, err := p.EmbedTitled(context.Background(), texts, titles)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(sizes, []int{100, 100, 50}) {
+		t.Errorf("expected sub-batches of 100, 100, 50, got %v", sizes)
+	}
+	if len(resp.Vectors) != len(texts) {
+		t.Fatalf("expected %d vectors, got %d", len(texts), len(resp.Vectors))
+	}
+	for i, vec := range resp.Vectors {
+		if vec[0] != float32(i+1) || vec[1] != 1 {
+			t.Fatalf("vector %d out of order or missing its title: %v", i, vec)
+		}
+	}
+	if resp.Usage != (vex.Usage{}) {
+		t.Errorf("expected no usage reported, got %+v", resp.Usage)
+	}
+
+	t.Run("honors configured size", func(t *testing.T) {
+		sizes = nil
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL, MaxBatchSize: 40})
+		if _, err := p.Embed(context.Background(), texts[:90]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(sizes, []int{40, 40, 10}) {
+			t.Errorf("expected sub-batches of 40, 40, 10, got %v", sizes)
+		}
+	})
+}
+
+func TestProvider_EmbedSingle(t *testing.T) {
+	var path string
+	var got embedContentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"embedding": {"values": [0.6, 0.8]}}`))
+	}))
+	defer server.Close()
+
+	p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+	resp, err := p.EmbedTitled(context.Background(), []string{"hello"}, []string{"Greeting"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/models/text-embedding-004:embedContent" {
+		t.Errorf("expected embedContent path, got %s", path)
+	}
+	if got.Content.Parts[0].Text != "hello" || got.Title != "Greeting" || got.TaskType != string(TaskTypeRetrievalDocument) {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if len(resp.Vectors) != 1 || len(resp.Vectors[0]) != 2 || resp.Dimensions != 2 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+// stubTokenSource hands out numbered tokens, as a refreshing source would.
+type stubTokenSource struct {
+	calls int
+	err   error
+}
+
+func (s *stubTokenSource) Token(context.Context) (string, error) {
+	s.calls++
+	if s.err != nil {
+		return "", s.err
+	}
+	return "token-" + strconv.Itoa(s.calls), nil
+}
+
+func TestConfig_TokenSource(t *testing.T) {
+	var auth []string
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		keys = append(keys, r.Header.Get("x-goog-api-key"))
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"embedding": {"values": [0.6, 0.8]}}`))
+	}))
+	defer server.Close()
+
+	t.Run("sends a fresh bearer token per request", func(t *testing.T) {
+		auth, keys = nil, nil
+		p := New(Config{TokenSource: &stubTokenSource{}, BaseURL: server.URL})
+		for range 2 {
+			if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if !slices.Equal(auth, []string{"Bearer token-1", "Bearer token-2"}) {
+			t.Errorf("unexpected Authorization headers %q", auth)
+		}
+		if !slices.Equal(keys, []string{"", ""}) {
+			t.Errorf("expected no API key header, got %q", keys)
+		}
+	})
+
+	t.Run("adapts a function", func(t *testing.T) {
+		auth = nil
+		ts := TokenSourceFunc(func(context.Context) (string, error) { return "fixed", nil })
+		if _, err := New(Config{TokenSource: ts, BaseURL: server.URL}).Embed(context.Background(), []string{"hello"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(auth, []string{"Bearer fixed"}) {
+			t.Errorf("unexpected Authorization headers %q", auth)
+		}
+	})
+
+	t.Run("returns token errors without sending", func(t *testing.T) {
+		auth = nil
+		tokenErr := errors.New("credentials expired")
+		p := New(Config{TokenSource: &stubTokenSource{err: tokenErr}, BaseURL: server.URL})
+		if _, err := p.Embed(context.Background(), []string{"hello"}); !errors.Is(err, tokenErr) {
+			t.Errorf("expected token error, got %v", err)
+		}
+		if len(auth) != 0 {
+			t.Errorf("expected no requests, got %d", len(auth))
+		}
+	})
+
+	t.Run("requires exactly one credential", func(t *testing.T) {
+		for _, config := range []Config{
+			{BaseURL: server.URL},
+			{APIKey: "key", TokenSource: &stubTokenSource{}, BaseURL: server.URL},
+		} {
+			if _, err := New(config).Embed(context.Background(), []string{"hello"}); err == nil {
+				t.Errorf("expected configuration error for %+v", config)
+			}
+		}
+	})
+}
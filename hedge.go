@@ -0,0 +1,104 @@
+package vex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zoobzio/pipz"
+)
+
+// hedge sends a second identical request through processor if the first has
+// not returned within delay, taking whichever succeeds first and canceling
+// the other. Only the winning response is kept, so its usage is the only
+// usage accounted for.
+type hedge struct {
+	identity  pipz.Identity
+	processor pipz.Chainable[*EmbedRequest]
+	delay     time.Duration
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newHedge(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], delay time.Duration) *hedge {
+	return &hedge{
+		identity:  identity,
+		processor: processor,
+		delay:     delay,
+	}
+}
+
+type hedgeResult struct {
+	req *EmbedRequest
+	err error
+}
+
+// Process implements pipz.Chainable.
+func (h *hedge) Process(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so the losing attempt never blocks after we return.
+	results := make(chan hedgeResult, 2)
+	attempt := func() {
+		// Each attempt works on its own copy so the loser cannot
+		// overwrite the winner's response.
+		r := *req
+		out, err := h.processor.Process(ctx, &r)
+		results <- hedgeResult{req: out, err: err}
+	}
+	go attempt()
+
+	timer := time.NewTimer(h.delay)
+	defer timer.Stop()
+	hedgeC := timer.C
+
+	launched, received := 1, 0
+	for {
+		select {
+		case <-hedgeC:
+			hedgeC = nil
+			go attempt()
+			launched++
+		case res := <-results:
+			received++
+			if res.err == nil {
+				*req = *res.req
+				return req, nil
+			}
+			if received == launched {
+				// Every launched attempt failed. A primary that fails
+				// before the delay is not hedged; leave that to retry.
+				req.Error = res.err
+				return req, res.err
+			}
+		}
+	}
+}
+
+// Identity implements pipz.Chainable.
+func (h *hedge) Identity() pipz.Identity {
+	return h.identity
+}
+
+// Schema implements pipz.Chainable.
+func (h *hedge) Schema() pipz.Node {
+	// The request races its own delayed duplicate.
+	processor := h.processor.Schema()
+	return pipz.Node{
+		Identity: h.identity,
+		Type:     "hedge",
+		Flow:     pipz.RaceFlow{Competitors: []pipz.Node{processor, processor}},
+		Metadata: map[string]any{
+			"delay": h.delay.String(),
+		},
+	}
+}
+
+// Close implements pipz.Chainable.
+func (h *hedge) Close() error {
+	h.closeOnce.Do(func() {
+		h.closeErr = h.processor.Close()
+	})
+	return h.closeErr
+}
@@ -15,51 +15,88 @@ var (
 	ProviderCallStarted   = capitan.NewSignal("vex.provider.call.started", "Provider HTTP call initiated")
 	ProviderCallCompleted = capitan.NewSignal("vex.provider.call.completed", "Provider HTTP call succeeded")
 	ProviderCallFailed    = capitan.NewSignal("vex.provider.call.failed", "Provider HTTP call failed")
+	InputTruncated        = capitan.NewSignal("vex.input.truncated", "Input truncated to maximum length")
+	ChunkStrategySelected = capitan.NewSignal("vex.chunk.selected", "Chunk strategy selected for input")
+	DimensionDrift        = capitan.NewSignal("vex.dimensions.drift", "Provider returned vectors of a new size")
+	ZeroVectorDetected    = capitan.NewSignal("vex.vector.zero", "Provider returned an all-zero vector")
+	QueryModeFallback     = capitan.NewSignal("vex.query.fallback", "Query embedded in document mode")
 )
 
 // Keys for hook event fields.
 var (
-	RequestIDKey    = capitan.NewStringKey("vex.request.id")
-	ProviderKey     = capitan.NewStringKey("vex.provider")
-	ModelKey        = capitan.NewStringKey("vex.model")
-	InputCountKey   = capitan.NewIntKey("vex.input.count")
-	DimensionsKey   = capitan.NewIntKey("vex.dimensions")
-	DurationMsKey   = capitan.NewIntKey("vex.duration.ms")
-	PromptTokensKey = capitan.NewIntKey("vex.tokens.prompt")
-	TotalTokensKey  = capitan.NewIntKey("vex.tokens.total")
-	ErrorKey        = capitan.NewStringKey("vex.error")
+	RequestIDKey      = capitan.NewStringKey("vex.request.id")
+	ProviderKey       = capitan.NewStringKey("vex.provider")
+	ModelKey          = capitan.NewStringKey("vex.model")
+	RequestedModelKey = capitan.NewStringKey("vex.model.requested")
+	ResponseModelKey  = capitan.NewStringKey("vex.model.response")
+	InputCountKey     = capitan.NewIntKey("vex.input.count")
+	DimensionsKey     = capitan.NewIntKey("vex.dimensions")
+	DurationMsKey     = capitan.NewIntKey("vex.duration.ms")
+	PromptTokensKey   = capitan.NewIntKey("vex.tokens.prompt")
+	TotalTokensKey    = capitan.NewIntKey("vex.tokens.total")
+	ErrorKey          = capitan.NewStringKey("vex.error")
+	InputIndexKey     = capitan.NewIntKey("vex.input.index")
+	InputLengthKey    = capitan.NewIntKey("vex.input.length")
+	TruncatedToKey    = capitan.NewIntKey("vex.input.truncated_to")
+	FingerprintKey    = capitan.NewStringKey("vex.fingerprint")
+	ChunkStrategyKey  = capitan.NewStringKey("vex.chunk.strategy")
+	ChunkCountKey     = capitan.NewIntKey("vex.chunk.count")
+	ExpectedDimsKey   = capitan.NewIntKey("vex.dimensions.expected")
+	TagKey            = capitan.NewStringKey("vex.request.tag")
 )
 
+// requestTagKey is the context key for WithRequestTag.
+type requestTagKey struct{}
+
+// WithRequestTag returns a context whose EmbedStarted, EmbedCompleted and
+// EmbedFailed signals carry tag under TagKey, so hook consumers can
+// attribute them to a logical operation such as "reindex-job-42".
+func WithRequestTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, requestTagKey{}, tag)
+}
+
+// withTag appends the request tag set on ctx, if any, to fields.
+func withTag(ctx context.Context, fields ...capitan.Field) []capitan.Field {
+	if tag, ok := ctx.Value(requestTagKey{}).(string); ok && tag != "" {
+		fields = append(fields, TagKey.Field(tag))
+	}
+	return fields
+}
+
 // emitEmbedStarted emits a signal when embedding begins.
 func emitEmbedStarted(ctx context.Context, requestID string, provider string, inputCount int) {
-	capitan.Info(ctx, EmbedStarted,
+	capitan.Info(ctx, EmbedStarted, withTag(ctx,
 		RequestIDKey.Field(requestID),
 		ProviderKey.Field(provider),
 		InputCountKey.Field(inputCount),
-	)
+	)...)
 }
 
 // emitEmbedCompleted emits a signal when embedding succeeds.
-func emitEmbedCompleted(ctx context.Context, requestID string, provider string, resp *EmbeddingResponse, duration time.Duration) {
-	capitan.Info(ctx, EmbedCompleted,
+// requestedModel is empty when the provider does not implement ModelReporter.
+func emitEmbedCompleted(ctx context.Context, requestID string, provider string, requestedModel string, fingerprint string, resp *EmbeddingResponse, duration time.Duration) {
+	capitan.Info(ctx, EmbedCompleted, withTag(ctx,
 		RequestIDKey.Field(requestID),
+		FingerprintKey.Field(fingerprint),
 		ProviderKey.Field(provider),
 		ModelKey.Field(resp.Model),
+		RequestedModelKey.Field(requestedModel),
+		ResponseModelKey.Field(resp.Model),
 		DimensionsKey.Field(resp.Dimensions),
 		DurationMsKey.Field(int(duration.Milliseconds())),
 		PromptTokensKey.Field(resp.Usage.PromptTokens),
 		TotalTokensKey.Field(resp.Usage.TotalTokens),
-	)
+	)...)
 }
 
 // emitEmbedFailed emits a signal when embedding fails.
 func emitEmbedFailed(ctx context.Context, requestID string, provider string, err error, duration time.Duration) {
-	capitan.Error(ctx, EmbedFailed,
+	capitan.Error(ctx, EmbedFailed, withTag(ctx,
 		RequestIDKey.Field(requestID),
 		ProviderKey.Field(provider),
 		DurationMsKey.Field(int(duration.Milliseconds())),
 		ErrorKey.Field(err.Error()),
-	)
+	)...)
 }
 
 // emitProviderCallStarted emits a signal when a provider HTTP call begins.
@@ -90,3 +127,52 @@ func emitProviderCallFailed(ctx context.Context, provider string, err error, dur
 		ErrorKey.Field(err.Error()),
 	)
 }
+
+// emitInputTruncated emits a signal when an input is truncated before sending.
+func emitInputTruncated(ctx context.Context, requestID string, provider string, index, length, truncatedTo int) {
+	capitan.Warn(ctx, InputTruncated,
+		RequestIDKey.Field(requestID),
+		ProviderKey.Field(provider),
+		InputIndexKey.Field(index),
+		InputLengthKey.Field(length),
+		TruncatedToKey.Field(truncatedTo),
+	)
+}
+
+// emitChunkStrategySelected emits a signal when ChunkAuto picks a strategy for an input.
+func emitChunkStrategySelected(ctx context.Context, provider string, index int, strategy ChunkStrategy, chunks int) {
+	capitan.Debug(ctx, ChunkStrategySelected,
+		ProviderKey.Field(provider),
+		InputIndexKey.Field(index),
+		ChunkStrategyKey.Field(strategy.String()),
+		ChunkCountKey.Field(chunks),
+	)
+}
+
+// emitDimensionDrift emits a signal when a response's vector size differs from
+// the first one the service observed.
+func emitDimensionDrift(ctx context.Context, requestID string, provider string, expected, got int) {
+	capitan.Error(ctx, DimensionDrift,
+		RequestIDKey.Field(requestID),
+		ProviderKey.Field(provider),
+		ExpectedDimsKey.Field(expected),
+		DimensionsKey.Field(got),
+	)
+}
+
+// emitQueryModeFallback emits a signal when a query is embedded in document
+// mode because the provider has no query mode.
+func emitQueryModeFallback(ctx context.Context, provider string) {
+	capitan.Warn(ctx, QueryModeFallback,
+		ProviderKey.Field(provider),
+	)
+}
+
+// emitZeroVectorDetected emits a signal when the provider returns an all-zero
+// vector for the input at index.
+func emitZeroVectorDetected(ctx context.Context, provider string, index int) {
+	capitan.Warn(ctx, ZeroVectorDetected,
+		ProviderKey.Field(provider),
+		InputIndexKey.Field(index),
+	)
+}
@@ -3,6 +3,7 @@ package vex
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,6 +19,11 @@ func TestHookSignals(t *testing.T) {
 		ProviderCallStarted,
 		ProviderCallCompleted,
 		ProviderCallFailed,
+		InputTruncated,
+		ChunkStrategySelected,
+		DimensionDrift,
+		ZeroVectorDetected,
+		QueryModeFallback,
 	}
 
 	for _, sig := range signals {
@@ -33,12 +39,22 @@ func TestHookKeys(t *testing.T) {
 		RequestIDKey.Name(),
 		ProviderKey.Name(),
 		ModelKey.Name(),
+		RequestedModelKey.Name(),
+		ResponseModelKey.Name(),
 		InputCountKey.Name(),
 		DimensionsKey.Name(),
 		DurationMsKey.Name(),
 		PromptTokensKey.Name(),
 		TotalTokensKey.Name(),
 		ErrorKey.Name(),
+		InputIndexKey.Name(),
+		InputLengthKey.Name(),
+		TruncatedToKey.Name(),
+		FingerprintKey.Name(),
+		ChunkStrategyKey.Name(),
+		ChunkCountKey.Name(),
+		ExpectedDimsKey.Name(),
+		TagKey.Name(),
 	}
 
 	for _, key := range keys {
@@ -65,7 +81,7 @@ func TestEmitEmbedCompleted(_ *testing.T) {
 			TotalTokens:  10,
 		},
 	}
-	emitEmbedCompleted(ctx, "req-123", "openai", resp, 100*time.Millisecond)
+	emitEmbedCompleted(ctx, "req-123", "openai", "text-embedding-3-small", "fp", resp, 100*time.Millisecond)
 	// No panic = success
 }
 
@@ -114,6 +130,7 @@ func TestSignalNames(t *testing.T) {
 		{ProviderCallStarted, "vex.provider.call.started"},
 		{ProviderCallCompleted, "vex.provider.call.completed"},
 		{ProviderCallFailed, "vex.provider.call.failed"},
+		{InputTruncated, "vex.input.truncated"},
 	}
 
 	for _, tt := range tests {
@@ -131,12 +148,18 @@ func TestKeyNames(t *testing.T) {
 		{RequestIDKey.Name(), "vex.request.id"},
 		{ProviderKey.Name(), "vex.provider"},
 		{ModelKey.Name(), "vex.model"},
+		{RequestedModelKey.Name(), "vex.model.requested"},
+		{ResponseModelKey.Name(), "vex.model.response"},
 		{InputCountKey.Name(), "vex.input.count"},
 		{DimensionsKey.Name(), "vex.dimensions"},
 		{DurationMsKey.Name(), "vex.duration.ms"},
 		{PromptTokensKey.Name(), "vex.tokens.prompt"},
 		{TotalTokensKey.Name(), "vex.tokens.total"},
 		{ErrorKey.Name(), "vex.error"},
+		{InputIndexKey.Name(), "vex.input.index"},
+		{InputLengthKey.Name(), "vex.input.length"},
+		{TruncatedToKey.Name(), "vex.input.truncated_to"},
+		{FingerprintKey.Name(), "vex.fingerprint"},
 	}
 
 	for _, tt := range tests {
@@ -145,3 +168,355 @@ func TestKeyNames(t *testing.T) {
 		}
 	}
 }
+
+// eventRecorder captures events for a signal emitted by a named provider.
+type eventRecorder struct {
+	listener *capitan.Listener
+	provider string
+	events   []*capitan.Event
+	mu       sync.Mutex
+}
+
+func recordEvents(t *testing.T, signal capitan.Signal, provider string) *eventRecorder {
+	t.Helper()
+	r := &eventRecorder{provider: provider}
+	r.listener = capitan.Hook(signal, func(_ context.Context, e *capitan.Event) {
+		if name, _ := ProviderKey.From(e); name != r.provider {
+			return
+		}
+		r.mu.Lock()
+		r.events = append(r.events, e.Clone())
+		r.mu.Unlock()
+	})
+	t.Cleanup(r.listener.Close)
+	return r
+}
+
+// Events drains pending deliveries and returns the captured events.
+func (r *eventRecorder) Events(t *testing.T) []*capitan.Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.listener.Drain(ctx); err != nil {
+		t.Fatalf("failed to drain events: %v", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*capitan.Event(nil), r.events...)
+}
+
+// modelReportingProvider requests one model but reports serving another.
+type modelReportingProvider struct {
+	*mockProvider
+	requested string
+}
+
+func (p *modelReportingProvider) Model() string { return p.requested }
+
+func TestEmbedCompleted_ModelFields(t *testing.T) {
+	provider := &modelReportingProvider{mockProvider: newMockProvider(8), requested: "requested-model"}
+	provider.name = "model-drift"
+	recorder := recordEvents(t, EmbedCompleted, "model-drift")
+
+	svc := NewService(provider)
+	if _, err := svc.Embed(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events := recorder.Events(t)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if requested, _ := RequestedModelKey.From(events[0]); requested != "requested-model" {
+		t.Errorf("expected requested model 'requested-model', got %q", requested)
+	}
+	if served, _ := ResponseModelKey.From(events[0]); served != "mock-model" {
+		t.Errorf("expected response model 'mock-model', got %q", served)
+	}
+}
+
+func TestService_WithMinimalOverhead(t *testing.T) {
+	t.Run("skips success hooks", func(t *testing.T) {
+		provider := newMockProvider(8)
+		provider.name = "minimal-success"
+		started := recordEvents(t, EmbedStarted, "minimal-success")
+		completed := recordEvents(t, EmbedCompleted, "minimal-success")
+		calls := recordEvents(t, ProviderCallCompleted, "minimal-success")
+
+		svc := NewService(provider).WithMinimalOverhead()
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if n := len(started.Events(t)) + len(completed.Events(t)) + len(calls.Events(t)); n != 0 {
+			t.Errorf("expected no success events, got %d", n)
+		}
+		if svc.Stats().Requests != 1 {
+			t.Errorf("expected stats to still be recorded, got %+v", svc.Stats())
+		}
+	})
+
+	t.Run("still emits failures", func(t *testing.T) {
+		provider := newMockProvider(8)
+		provider.name = "minimal-failure"
+		provider.err = errors.New("provider error")
+		failed := recordEvents(t, EmbedFailed, "minimal-failure")
+		callFailed := recordEvents(t, ProviderCallFailed, "minimal-failure")
+
+		svc := NewService(provider).WithMinimalOverhead()
+		if _, err := svc.Embed(context.Background(), "test"); err == nil {
+			t.Fatal("expected error")
+		}
+
+		events := failed.Events(t)
+		if len(events) != 1 {
+			t.Fatalf("expected 1 EmbedFailed event, got %d", len(events))
+		}
+		if id, _ := RequestIDKey.From(events[0]); id != "1" {
+			t.Errorf("expected counter request ID '1', got %q", id)
+		}
+		if len(callFailed.Events(t)) != 1 {
+			t.Error("expected ProviderCallFailed event")
+		}
+	})
+}
+
+func BenchmarkService_Embed(b *testing.B) {
+	svc := NewService(newMockProvider(8))
+	ctx := context.Background()
+	b.ReportAllocs()
+	for b.Loop() {
+		svc.Embed(ctx, "test") //nolint:errcheck // benchmark
+	}
+}
+
+func BenchmarkService_EmbedMinimalOverhead(b *testing.B) {
+	svc := NewService(newMockProvider(8)).WithMinimalOverhead()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for b.Loop() {
+		svc.Embed(ctx, "test") //nolint:errcheck // benchmark
+	}
+}
+
+func TestService_WithMaxInputChars(t *testing.T) {
+	chunker := &Chunker{Strategy: ChunkNone}
+
+	t.Run("rejects overlong input by default", func(t *testing.T) {
+		provider := newMockProvider(8)
+		svc := NewService(provider).WithChunker(chunker).WithMaxInputChars(5, TruncateError)
+
+		_, err := svc.Embed(context.Background(), "too long")
+		if !errors.Is(err, ErrInputTooLong) {
+			t.Errorf("expected ErrInputTooLong, got %v", err)
+		}
+		if provider.callCount != 0 {
+			t.Errorf("expected no provider calls, got %d", provider.callCount)
+		}
+	})
+
+	t.Run("truncates tail and head", func(t *testing.T) {
+		tests := []struct {
+			mode     TruncationMode
+			expected string
+		}{
+			{TruncateTail, "héllo"},
+			{TruncateHead, "world"},
+		}
+		for _, tt := range tests {
+			svc := NewService(newMockProvider(8)).WithChunker(chunker).WithMaxInputChars(5, tt.mode)
+
+			_, chunks, err := svc.EmbedChunks(context.Background(), "héllo world")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if chunks[0] != tt.expected {
+				t.Errorf("mode %d: expected %q, got %q", tt.mode, tt.expected, chunks[0])
+			}
+		}
+	})
+
+	t.Run("emits signal on truncation", func(t *testing.T) {
+		provider := newMockProvider(8)
+		provider.name = "truncating"
+		recorder := recordEvents(t, InputTruncated, "truncating")
+
+		svc := NewService(provider).WithChunker(chunker).WithMaxInputChars(4, TruncateTail)
+		if _, err := svc.Batch(context.Background(), []string{"ok", "too long"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		events := recorder.Events(t)
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event, got %d", len(events))
+		}
+		index, _ := InputIndexKey.From(events[0])
+		length, _ := InputLengthKey.From(events[0])
+		truncatedTo, _ := TruncatedToKey.From(events[0])
+		if index != 1 || length != 8 || truncatedTo != 4 {
+			t.Errorf("unexpected event fields: index=%d length=%d truncated_to=%d", index, length, truncatedTo)
+		}
+	})
+}
+
+func TestService_ChunkAutoHook(t *testing.T) {
+	provider := newMockProvider(4)
+	provider.name = "chunk-auto-hook"
+	events := recordEvents(t, ChunkStrategySelected, provider.name)
+
+	chunker := &Chunker{Strategy: ChunkAuto, TrimSpace: true, Auto: AutoThresholds{ShortLength: 20}}
+	svc := NewService(provider).WithChunker(chunker)
+
+	texts := []string{"Short one.", "A longer text. It has two sentences."}
+	if _, err := svc.Batch(context.Background(), texts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := events.Events(t)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(got))
+	}
+	expected := []struct {
+		strategy string
+		chunks   int
+	}{{"none", 1}, {"sentence", 2}}
+	for i, e := range got {
+		index, _ := InputIndexKey.From(e)
+		strategy, _ := ChunkStrategyKey.From(e)
+		chunks, _ := ChunkCountKey.From(e)
+		if index != i || strategy != expected[i].strategy || chunks != expected[i].chunks {
+			t.Errorf("event %d: got index %d, strategy %q, %d chunks", i, index, strategy, chunks)
+		}
+	}
+}
+
+func TestService_ChunkAutoHook_ProviderOverride(t *testing.T) {
+	override := newMockProvider(4)
+	override.name = "chunk-auto-override"
+	events := recordEvents(t, ChunkStrategySelected, override.name)
+
+	svc := NewService(newMockProvider(4)).WithChunker(&Chunker{Strategy: ChunkAuto})
+	ctx := WithProviderOverride(context.Background(), override)
+	if _, err := svc.Embed(ctx, "Short one."); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := events.Events(t); len(got) != 1 {
+		t.Errorf("expected 1 event naming the override, got %d", len(got))
+	}
+}
+
+// driftingProvider returns 256-dimensional vectors on its first call and
+// 512-dimensional vectors afterwards.
+type driftingProvider struct {
+	*mockProvider
+}
+
+func (p *driftingProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	if p.callCount > 0 {
+		p.dimensions = 512
+	}
+	return p.mockProvider.Embed(ctx, texts)
+}
+
+func TestService_DimensionDrift(t *testing.T) {
+	t.Run("emits signal when dimensions change", func(t *testing.T) {
+		provider := &driftingProvider{newMockProvider(256)}
+		provider.name = "drift-signal"
+		events := recordEvents(t, DimensionDrift, provider.name)
+		svc := NewService(provider)
+
+		if _, err := svc.Embed(context.Background(), "first"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		vec, err := svc.Embed(context.Background(), "second")
+		if err != nil {
+			t.Fatalf("expected drift to be reported without failing, got %v", err)
+		}
+		if len(vec) != 512 {
+			t.Errorf("expected 512-dimensional vector, got %d", len(vec))
+		}
+
+		got := events.Events(t)
+		if len(got) != 1 {
+			t.Fatalf("expected 1 drift event, got %d", len(got))
+		}
+		expected, _ := ExpectedDimsKey.From(got[0])
+		dims, _ := DimensionsKey.From(got[0])
+		if expected != 256 || dims != 512 {
+			t.Errorf("expected drift 256 -> 512, got %d -> %d", expected, dims)
+		}
+	})
+
+	t.Run("fails when configured", func(t *testing.T) {
+		provider := &driftingProvider{newMockProvider(256)}
+		provider.name = "drift-error"
+		svc := NewService(provider).WithFailOnDimensionDrift()
+
+		if _, err := svc.Embed(context.Background(), "first"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := svc.Embed(context.Background(), "second"); !errors.Is(err, ErrDimensionDrift) {
+			t.Errorf("expected ErrDimensionDrift, got %v", err)
+		}
+	})
+
+	t.Run("stays quiet for stable dimensions", func(t *testing.T) {
+		provider := newMockProvider(256)
+		provider.name = "drift-stable"
+		events := recordEvents(t, DimensionDrift, provider.name)
+		svc := NewService(provider)
+
+		for i := 0; i < 3; i++ {
+			if _, err := svc.Embed(context.Background(), "text"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if got := events.Events(t); len(got) != 0 {
+			t.Errorf("expected no drift events, got %d", len(got))
+		}
+	})
+}
+
+func TestWithRequestTag(t *testing.T) {
+	provider := newMockProvider(8)
+	provider.name = "tagged"
+	started := recordEvents(t, EmbedStarted, provider.name)
+	completed := recordEvents(t, EmbedCompleted, provider.name)
+	failed := recordEvents(t, EmbedFailed, provider.name)
+	svc := NewService(provider)
+
+	ctx := WithRequestTag(context.Background(), "reindex-job-42")
+	if _, err := svc.Embed(ctx, "tagged"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Embed(context.Background(), "untagged"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	provider.err = errors.New("provider down")
+	if _, err := svc.Embed(ctx, "tagged"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	startedEvents := started.Events(t)
+	if len(startedEvents) != 3 {
+		t.Fatalf("expected 3 started events, got %d", len(startedEvents))
+	}
+	wantTags := []string{"reindex-job-42", "", "reindex-job-42"}
+	for i, e := range startedEvents {
+		tag, _ := TagKey.From(e)
+		if tag != wantTags[i] {
+			t.Errorf("started event %d: expected tag %q, got %q", i, wantTags[i], tag)
+		}
+	}
+	if got := completed.Events(t); len(got) != 2 {
+		t.Errorf("expected 2 completed events, got %d", len(got))
+	} else if tag, _ := TagKey.From(got[0]); tag != "reindex-job-42" {
+		t.Errorf("expected completed event tag, got %q", tag)
+	}
+	if got := failed.Events(t); len(got) != 1 {
+		t.Errorf("expected 1 failed event, got %d", len(got))
+	} else if tag, _ := TagKey.From(got[0]); tag != "reindex-job-42" {
+		t.Errorf("expected failed event tag, got %q", tag)
+	}
+}
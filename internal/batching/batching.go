@@ -0,0 +1,79 @@
+// Package batching splits embedding requests that exceed a provider's
+// per-request input limit and merges the results.
+package batching
+
+import (
+	"context"
+
+	"github.com/zoobzio/vex"
+)
+
+// EmbedFunc performs a single provider request.
+type EmbedFunc func(ctx context.Context, texts []string) (*vex.EmbeddingResponse, error)
+
+// Embed issues texts through embed in sub-requests of at most size inputs,
+// sequentially, and merges the responses in order with usage summed.
+// Float and quantized vectors are each merged only when the sub-requests
+// return them, so a quantized-only response carries no float vectors.
+// A size of zero or less disables splitting.
+//
+// Sub-requests run under a context derived from ctx that the first failure
+// cancels, so no further requests are sent once one fails or ctx is done.
+// If any fail, Embed returns a *vex.BatchError carrying the failure, a
+// cancellation for each range left unsent, and the vectors that did succeed.
+func Embed(ctx context.Context, texts []string, size int, embed EmbedFunc) (*vex.EmbeddingResponse, error) {
+	if size <= 0 || len(texts) <= size {
+		return embed(ctx, texts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	merged := &vex.EmbeddingResponse{}
+	var failures []vex.BatchFailure
+	seen := false
+	for start := 0; start < len(texts); start += size {
+		end := min(start+size, len(texts))
+		if ctx.Err() != nil {
+			failures = append(failures, vex.BatchFailure{Err: ctx.Err(), Start: start, End: end})
+			continue
+		}
+		resp, err := embed(ctx, texts[start:end])
+		if err != nil {
+			failures = append(failures, vex.BatchFailure{Err: err, Start: start, End: end})
+			cancel()
+			continue
+		}
+		if !seen {
+			merged.Model = resp.Model
+			merged.Dimensions = resp.Dimensions
+			seen = true
+		}
+		if resp.Vectors != nil {
+			if merged.Vectors == nil {
+				merged.Vectors = make([]vex.Vector, len(texts))
+			}
+			copy(merged.Vectors[start:end], resp.Vectors)
+		}
+		if resp.Int8Vectors != nil {
+			if merged.Int8Vectors == nil {
+				merged.Int8Vectors = make([][]int8, len(texts))
+			}
+			copy(merged.Int8Vectors[start:end], resp.Int8Vectors)
+		}
+		if resp.BinaryVectors != nil {
+			if merged.BinaryVectors == nil {
+				merged.BinaryVectors = make([][]byte, len(texts))
+			}
+			copy(merged.BinaryVectors[start:end], resp.BinaryVectors)
+		}
+		merged.Truncated += resp.Truncated
+		merged.Warnings = append(merged.Warnings, resp.Warnings...)
+		merged.Usage.PromptTokens += resp.Usage.PromptTokens
+		merged.Usage.TotalTokens += resp.Usage.TotalTokens
+	}
+	if len(failures) > 0 {
+		return nil, vex.NewBatchError(failures, merged.Vectors)
+	}
+	return merged, nil
+}
@@ -0,0 +1,191 @@
+package batching
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zoobzio/vex"
+)
+
+// indexEmbed returns one-dimensional vectors holding each text's length.
+func indexEmbed(calls *[]int) EmbedFunc {
+	return func(_ context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+		*calls = append(*calls, len(texts))
+		vecs := make([]vex.Vector, len(texts))
+		for i, text := range texts {
+			vecs[i] = vex.Vector{float32(len(text))}
+		}
+		return &vex.EmbeddingResponse{
+			Vectors:    vecs,
+			Model:      "test",
+			Dimensions: 1,
+			Usage:      vex.Usage{PromptTokens: len(texts), TotalTokens: 2 * len(texts)},
+		}, nil
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	t.Run("splits and preserves order", func(t *testing.T) {
+		var calls []int
+		texts := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff", "ggggggg"}
+
+		resp, err := Embed(context.Background(), texts, 3, indexEmbed(&calls))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(calls) != 3 || calls[0] != 3 || calls[1] != 3 || calls[2] != 1 {
+			t.Errorf("expected sub-requests [3 3 1], got %v", calls)
+		}
+		for i, vec := range resp.Vectors {
+			if vec[0] != float32(i+1) {
+				t.Errorf("vector %d out of order: %v", i, vec)
+			}
+		}
+		if resp.Usage.PromptTokens != 7 || resp.Usage.TotalTokens != 14 {
+			t.Errorf("expected summed usage 7/14, got %+v", resp.Usage)
+		}
+		if resp.Model != "test" || resp.Dimensions != 1 {
+			t.Errorf("expected model and dimensions from first response, got %q/%d", resp.Model, resp.Dimensions)
+		}
+	})
+
+	t.Run("merges quantized vectors", func(t *testing.T) {
+		quantized := func(_ context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+			resp := &vex.EmbeddingResponse{Truncated: 1}
+			for _, text := range texts {
+				resp.Int8Vectors = append(resp.Int8Vectors, []int8{int8(len(text))})
+				resp.BinaryVectors = append(resp.BinaryVectors, []byte{byte(len(text))})
+			}
+			return resp, nil
+		}
+
+		resp, err := Embed(context.Background(), []string{"a", "bb", "ccc"}, 2, quantized)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i := range 3 {
+			if resp.Int8Vectors[i][0] != int8(i+1) || resp.BinaryVectors[i][0] != byte(i+1) {
+				t.Errorf("text %d: unexpected quantized vectors %v, %v", i, resp.Int8Vectors[i], resp.BinaryVectors[i])
+			}
+		}
+		if resp.Truncated != 2 {
+			t.Errorf("expected truncated count summed to 2, got %d", resp.Truncated)
+		}
+		if resp.Vectors != nil {
+			t.Errorf("expected no float vectors, got %v", resp.Vectors)
+		}
+	})
+
+	t.Run("concatenates warnings", func(t *testing.T) {
+		warn := func(_ context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+			return &vex.EmbeddingResponse{
+				Vectors:  make([]vex.Vector, len(texts)),
+				Warnings: []string{texts[0] + " truncated"},
+			}, nil
+		}
+
+		resp, err := Embed(context.Background(), []string{"a", "b", "c"}, 2, warn)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Warnings) != 2 || resp.Warnings[0] != "a truncated" || resp.Warnings[1] != "c truncated" {
+			t.Errorf("expected warnings from both sub-requests, got %q", resp.Warnings)
+		}
+	})
+
+	t.Run("does not split within limit", func(t *testing.T) {
+		var calls []int
+		if _, err := Embed(context.Background(), []string{"a", "b"}, 2, indexEmbed(&calls)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(calls) != 1 {
+			t.Errorf("expected 1 call, got %d", len(calls))
+		}
+	})
+
+	t.Run("cancels remaining sub-batches after a failure", func(t *testing.T) {
+		errFirst := errors.New("first")
+		var calls []int
+		succeed := indexEmbed(&calls)
+		call := 0
+		flaky := func(ctx context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+			call++
+			if call == 2 {
+				return nil, errFirst
+			}
+			return succeed(ctx, texts)
+		}
+
+		texts := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff", "ggggggg", "hhhhhhhh", "iiiiiiiii"}
+		_, err := Embed(context.Background(), texts, 2, flaky)
+
+		var berr *vex.BatchError
+		if !errors.As(err, &berr) {
+			t.Fatalf("expected BatchError, got %v", err)
+		}
+		if call != 2 {
+			t.Errorf("expected no calls after the failure, got %d calls", call)
+		}
+		if !errors.Is(err, errFirst) {
+			t.Error("expected errors.Is to match the failure")
+		}
+
+		ranges := [][2]int{{2, 4}, {4, 6}, {6, 8}, {8, 9}}
+		if len(berr.Failures) != len(ranges) {
+			t.Fatalf("expected %d failures, got %d", len(ranges), len(berr.Failures))
+		}
+		for i, f := range berr.Failures {
+			if f.Start != ranges[i][0] || f.End != ranges[i][1] {
+				t.Errorf("failure %d: expected range %v, got [%d:%d]", i, ranges[i], f.Start, f.End)
+			}
+			if i > 0 && !errors.Is(f.Err, context.Canceled) {
+				t.Errorf("failure %d: expected the skipped range to be canceled, got %v", i, f.Err)
+			}
+		}
+
+		partial := berr.Partial()
+		if len(partial) != len(texts) {
+			t.Fatalf("expected partial results aligned with %d texts, got %d", len(texts), len(partial))
+		}
+		for i, vec := range partial {
+			if (i < 2) != (vec != nil) {
+				t.Errorf("text %d: expected a vector only for the first sub-batch, got %v", i, vec)
+			}
+		}
+	})
+
+	t.Run("skips remaining sub-batches once canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		failing := func(context.Context, []string) (*vex.EmbeddingResponse, error) {
+			calls++
+			cancel()
+			return nil, context.Canceled
+		}
+
+		_, err := Embed(ctx, []string{"a", "b", "c"}, 1, failing)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("returns unsplit error unwrapped", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		failing := func(context.Context, []string) (*vex.EmbeddingResponse, error) {
+			return nil, errBoom
+		}
+		_, err := Embed(context.Background(), []string{"a"}, 2, failing)
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected boom, got %v", err)
+		}
+		var berr *vex.BatchError
+		if errors.As(err, &berr) {
+			t.Error("expected no BatchError for an unsplit request")
+		}
+	})
+}
@@ -0,0 +1,162 @@
+// Package httputil provides HTTP helpers shared by the embedding providers.
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/zoobzio/vex"
+)
+
+// RequestIDHeader carries the vex request ID to the provider's API.
+const RequestIDHeader = "X-Request-ID"
+
+// SetRequestID sets RequestIDHeader on req to the vex request ID in its
+// context, if any.
+func SetRequestID(req *http.Request) {
+	if id := vex.RequestIDFromContext(req.Context()); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+}
+
+// SetHeaders sets each of headers on req, replacing any value the provider
+// set. Providers call it last so Config.Headers takes precedence.
+func SetHeaders(req *http.Request, headers map[string]string) {
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+}
+
+// NewClient returns the HTTP client a provider should use.
+// A nil client yields a new client with the given timeout. A non-nil client is
+// used as-is, except that timeout is applied to a copy if the client has none.
+func NewClient(client *http.Client, timeout time.Duration) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: timeout}
+	}
+	if client.Timeout != 0 {
+		return client
+	}
+	c := *client
+	c.Timeout = timeout
+	return &c
+}
+
+// RetryAfter parses a Retry-After header given either as delay seconds or as
+// an HTTP date. It returns zero if the header is absent, malformed, or in the past.
+func RetryAfter(h http.Header, now time.Time) time.Duration {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// CheckRedirect returns an error wrapping vex.ErrRedirectAuthStripped if
+// resp was reached through a redirect that dropped req's Authorization
+// header. net/http removes it when redirecting to another host, so the
+// provider answers 401 and the real cause, a base URL that redirects, is
+// hidden. Query strings are left out of the error since they may hold keys.
+func CheckRedirect(req *http.Request, resp *http.Response) error {
+	return CheckRedirectHeader(req, resp, "Authorization")
+}
+
+// CheckRedirectHeader is CheckRedirect for providers that authenticate with
+// another header, such as one removed by a StripOnRedirect client.
+func CheckRedirectHeader(req *http.Request, resp *http.Response, header string) error {
+	final := resp.Request
+	if final == nil || final == req || req.Header.Get(header) == "" {
+		return nil
+	}
+	if final.Header.Get(header) != "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s redirected to %s; check the provider base URL",
+		vex.ErrRedirectAuthStripped, withoutQuery(req.URL), withoutQuery(final.URL))
+}
+
+// StripOnRedirect returns a copy of client that removes headers from
+// requests redirected to another host. net/http does this for
+// Authorization but forwards custom credential headers such as API keys.
+// The client's own redirect policy, if any, still applies.
+func StripOnRedirect(client *http.Client, headers ...string) *http.Client {
+	c := *client
+	next := client.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Hostname() != via[0].URL.Hostname() {
+			for _, h := range headers {
+				req.Header.Del(h)
+			}
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &c
+}
+
+// RedactURL returns err with the query string removed from the URL of any
+// *url.Error it wraps, as returned by http.Client.Do, so that credentials
+// passed as query parameters cannot leak through error messages.
+func RedactURL(err error) error {
+	var uerr *url.Error
+	if !errors.As(err, &uerr) {
+		return err
+	}
+	u, perr := url.Parse(uerr.URL)
+	if perr != nil {
+		return &url.Error{Op: uerr.Op, URL: "[redacted]", Err: uerr.Err}
+	}
+	if u.RawQuery == "" && u.Fragment == "" {
+		return err
+	}
+	return &url.Error{Op: uerr.Op, URL: withoutQuery(u), Err: uerr.Err}
+}
+
+// withoutQuery returns u as a string without its query or fragment.
+func withoutQuery(u *url.URL) string {
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+}
+
+// MarshalBody encodes v, which must encode as a JSON object, and adds the
+// fields of extra that v does not already set. Providers use it to pass
+// Config.Extra parameters through without letting them override their own.
+func MarshalBody(v any, extra map[string]any) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return body, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("extra field %q: %w", key, err)
+		}
+		fields[key] = raw
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,264 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zoobzio/vex"
+)
+
+func TestNewClient(t *testing.T) {
+	t.Run("creates client when nil", func(t *testing.T) {
+		c := NewClient(nil, 5*time.Second)
+		if c == nil || c.Timeout != 5*time.Second {
+			t.Errorf("expected new client with 5s timeout, got %+v", c)
+		}
+	})
+
+	t.Run("uses client with timeout as-is", func(t *testing.T) {
+		custom := &http.Client{Timeout: time.Second}
+		if NewClient(custom, 5*time.Second) != custom {
+			t.Error("expected custom client to be returned unchanged")
+		}
+	})
+
+	t.Run("applies timeout without mutating client", func(t *testing.T) {
+		transport := &http.Transport{}
+		custom := &http.Client{Transport: transport}
+
+		c := NewClient(custom, 5*time.Second)
+		if c.Timeout != 5*time.Second {
+			t.Errorf("expected 5s timeout, got %v", c.Timeout)
+		}
+		if c.Transport != transport {
+			t.Error("expected custom transport to be preserved")
+		}
+		if custom.Timeout != 0 {
+			t.Error("expected original client to be unmodified")
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{"absent", "", 0},
+		{"seconds", "30", 30 * time.Second},
+		{"negative seconds", "-5", 0},
+		{"http date", now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{"past date", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"malformed", "soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.value != "" {
+				h.Set("Retry-After", tt.value)
+			}
+			if got := RetryAfter(h, now); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer target.Close()
+
+	// Redirecting from 127.0.0.1 to localhost changes the host, so the
+	// client drops the Authorization header.
+	crossHost := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cross":
+			http.Redirect(w, r, crossHost+"/v1", http.StatusMovedPermanently)
+		case "/same":
+			http.Redirect(w, r, "/v1", http.StatusFound)
+		}
+	}))
+	defer origin.Close()
+
+	do := func(t *testing.T, path string, auth bool) error {
+		t.Helper()
+		req, err := http.NewRequestWithContext(context.Background(), "GET", origin.URL+path+"?key=secret", http.NoBody)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if auth {
+			req.Header.Set("Authorization", "Bearer test")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close() //nolint:errcheck // test helper
+		return CheckRedirect(req, resp)
+	}
+
+	t.Run("cross-host redirect", func(t *testing.T) {
+		err := do(t, "/cross", true)
+		if !errors.Is(err, vex.ErrRedirectAuthStripped) {
+			t.Fatalf("expected ErrRedirectAuthStripped, got %v", err)
+		}
+		if !strings.Contains(err.Error(), origin.URL+"/cross") || !strings.Contains(err.Error(), crossHost+"/v1") {
+			t.Errorf("expected both URLs in error, got %q", err)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("expected query string omitted, got %q", err)
+		}
+	})
+
+	t.Run("same-host redirect", func(t *testing.T) {
+		if err := do(t, "/same", true); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("no redirect", func(t *testing.T) {
+		if err := do(t, "/v1", true); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("unauthenticated request", func(t *testing.T) {
+		if err := do(t, "/cross", false); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
+
+func TestStripOnRedirect(t *testing.T) {
+	var received []string
+	target := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Api-Key"))
+	}))
+	defer target.Close()
+
+	crossHost := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cross":
+			http.Redirect(w, r, crossHost+"/v1", http.StatusTemporaryRedirect)
+		case "/same":
+			http.Redirect(w, r, target.URL+"/v1", http.StatusTemporaryRedirect)
+		}
+	}))
+	defer origin.Close()
+
+	client := StripOnRedirect(&http.Client{}, "X-Api-Key")
+	for _, path := range []string{"/cross", "/same"} {
+		req, err := http.NewRequestWithContext(context.Background(), "GET", origin.URL+path, http.NoBody)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("X-Api-Key", "secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close() //nolint:errcheck // test helper
+
+		err = CheckRedirectHeader(req, resp, "X-Api-Key")
+		if path == "/cross" && !errors.Is(err, vex.ErrRedirectAuthStripped) {
+			t.Errorf("expected ErrRedirectAuthStripped for cross-host redirect, got %v", err)
+		}
+		if path == "/same" && err != nil {
+			t.Errorf("expected no error when the header survives, got %v", err)
+		}
+	}
+
+	// Both redirects land on target, on different hosts.
+	if len(received) != 2 || received[0] != "" || received[1] != "secret" {
+		t.Errorf("expected key stripped only across hosts, got %q", received)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	inner := errors.New("connection refused")
+	err := RedactURL(&url.Error{Op: "Post", URL: "https://api.example.com/v1/embed?key=secret", Err: inner})
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected query removed, got %q", err)
+	}
+	if !errors.Is(err, inner) || !strings.Contains(err.Error(), "https://api.example.com/v1/embed") {
+		t.Errorf("expected wrapped error with path kept, got %q", err)
+	}
+
+	plain := errors.New("other")
+	if RedactURL(plain) != plain {
+		t.Error("expected non-URL errors to pass through")
+	}
+}
+
+func TestMarshalBody(t *testing.T) {
+	type body struct {
+		Model string `json:"model"`
+		User  string `json:"user,omitempty"`
+	}
+
+	t.Run("without extra", func(t *testing.T) {
+		got, err := MarshalBody(body{Model: "m"}, nil)
+		if err != nil || string(got) != `{"model":"m"}` {
+			t.Errorf("expected plain body, got %s, %v", got, err)
+		}
+	})
+
+	t.Run("merges extra without overriding", func(t *testing.T) {
+		extra := map[string]any{"model": "other", "user": "u", "late_chunking": true}
+		got, err := MarshalBody(body{Model: "m"}, extra)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := `{"late_chunking":true,"model":"m","user":"u"}`
+		if string(got) != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	})
+
+	t.Run("reports unencodable extra", func(t *testing.T) {
+		if _, err := MarshalBody(body{}, map[string]any{"bad": func() {}}); err == nil {
+			t.Error("expected error for unencodable extra field")
+		}
+	})
+}
+
+func TestSetHeaders(t *testing.T) {
+	req, err := http.NewRequestWithContext(context.Background(), "POST", "https://api.example.com", http.NoBody)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	SetHeaders(req, map[string]string{"x-tenant-id": "acme", "Content-Type": "text/plain"})
+	if got := req.Header.Get("X-Tenant-ID"); got != "acme" {
+		t.Errorf("expected canonicalized custom header, got %q", got)
+	}
+	if got := req.Header.Get("Content-Type"); got != "text/plain" {
+		t.Errorf("expected custom header to win, got %q", got)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("expected untouched header to be kept, got %q", got)
+	}
+
+	SetHeaders(req, nil)
+	if len(req.Header) != 3 {
+		t.Errorf("expected nil headers to be a no-op, got %v", req.Header)
+	}
+}
@@ -0,0 +1,268 @@
+// Package jina provides an embedding provider for the Jina AI API.
+package jina
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/zoobzio/vex"
+	"github.com/zoobzio/vex/internal/httputil"
+)
+
+// DimensionsJinaV3 is the default output dimensionality of jina-embeddings-v3.
+const DimensionsJinaV3 = 1024
+
+// MaxTokensJinaV3 is the input token limit for jina-embeddings-v3.
+const MaxTokensJinaV3 = 8192
+
+// Task selects the task-specific adapter used to embed texts.
+type Task string
+
+// Task constants.
+const (
+	TaskRetrievalQuery   Task = "retrieval.query"
+	TaskRetrievalPassage Task = "retrieval.passage"
+	TaskTextMatching     Task = "text-matching"
+	TaskClassification   Task = "classification"
+	TaskSeparation       Task = "separation"
+)
+
+// Provider implements vex.Provider for Jina AI embeddings API.
+type Provider struct {
+	httpClient       *http.Client
+	apiKey           string
+	model            string
+	baseURL          string
+	task             Task
+	dimensions       int
+	outputDimensions int
+	extra            map[string]any
+	lateChunking     bool
+}
+
+// Config holds configuration for the Jina AI embedding provider.
+type Config struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+	Task    Task
+	Timeout time.Duration
+
+	// Dimensions truncates output vectors to this size, which the v3 model
+	// supports via Matryoshka representation learning. Optional, defaults
+	// to the model's full dimensionality.
+	Dimensions int
+
+	// LateChunking embeds all texts of a request as one context and then
+	// splits the result back per text, so each chunk's vector reflects the
+	// surrounding chunks. Pair it with a vex.Chunker so each request holds
+	// the chunks of a single document.
+	LateChunking bool
+
+	// Extra holds additional request body fields for API parameters not
+	// covered by Config, e.g. {"embedding_type": "base64"}. Fields the
+	// provider sets itself are not overridden. Optional.
+	Extra map[string]any
+
+	// HTTPClient is used for all requests when set, e.g. for proxies, mTLS,
+	// or instrumented transports. Timeout is applied if the client has none.
+	HTTPClient *http.Client
+}
+
+// New creates a new Jina AI embedding provider.
+func New(config Config) *Provider {
+	if config.Model == "" {
+		config.Model = "jina-embeddings-v3"
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.jina.ai/v1"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.Task == "" {
+		config.Task = TaskRetrievalPassage
+	}
+
+	dimensions := config.Dimensions
+	if dimensions == 0 {
+		dimensions = DimensionsJinaV3
+	}
+
+	return &Provider{
+		apiKey:           config.APIKey,
+		model:            config.Model,
+		baseURL:          config.BaseURL,
+		task:             config.Task,
+		dimensions:       dimensions,
+		outputDimensions: config.Dimensions,
+		lateChunking:     config.LateChunking,
+		extra:            maps.Clone(config.Extra),
+		httpClient:       httputil.NewClient(config.HTTPClient, config.Timeout),
+	}
+}
+
+// Name returns the provider identifier.
+func (*Provider) Name() string {
+	return "jina"
+}
+
+// Dimensions returns the output vector dimensionality.
+func (p *Provider) Dimensions() int {
+	return p.dimensions
+}
+
+// MaxTokens returns the per-input token limit.
+// Implements vex.TokenLimiter.
+func (*Provider) MaxTokens() int {
+	return MaxTokensJinaV3
+}
+
+// Model returns the requested model identifier.
+// Implements vex.ModelReporter.
+func (p *Provider) Model() string {
+	return p.model
+}
+
+// WithTask returns a new provider with the specified task.
+func (p *Provider) WithTask(task Task) *Provider {
+	newP := *p
+	newP.task = task
+	return &newP
+}
+
+// ForQuery returns a provider configured for query embedding mode.
+// Implements vex.QueryProviderFactory.
+func (p *Provider) ForQuery() vex.Provider {
+	return p.WithTask(TaskRetrievalQuery)
+}
+
+// Embed generates embeddings for the given texts.
+func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+	if len(texts) == 0 {
+		return &vex.EmbeddingResponse{
+			Vectors:    nil,
+			Model:      p.model,
+			Dimensions: p.dimensions,
+		}, nil
+	}
+
+	reqBody := embeddingRequest{
+		Model:        p.model,
+		Input:        texts,
+		Task:         string(p.task),
+		Dimensions:   p.outputDimensions,
+		LateChunking: p.lateChunking,
+	}
+
+	jsonBody, err := httputil.MarshalBody(reqBody, p.extra)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/embeddings", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	httputil.SetRequestID(req)
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := httputil.CheckRedirect(req, resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		perr := &vex.ProviderError{
+			Provider:   "jina",
+			StatusCode: resp.StatusCode,
+			RetryAfter: httputil.RetryAfter(resp.Header, time.Now()),
+		}
+		var errResp errorResponse
+		if err := json.Unmarshal(body, &errResp); err == nil {
+			perr.Message = errResp.Detail
+		}
+		return nil, perr
+	}
+
+	var embResp embeddingResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	vectors := make([]vex.Vector, len(embResp.Data))
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("invalid index %d from API", d.Index)
+		}
+		vectors[d.Index] = vex.Vector(d.Embedding)
+	}
+	if err := vex.ValidateVectors("jina", vectors); err != nil {
+		return nil, err
+	}
+
+	dims := p.dimensions
+	if len(vectors) > 0 && len(vectors[0]) > 0 {
+		dims = len(vectors[0])
+	}
+
+	return &vex.EmbeddingResponse{
+		Vectors:    vectors,
+		Model:      embResp.Model,
+		Dimensions: dims,
+		Usage: vex.Usage{
+			PromptTokens: embResp.Usage.PromptTokens,
+			TotalTokens:  embResp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// API types
+
+type embeddingRequest struct {
+	Model        string   `json:"model"`
+	Task         string   `json:"task,omitempty"`
+	Input        []string `json:"input"`
+	Dimensions   int      `json:"dimensions,omitempty"`
+	LateChunking bool     `json:"late_chunking,omitempty"`
+}
+
+type embeddingResponse struct {
+	Object string          `json:"object"`
+	Model  string          `json:"model"`
+	Data   []embeddingData `json:"data"`
+	Usage  usage           `json:"usage"`
+}
+
+type embeddingData struct {
+	Object    string    `json:"object"`
+	Embedding []float32 `json:"embedding"`
+	Index     int       `json:"index"`
+}
+
+type usage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+type errorResponse struct {
+	Detail string `json:"detail"`
+}
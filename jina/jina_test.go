@@ -0,0 +1,231 @@
+package jina
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zoobzio/vex"
+)
+
+func TestProvider_Name(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+	if p.Name() != "jina" {
+		t.Errorf("expected 'jina', got %q", p.Name())
+	}
+}
+
+func TestProvider_Dimensions(t *testing.T) {
+	if p := New(Config{APIKey: "test"}); p.Dimensions() != DimensionsJinaV3 {
+		t.Errorf("expected %d, got %d", DimensionsJinaV3, p.Dimensions())
+	}
+	if p := New(Config{APIKey: "test", Dimensions: 256}); p.Dimensions() != 256 {
+		t.Errorf("expected 256, got %d", p.Dimensions())
+	}
+}
+
+func TestProvider_Embed(t *testing.T) {
+	t.Run("successful embedding", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" {
+				t.Errorf("expected POST, got %s", r.Method)
+			}
+			if r.URL.Path != "/embeddings" {
+				t.Errorf("expected /embeddings, got %s", r.URL.Path)
+			}
+			if r.Header.Get("Authorization") != "Bearer test-key" {
+				t.Errorf("missing or incorrect authorization header")
+			}
+
+			var req embeddingRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			if req.Task != string(TaskRetrievalPassage) {
+				t.Errorf("expected task 'retrieval.passage', got %q", req.Task)
+			}
+			if req.Dimensions != 256 || !req.LateChunking {
+				t.Errorf("expected dimensions 256 and late chunking, got %+v", req)
+			}
+
+			resp := embeddingResponse{
+				Object: "list",
+				Data: []embeddingData{
+					{Object: "embedding", Index: 1, Embedding: []float32{0.4, 0.5, 0.6}},
+					{Object: "embedding", Index: 0, Embedding: []float32{0.1, 0.2, 0.3}},
+				},
+				Model: "jina-embeddings-v3",
+				Usage: usage{PromptTokens: 10, TotalTokens: 10},
+			}
+			if err := json.NewEncoder(w).Encode(resp); err != nil {
+				t.Fatalf("failed to encode response: %v", err)
+			}
+		}))
+		defer server.Close()
+
+		p := New(Config{
+			APIKey:       "test-key",
+			BaseURL:      server.URL,
+			Dimensions:   256,
+			LateChunking: true,
+		})
+
+		resp, err := p.Embed(context.Background(), []string{"hello", "world"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(resp.Vectors) != 2 || resp.Vectors[0][0] != float32(0.1) {
+			t.Errorf("unexpected vectors: %v", resp.Vectors)
+		}
+		if resp.Model != "jina-embeddings-v3" {
+			t.Errorf("expected model 'jina-embeddings-v3', got %q", resp.Model)
+		}
+		if resp.Usage.TotalTokens != 10 {
+			t.Errorf("expected 10 tokens, got %d", resp.Usage.TotalTokens)
+		}
+	})
+
+	t.Run("omits optional params by default", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var raw map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			for _, key := range []string{"dimensions", "late_chunking"} {
+				if _, ok := raw[key]; ok {
+					t.Errorf("expected %s to be omitted", key)
+				}
+			}
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"data": [{"index": 0, "embedding": [0.1]}]}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+		if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("sends extra params", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var raw map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			if raw["normalized"] != true {
+				t.Errorf("expected extra field normalized=true, got %v", raw["normalized"])
+			}
+			if raw["task"] != string(TaskRetrievalPassage) {
+				t.Errorf("expected extra not to override task, got %v", raw["task"])
+			}
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"data": [{"index": 0, "embedding": [0.1]}]}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{
+			APIKey:  "test-key",
+			BaseURL: server.URL,
+			Extra:   map[string]any{"normalized": true, "task": "classification"},
+		})
+		if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("handles empty input", func(t *testing.T) {
+		p := New(Config{APIKey: "test"})
+
+		resp, err := p.Embed(context.Background(), []string{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Vectors != nil {
+			t.Errorf("expected nil vectors for empty input")
+		}
+	})
+
+	t.Run("handles API error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			//nolint:errcheck // test helper
+			json.NewEncoder(w).Encode(map[string]string{
+				"detail": "Invalid API key",
+			})
+		}))
+		defer server.Close()
+
+		p := New(Config{
+			APIKey:  "bad-key",
+			BaseURL: server.URL,
+		})
+
+		_, err := p.Embed(context.Background(), []string{"test"})
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected ProviderError, got %v", err)
+		}
+		if perr.Provider != "jina" || perr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected provider error fields: %+v", perr)
+		}
+		if perr.Message != "Invalid API key" {
+			t.Errorf("expected message 'Invalid API key', got %q", perr.Message)
+		}
+	})
+
+	t.Run("rejects invalid index from API", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"data": [{"index": 5, "embedding": [0.1]}]}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test", BaseURL: server.URL})
+		if _, err := p.Embed(context.Background(), []string{"test"}); err == nil {
+			t.Error("expected error for invalid index")
+		}
+	})
+}
+
+func TestProvider_ForQuery(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+
+	queryProvider := p.ForQuery()
+
+	qp, ok := queryProvider.(*Provider)
+	if !ok {
+		t.Fatalf("expected *Provider, got %T", queryProvider)
+	}
+	if qp.task != TaskRetrievalQuery {
+		t.Errorf("expected retrieval.query task, got %s", qp.task)
+	}
+	if p.task != TaskRetrievalPassage {
+		t.Errorf("original provider should be unchanged")
+	}
+}
+
+func TestProvider_ImplementsQueryProviderFactory(_ *testing.T) {
+	p := New(Config{APIKey: "test"})
+
+	// Verify it implements QueryProviderFactory (compile-time check)
+	var _ vex.QueryProviderFactory = p
+}
+
+func TestConfig_Defaults(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+
+	if p.model != "jina-embeddings-v3" {
+		t.Errorf("expected default model 'jina-embeddings-v3', got %q", p.model)
+	}
+	if p.baseURL != "https://api.jina.ai/v1" {
+		t.Errorf("expected default base URL, got %q", p.baseURL)
+	}
+	if p.MaxTokens() != MaxTokensJinaV3 {
+		t.Errorf("expected %d max tokens, got %d", MaxTokensJinaV3, p.MaxTokens())
+	}
+}
@@ -0,0 +1,239 @@
+package vex
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MicroBatchingProvider wraps a Provider and coalesces concurrent small Embed
+// calls into a single provider request, fanning the results back to each caller.
+type MicroBatchingProvider struct {
+	provider Provider
+	timer    *time.Timer
+	pending  []*microBatchCall
+	maxBatch int
+	maxWait  time.Duration
+	size     int
+	mu       sync.Mutex
+}
+
+type microBatchCall struct {
+	ctx    context.Context
+	result chan microBatchResult
+	texts  []string
+}
+
+type microBatchResult struct {
+	resp *EmbeddingResponse
+	err  error
+}
+
+// NewMicroBatchingProvider creates a provider that collects concurrent Embed
+// calls for up to maxWait, or until maxBatch texts are pending, and issues them
+// as one request to p. Calls with maxBatch or more texts bypass batching.
+// The batched request is canceled once every caller in it has given up, and
+// bounded by the latest caller deadline.
+func NewMicroBatchingProvider(p Provider, maxBatch int, maxWait time.Duration) *MicroBatchingProvider {
+	if maxBatch <= 0 {
+		maxBatch = 1
+	}
+	return &MicroBatchingProvider{
+		provider: p,
+		maxBatch: maxBatch,
+		maxWait:  maxWait,
+	}
+}
+
+// Name returns the wrapped provider's identifier.
+func (m *MicroBatchingProvider) Name() string {
+	return m.provider.Name()
+}
+
+// Dimensions returns the wrapped provider's output dimensionality.
+func (m *MicroBatchingProvider) Dimensions() int {
+	return m.provider.Dimensions()
+}
+
+// Embed queues texts for the next batch and waits for its result.
+func (m *MicroBatchingProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	if len(texts) == 0 || len(texts) >= m.maxBatch {
+		return m.provider.Embed(ctx, texts)
+	}
+
+	call := &microBatchCall{
+		ctx:    ctx,
+		texts:  texts,
+		result: make(chan microBatchResult, 1),
+	}
+
+	m.mu.Lock()
+	if m.size+len(texts) > m.maxBatch {
+		m.flushLocked()
+	}
+	m.pending = append(m.pending, call)
+	m.size += len(texts)
+	if m.size >= m.maxBatch {
+		m.flushLocked()
+	} else if len(m.pending) == 1 {
+		m.timer = time.AfterFunc(m.maxWait, m.flush)
+	}
+	m.mu.Unlock()
+
+	select {
+	case res := <-call.result:
+		return res.resp, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flush sends any pending calls when the wait window expires.
+func (m *MicroBatchingProvider) flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flushLocked()
+}
+
+// flushLocked detaches the pending calls and dispatches them. Caller holds mu.
+func (m *MicroBatchingProvider) flushLocked() {
+	if len(m.pending) == 0 {
+		return
+	}
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	calls := m.pending
+	m.pending = nil
+	m.size = 0
+	go m.dispatch(calls)
+}
+
+// dispatch issues one provider request for calls and fans out the results.
+func (m *MicroBatchingProvider) dispatch(calls []*microBatchCall) {
+	var texts []string
+	for _, call := range calls {
+		texts = append(texts, call.texts...)
+	}
+
+	ctxs := make([]context.Context, len(calls))
+	for i, call := range calls {
+		ctxs[i] = call.ctx
+	}
+	ctx, cancel := mergedContext(ctxs)
+	defer cancel()
+	resp, err := m.provider.Embed(ctx, texts)
+	if err == nil && (resp == nil || len(resp.Vectors) != len(texts)) {
+		err = fmt.Errorf("micro-batch: expected %d vectors from provider", len(texts))
+	}
+	if err != nil {
+		for _, call := range calls {
+			call.result <- microBatchResult{err: err}
+		}
+		return
+	}
+
+	sizes := make([]int, len(calls))
+	for i, call := range calls {
+		sizes[i] = len(call.texts)
+	}
+	for i, part := range splitResponse(resp, sizes) {
+		calls[i].result <- microBatchResult{resp: part}
+	}
+}
+
+// mergedContext returns the context for one request made on behalf of
+// callers. One caller giving up must not fail the others, so it carries the
+// first caller's values but none of their cancellations; it is canceled
+// instead once every caller's context is done, and bounded by the latest
+// caller deadline, or by none if any caller has none. cancel releases it.
+func mergedContext(callers []context.Context) (ctx context.Context, cancel context.CancelFunc) {
+	ctx = context.WithoutCancel(callers[0])
+	latest, bounded := time.Time{}, true
+	for _, caller := range callers {
+		deadline, ok := caller.Deadline()
+		if !ok {
+			bounded = false
+			break
+		}
+		if deadline.After(latest) {
+			latest = deadline
+		}
+	}
+	cancelDeadline := context.CancelFunc(func() {})
+	if bounded {
+		ctx, cancelDeadline = context.WithDeadline(ctx, latest)
+	}
+	ctx, cancelAll := context.WithCancel(ctx)
+
+	var waiting atomic.Int64
+	waiting.Store(int64(len(callers)))
+	stops := make([]func() bool, len(callers))
+	for i, caller := range callers {
+		stops[i] = context.AfterFunc(caller, func() {
+			if waiting.Add(-1) == 0 {
+				cancelAll()
+			}
+		})
+	}
+	return ctx, func() {
+		for _, stop := range stops {
+			stop()
+		}
+		cancelAll()
+		cancelDeadline()
+	}
+}
+
+// splitResponse divides resp, whose embeddings cover consecutive groups of
+// the given sizes, into one response per group. Float and quantized vectors
+// are sliced per group. Usage is apportioned by text count, with the
+// remainder going to the last group so totals still match. Providers report
+// truncation and warnings per request, not per input, so each group gets
+// every warning and Truncated capped at its size: an upper bound that never
+// hides a truncation from the caller it may affect.
+func splitResponse(resp *EmbeddingResponse, sizes []int) []*EmbeddingResponse {
+	total := 0
+	for _, n := range sizes {
+		total += n
+	}
+
+	parts := make([]*EmbeddingResponse, len(sizes))
+	offset := 0
+	promptLeft, totalLeft := resp.Usage.PromptTokens, resp.Usage.TotalTokens
+	for i, n := range sizes {
+		usage := Usage{
+			PromptTokens: resp.Usage.PromptTokens * n / total,
+			TotalTokens:  resp.Usage.TotalTokens * n / total,
+		}
+		if i == len(sizes)-1 {
+			usage = Usage{PromptTokens: promptLeft, TotalTokens: totalLeft}
+		}
+		promptLeft -= usage.PromptTokens
+		totalLeft -= usage.TotalTokens
+
+		part := &EmbeddingResponse{
+			Model:      resp.Model,
+			Usage:      usage,
+			Dimensions: resp.Dimensions,
+			Truncated:  min(resp.Truncated, n),
+			Warnings:   slices.Clone(resp.Warnings),
+		}
+		if resp.Vectors != nil {
+			part.Vectors = resp.Vectors[offset : offset+n]
+		}
+		if resp.Int8Vectors != nil {
+			part.Int8Vectors = resp.Int8Vectors[offset : offset+n]
+		}
+		if resp.BinaryVectors != nil {
+			part.BinaryVectors = resp.BinaryVectors[offset : offset+n]
+		}
+		parts[i] = part
+		offset += n
+	}
+	return parts
+}
@@ -0,0 +1,219 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingProvider records the batch size of every Embed call.
+type recordingProvider struct {
+	err     error
+	batches []int
+	dims    int
+	mu      sync.Mutex
+}
+
+func (*recordingProvider) Name() string      { return "recording" }
+func (p *recordingProvider) Dimensions() int { return p.dims }
+
+func (p *recordingProvider) Embed(_ context.Context, texts []string) (*EmbeddingResponse, error) {
+	p.mu.Lock()
+	p.batches = append(p.batches, len(texts))
+	p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	vecs := make([]Vector, len(texts))
+	for i, text := range texts {
+		vec := make(Vector, p.dims)
+		vec[0] = float32(len(text))
+		vecs[i] = vec
+	}
+	return &EmbeddingResponse{
+		Vectors:    vecs,
+		Model:      "recording",
+		Dimensions: p.dims,
+		Usage:      Usage{PromptTokens: len(texts), TotalTokens: len(texts)},
+	}, nil
+}
+
+func (p *recordingProvider) calls() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]int(nil), p.batches...)
+}
+
+// blockingProvider hands each call's context to the test and holds the
+// call until that context is done.
+type blockingProvider struct {
+	seen chan context.Context
+}
+
+func (*blockingProvider) Name() string    { return "blocking" }
+func (*blockingProvider) Dimensions() int { return 1 }
+func (p *blockingProvider) Embed(ctx context.Context, _ []string) (*EmbeddingResponse, error) {
+	p.seen <- ctx
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestMicroBatchingProvider(t *testing.T) {
+	t.Run("batches concurrent single embeds", func(t *testing.T) {
+		inner := &recordingProvider{dims: 4}
+		provider := NewMicroBatchingProvider(inner, 16, 50*time.Millisecond)
+
+		const callers = 48
+		var wg sync.WaitGroup
+		errs := make(chan error, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				// Each text has a distinct length so results can be matched to callers
+				text := string(make([]byte, i+1))
+				resp, err := provider.Embed(context.Background(), []string{text})
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(resp.Vectors) != 1 || resp.Vectors[0][0] != float32(i+1) {
+					errs <- errors.New("caller received another caller's vector")
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+
+		calls := inner.calls()
+		if len(calls) >= callers {
+			t.Errorf("expected batched calls, got %d calls for %d callers", len(calls), callers)
+		}
+		total := 0
+		for _, n := range calls {
+			if n > 16 {
+				t.Errorf("batch of %d exceeds max batch 16", n)
+			}
+			total += n
+		}
+		if total != callers {
+			t.Errorf("expected %d texts embedded, got %d", callers, total)
+		}
+	})
+
+	t.Run("flushes after max wait", func(t *testing.T) {
+		inner := &recordingProvider{dims: 4}
+		provider := NewMicroBatchingProvider(inner, 100, 10*time.Millisecond)
+
+		resp, err := provider.Embed(context.Background(), []string{"alone"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Vectors) != 1 {
+			t.Errorf("expected 1 vector, got %d", len(resp.Vectors))
+		}
+	})
+
+	t.Run("large calls bypass batching", func(t *testing.T) {
+		inner := &recordingProvider{dims: 4}
+		provider := NewMicroBatchingProvider(inner, 2, time.Hour)
+
+		resp, err := provider.Embed(context.Background(), []string{"a", "b", "c"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Vectors) != 3 {
+			t.Errorf("expected 3 vectors, got %d", len(resp.Vectors))
+		}
+	})
+
+	t.Run("propagates provider errors to all callers", func(t *testing.T) {
+		inner := &recordingProvider{dims: 4, err: errors.New("provider down")}
+		provider := NewMicroBatchingProvider(inner, 2, time.Hour)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := provider.Embed(context.Background(), []string{"x"}); err == nil {
+					t.Error("expected error, got nil")
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("honors caller cancellation", func(t *testing.T) {
+		inner := &recordingProvider{dims: 4}
+		provider := NewMicroBatchingProvider(inner, 100, time.Hour)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := provider.Embed(ctx, []string{"x"}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("bounds the merged call by the latest caller deadline", func(t *testing.T) {
+		inner := &blockingProvider{seen: make(chan context.Context, 1)}
+		provider := NewMicroBatchingProvider(inner, 2, time.Hour)
+
+		latest := time.Now().Add(time.Hour)
+		ctx1, cancel1 := context.WithDeadline(context.Background(), latest.Add(-time.Minute))
+		ctx2, cancel2 := context.WithDeadline(context.Background(), latest)
+		done := make(chan struct{}, 2)
+		for _, ctx := range []context.Context{ctx1, ctx2} {
+			go func() {
+				provider.Embed(ctx, []string{"x"}) //nolint:errcheck // canceled below
+				done <- struct{}{}
+			}()
+		}
+
+		merged := <-inner.seen
+		if deadline, ok := merged.Deadline(); !ok || !deadline.Equal(latest) {
+			t.Errorf("expected deadline %v, got %v", latest, deadline)
+		}
+		cancel1()
+		cancel2()
+		<-done
+		<-done
+	})
+
+	t.Run("cancels the merged call once every caller is done", func(t *testing.T) {
+		inner := &blockingProvider{seen: make(chan context.Context, 1)}
+		provider := NewMicroBatchingProvider(inner, 2, time.Hour)
+
+		ctx1, cancel1 := context.WithCancel(context.Background())
+		ctx2, cancel2 := context.WithCancel(context.Background())
+		for _, ctx := range []context.Context{ctx1, ctx2} {
+			go provider.Embed(ctx, []string{"x"}) //nolint:errcheck // canceled below
+		}
+
+		merged := <-inner.seen
+		cancel1()
+		time.Sleep(10 * time.Millisecond)
+		if merged.Err() != nil {
+			t.Fatal("expected the merged call to continue while a caller waits")
+		}
+		cancel2()
+		select {
+		case <-merged.Done():
+		case <-time.After(time.Second):
+			t.Error("expected the merged call to be canceled")
+		}
+	})
+
+	t.Run("delegates name and dimensions", func(t *testing.T) {
+		provider := NewMicroBatchingProvider(&recordingProvider{dims: 8}, 4, time.Millisecond)
+		if provider.Name() != "recording" || provider.Dimensions() != 8 {
+			t.Error("expected wrapped provider's name and dimensions")
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package vex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/zoobzio/pipz"
+)
+
+// negativeCache remembers requests that failed with a non-retryable
+// ProviderError and short-circuits identical requests with the cached error
+// until the TTL expires. Requests are keyed by their exact texts, since a
+// provider error does not identify which input in a batch was at fault.
+type negativeCache struct {
+	identity  pipz.Identity
+	processor pipz.Chainable[*EmbedRequest]
+	ttl       time.Duration
+	now       func() time.Time
+	entries   map[[sha256.Size]byte]negativeEntry
+	mu        sync.Mutex
+	closeOnce sync.Once
+	closeErr  error
+}
+
+type negativeEntry struct {
+	err     error
+	expires time.Time
+}
+
+func newNegativeCache(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], ttl time.Duration) *negativeCache {
+	return &negativeCache{
+		identity:  identity,
+		processor: processor,
+		ttl:       ttl,
+		now:       time.Now,
+		entries:   make(map[[sha256.Size]byte]negativeEntry),
+	}
+}
+
+// Process implements pipz.Chainable.
+func (c *negativeCache) Process(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+	key := negativeCacheKey(req.Texts)
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && now.After(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+
+	if ok {
+		req.stats.recordCacheHit()
+		req.Error = entry.err
+		return req, entry.err
+	}
+
+	out, err := c.processor.Process(ctx, req)
+	var perr *ProviderError
+	if err != nil && errors.As(err, &perr) && !perr.Retryable() {
+		c.mu.Lock()
+		c.entries[key] = negativeEntry{err: perr, expires: now.Add(c.ttl)}
+		c.mu.Unlock()
+	}
+	return out, err
+}
+
+// negativeCacheKey hashes texts with length prefixes so that different
+// splits of the same characters never collide.
+func negativeCacheKey(texts []string) [sha256.Size]byte {
+	h := sha256.New()
+	var lenBuf [8]byte
+	for _, text := range texts {
+		binary.LittleEndian.PutUint64(lenBuf[:], uint64(len(text)))
+		h.Write(lenBuf[:])
+		h.Write([]byte(text))
+	}
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// Identity implements pipz.Chainable.
+func (c *negativeCache) Identity() pipz.Identity {
+	return c.identity
+}
+
+// Schema implements pipz.Chainable.
+func (c *negativeCache) Schema() pipz.Node {
+	return pipz.Node{
+		Identity: c.identity,
+		Type:     "negative-cache",
+		Flow:     pipz.FilterFlow{Processor: c.processor.Schema()},
+		Metadata: map[string]any{
+			"ttl": c.ttl.String(),
+		},
+	}
+}
+
+// Close implements pipz.Chainable.
+func (c *negativeCache) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.processor.Close()
+	})
+	return c.closeErr
+}
@@ -0,0 +1,352 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zoobzio/vex"
+)
+
+// MaxBatchRequests is the maximum number of requests in a single Batch API job.
+const MaxBatchRequests = 50000
+
+// DefaultBatchPollInterval is the initial delay between batch status checks.
+// The delay doubles after each check, up to maxBatchPollInterval.
+const DefaultBatchPollInterval = 5 * time.Second
+
+const maxBatchPollInterval = time.Minute
+
+// Batch API status values.
+const (
+	batchStatusCompleted = "completed"
+	batchStatusFailed    = "failed"
+	batchStatusExpired   = "expired"
+	batchStatusCancelled = "cancelled"
+)
+
+// BatchJob is an embedding job submitted to the OpenAI Batch API.
+// Batch jobs are billed at a discount but complete asynchronously,
+// within 24 hours. Use Wait to block until results are available.
+type BatchJob struct {
+	provider *Provider
+
+	// ID is the OpenAI batch identifier, e.g. for resuming with ResumeBatch.
+	ID string
+
+	// PollInterval is the initial delay between status checks.
+	// Defaults to DefaultBatchPollInterval.
+	PollInterval time.Duration
+
+	count int
+}
+
+// EmbedAsync submits texts as an OpenAI Batch API job. Each text becomes one
+// embeddings request whose custom_id is its index in texts. The returned job
+// has been created; call Wait to collect the vectors.
+func (p *Provider) EmbedAsync(ctx context.Context, texts []string) (*BatchJob, error) {
+	if len(texts) == 0 {
+		return nil, errors.New("openai batch: no texts")
+	}
+	if len(texts) > MaxBatchRequests {
+		return nil, fmt.Errorf("openai batch: %d texts exceeds limit of %d", len(texts), MaxBatchRequests)
+	}
+
+	var input bytes.Buffer
+	enc := json.NewEncoder(&input)
+	for i, text := range texts {
+		line := batchRequestLine{
+			CustomID: strconv.Itoa(i),
+			Method:   "POST",
+			URL:      "/v1/embeddings",
+			Body:     p.newEmbeddingRequest([]string{text}),
+		}
+		if err := enc.Encode(line); err != nil {
+			return nil, fmt.Errorf("failed to marshal batch request: %w", err)
+		}
+	}
+
+	fileID, err := p.uploadBatchFile(ctx, input.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBody, err := json.Marshal(createBatchRequest{
+		InputFileID:      fileID,
+		Endpoint:         "/v1/embeddings",
+		CompletionWindow: "24h",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint("/batches"), bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var b batchObject
+	if err := json.Unmarshal(body, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return p.ResumeBatch(b.ID, len(texts)), nil
+}
+
+// ResumeBatch returns a handle to a previously submitted job, e.g. after a
+// restart. count must equal the number of texts originally submitted.
+func (p *Provider) ResumeBatch(id string, count int) *BatchJob {
+	return &BatchJob{
+		provider:     p,
+		ID:           id,
+		PollInterval: DefaultBatchPollInterval,
+		count:        count,
+	}
+}
+
+// Wait polls the job with exponential backoff until it finishes or ctx is
+// done, then downloads and parses its results. Vectors are returned in the
+// order the texts were submitted.
+//
+// If some requests in the job failed, or the job expired or was cancelled
+// before finishing, Wait returns a *vex.BatchError whose Partial method
+// exposes the vectors that did succeed.
+func (j *BatchJob) Wait(ctx context.Context) (*vex.EmbeddingResponse, error) {
+	delay := j.PollInterval
+	if delay <= 0 {
+		delay = DefaultBatchPollInterval
+	}
+
+	for {
+		b, err := j.provider.getBatch(ctx, j.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch b.Status {
+		case batchStatusCompleted, batchStatusExpired, batchStatusCancelled:
+			return j.collect(ctx, b)
+		case batchStatusFailed:
+			return nil, fmt.Errorf("openai batch %s failed: %s", j.ID, b.Errors.message())
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(2*delay, maxBatchPollInterval)
+	}
+}
+
+// collect downloads the output and error files of a finished batch.
+func (j *BatchJob) collect(ctx context.Context, b *batchObject) (*vex.EmbeddingResponse, error) {
+	result := &vex.EmbeddingResponse{
+		Vectors: make([]vex.Vector, j.count),
+	}
+	failed := make([]error, j.count)
+
+	for _, fileID := range []string{b.OutputFileID, b.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		content, err := j.provider.downloadFile(ctx, fileID)
+		if err != nil {
+			return nil, err
+		}
+		if err := j.parseOutput(content, result, failed); err != nil {
+			return nil, err
+		}
+	}
+
+	var failures []vex.BatchFailure
+	for i := range j.count {
+		err := failed[i]
+		if err == nil && result.Vectors[i] == nil {
+			err = fmt.Errorf("openai batch %s: request %d not processed (status %s)", j.ID, i, b.Status)
+		}
+		if err != nil {
+			failures = append(failures, vex.BatchFailure{Err: err, Start: i, End: i + 1})
+		}
+	}
+	if len(failures) > 0 {
+		return nil, vex.NewBatchError(failures, result.Vectors)
+	}
+	return result, nil
+}
+
+// parseOutput reads JSONL result lines into result and failed, indexed by custom_id.
+func (j *BatchJob) parseOutput(content []byte, result *vex.EmbeddingResponse, failed []error) error {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	for {
+		var line batchResponseLine
+		if err := dec.Decode(&line); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to parse batch output: %w", err)
+		}
+
+		idx, err := strconv.Atoi(line.CustomID)
+		if err != nil || idx < 0 || idx >= j.count {
+			return fmt.Errorf("invalid custom_id %q in batch output", line.CustomID)
+		}
+
+		switch {
+		case line.Error != nil:
+			failed[idx] = fmt.Errorf("openai batch request %d: %s", idx, line.Error.Message)
+		case line.Response.StatusCode != http.StatusOK:
+			failed[idx] = newProviderError(line.Response.StatusCode, nil, line.Response.Body)
+		default:
+			var embResp embeddingResponse
+			if err := json.Unmarshal(line.Response.Body, &embResp); err != nil {
+				return fmt.Errorf("failed to parse batch response %d: %w", idx, err)
+			}
+			if len(embResp.Data) != 1 {
+				failed[idx] = fmt.Errorf("openai batch request %d: expected 1 embedding, got %d", idx, len(embResp.Data))
+				continue
+			}
+			if len(embResp.Data[0].Embedding) == 0 {
+				failed[idx] = &vex.EmptyEmbeddingError{Provider: "openai", Index: idx}
+				continue
+			}
+			result.Vectors[idx] = vex.Vector(embResp.Data[0].Embedding)
+			result.Model = embResp.Model
+			result.Dimensions = len(result.Vectors[idx])
+			result.Usage.PromptTokens += embResp.Usage.PromptTokens
+			result.Usage.TotalTokens += embResp.Usage.TotalTokens
+		}
+	}
+}
+
+// uploadBatchFile uploads JSONL content via the Files API and returns its ID.
+func (p *Provider) uploadBatchFile(ctx context.Context, content []byte) (string, error) {
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	if err := w.WriteField("purpose", "batch"); err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+	part, err := w.CreateFormFile("file", "embeddings.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint("/files"), &form)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	body, err := p.do(req)
+	if err != nil {
+		return "", err
+	}
+
+	var f fileObject
+	if err := json.Unmarshal(body, &f); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return f.ID, nil
+}
+
+// getBatch fetches the current state of a batch.
+func (p *Provider) getBatch(ctx context.Context, id string) (*batchObject, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.endpoint("/batches/"+id), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var b batchObject
+	if err := json.Unmarshal(body, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &b, nil
+}
+
+// downloadFile fetches the content of a file.
+func (p *Provider) downloadFile(ctx context.Context, id string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.endpoint("/files/"+id+"/content"), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return p.do(req)
+}
+
+// Batch API types
+
+type batchRequestLine struct {
+	CustomID string           `json:"custom_id"`
+	Method   string           `json:"method"`
+	URL      string           `json:"url"`
+	Body     embeddingRequest `json:"body"`
+}
+
+type batchResponseLine struct {
+	Error    *batchLineError `json:"error"`
+	CustomID string          `json:"custom_id"`
+	Response struct {
+		Body       json.RawMessage `json:"body"`
+		StatusCode int             `json:"status_code"`
+	} `json:"response"`
+}
+
+type batchLineError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type createBatchRequest struct {
+	InputFileID      string `json:"input_file_id"`
+	Endpoint         string `json:"endpoint"`
+	CompletionWindow string `json:"completion_window"`
+}
+
+type batchObject struct {
+	ID           string      `json:"id"`
+	Status       string      `json:"status"`
+	OutputFileID string      `json:"output_file_id"`
+	ErrorFileID  string      `json:"error_file_id"`
+	Errors       batchErrors `json:"errors"`
+}
+
+type batchErrors struct {
+	Data []batchLineError `json:"data"`
+}
+
+// message returns the first error message, if any.
+func (e batchErrors) message() string {
+	if len(e.Data) == 0 {
+		return "no error details"
+	}
+	return e.Data[0].Message
+}
+
+type fileObject struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,271 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zoobzio/vex"
+)
+
+// batchServer mocks the Files and Batches endpoints. Each uploaded request
+// embeds to a one-dimensional vector holding its text length; texts listed in
+// reject fail with a 400 in the error file.
+type batchServer struct {
+	reject   map[string]bool
+	status   string
+	requests []batchRequestLine
+	polls    int
+	mu       sync.Mutex
+}
+
+func (s *batchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/files":
+		if r.FormValue("purpose") != "batch" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		dec := json.NewDecoder(file)
+		for {
+			var line batchRequestLine
+			if err := dec.Decode(&line); err != nil {
+				break
+			}
+			s.requests = append(s.requests, line)
+		}
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(fileObject{ID: "file-input"})
+
+	case r.Method == "POST" && r.URL.Path == "/batches":
+		var req createBatchRequest
+		//nolint:errcheck // test helper
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.InputFileID != "file-input" || req.Endpoint != "/v1/embeddings" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(batchObject{ID: "batch-1", Status: "validating"})
+
+	case r.Method == "GET" && r.URL.Path == "/batches/batch-1":
+		s.polls++
+		b := batchObject{ID: "batch-1", Status: "in_progress"}
+		if s.polls > 1 {
+			b.Status = s.status
+			b.OutputFileID = "file-output"
+			b.ErrorFileID = "file-errors"
+			if s.status == batchStatusFailed {
+				b.Errors.Data = []batchLineError{{Code: "invalid_file", Message: "bad input file"}}
+			}
+		}
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(b)
+
+	case r.Method == "GET" && r.URL.Path == "/files/file-output/content":
+		w.Write(s.output(false)) //nolint:errcheck // test helper
+
+	case r.Method == "GET" && r.URL.Path == "/files/file-errors/content":
+		w.Write(s.output(true)) //nolint:errcheck // test helper
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// output renders result lines for successful or rejected requests. An
+// expired batch leaves its last request unprocessed.
+func (s *batchServer) output(rejected bool) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i, req := range s.requests {
+		if s.status == batchStatusExpired && i == len(s.requests)-1 {
+			continue
+		}
+		text := req.Body.Input[0]
+		if s.reject[text] != rejected {
+			continue
+		}
+
+		var line batchResponseLine
+		line.CustomID = req.CustomID
+		if rejected {
+			line.Response.StatusCode = http.StatusBadRequest
+			line.Response.Body = mustMarshal(map[string]interface{}{
+				"error": map[string]string{"message": "input rejected", "type": "invalid_request_error"},
+			})
+		} else {
+			line.Response.StatusCode = http.StatusOK
+			line.Response.Body = mustMarshal(embeddingResponse{
+				Model: "text-embedding-3-small",
+				Data:  []embeddingData{{Embedding: embeddingValues{float32(len(text))}}},
+				Usage: usage{PromptTokens: 1, TotalTokens: 1},
+			})
+		}
+		enc.Encode(line) //nolint:errcheck // test helper
+	}
+	return buf.Bytes()
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func submitBatch(t *testing.T, s *batchServer, texts []string) (*BatchJob, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	p := New(Config{APIKey: "test", BaseURL: server.URL})
+	job, err := p.EmbedAsync(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	job.PollInterval = time.Millisecond
+	return job, server
+}
+
+func TestProvider_EmbedAsync(t *testing.T) {
+	t.Run("submits texts and collects vectors in order", func(t *testing.T) {
+		s := &batchServer{status: batchStatusCompleted}
+		texts := []string{"a", "bb", "ccc"}
+		job, _ := submitBatch(t, s, texts)
+
+		if job.ID != "batch-1" {
+			t.Errorf("expected job ID 'batch-1', got %q", job.ID)
+		}
+		if len(s.requests) != 3 || s.requests[2].CustomID != "2" || s.requests[2].URL != "/v1/embeddings" {
+			t.Fatalf("unexpected uploaded requests: %+v", s.requests)
+		}
+
+		resp, err := job.Wait(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, vec := range resp.Vectors {
+			if vec[0] != float32(len(texts[i])) {
+				t.Errorf("vector %d out of order: %v", i, vec)
+			}
+		}
+		if resp.Usage.TotalTokens != 3 || resp.Dimensions != 1 {
+			t.Errorf("unexpected response metadata: %+v", resp)
+		}
+		if s.polls != 2 {
+			t.Errorf("expected 2 status polls, got %d", s.polls)
+		}
+	})
+
+	t.Run("reports partial failures from error file", func(t *testing.T) {
+		s := &batchServer{status: batchStatusCompleted, reject: map[string]bool{"bb": true}}
+		job, _ := submitBatch(t, s, []string{"a", "bb", "ccc"})
+
+		_, err := job.Wait(context.Background())
+		var berr *vex.BatchError
+		if !errors.As(err, &berr) {
+			t.Fatalf("expected BatchError, got %v", err)
+		}
+		if len(berr.Failures) != 1 || berr.Failures[0].Start != 1 || berr.Failures[0].End != 2 {
+			t.Errorf("expected failure for request 1, got %+v", berr.Failures)
+		}
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) || perr.StatusCode != http.StatusBadRequest || perr.Message != "input rejected" {
+			t.Errorf("expected 400 ProviderError, got %v", err)
+		}
+		partial := berr.Partial()
+		if partial[0] == nil || partial[1] != nil || partial[2] == nil {
+			t.Errorf("expected vectors for requests 0 and 2 only, got %v", partial)
+		}
+	})
+
+	t.Run("reports unprocessed requests of expired batch", func(t *testing.T) {
+		s := &batchServer{status: batchStatusExpired}
+		job, _ := submitBatch(t, s, []string{"a", "bb"})
+
+		_, err := job.Wait(context.Background())
+		var berr *vex.BatchError
+		if !errors.As(err, &berr) {
+			t.Fatalf("expected BatchError, got %v", err)
+		}
+		if len(berr.Failures) != 1 || berr.Failures[0].Start != 1 {
+			t.Errorf("expected request 1 to be reported unprocessed, got %+v", berr.Failures)
+		}
+		if !strings.Contains(err.Error(), "not processed") {
+			t.Errorf("expected 'not processed' in error, got %q", err.Error())
+		}
+	})
+
+	t.Run("returns error for failed batch", func(t *testing.T) {
+		s := &batchServer{status: batchStatusFailed}
+		job, _ := submitBatch(t, s, []string{"a"})
+
+		_, err := job.Wait(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "bad input file") {
+			t.Errorf("expected failed batch error, got %v", err)
+		}
+	})
+
+	t.Run("stops polling when context is canceled", func(t *testing.T) {
+		s := &batchServer{status: "in_progress"}
+		job, _ := submitBatch(t, s, []string{"a"})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		if _, err := job.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("resumes by ID", func(t *testing.T) {
+		s := &batchServer{status: batchStatusCompleted}
+		_, server := submitBatch(t, s, []string{"a", "bb"})
+
+		p := New(Config{APIKey: "test", BaseURL: server.URL})
+		job := p.ResumeBatch("batch-1", 2)
+		job.PollInterval = time.Millisecond
+
+		resp, err := job.Wait(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Vectors) != 2 {
+			t.Errorf("expected 2 vectors, got %d", len(resp.Vectors))
+		}
+	})
+
+	t.Run("rejects empty input", func(t *testing.T) {
+		p := New(Config{APIKey: "test"})
+		if _, err := p.EmbedAsync(context.Background(), nil); err == nil {
+			t.Error("expected error for empty input")
+		}
+	})
+}
+
+func TestBatchJob_ParseOutputRejectsUnknownCustomID(t *testing.T) {
+	job := &BatchJob{ID: "batch-1", count: 1}
+	content := []byte(`{"custom_id":"7","response":{"status_code":200,"body":{}}}` + "\n")
+
+	err := job.parseOutput(content, &vex.EmbeddingResponse{Vectors: make([]vex.Vector, 1)}, make([]error, 1))
+	if err == nil || !strings.Contains(err.Error(), "custom_id") {
+		t.Errorf("expected invalid custom_id error, got %v", err)
+	}
+}
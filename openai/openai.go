@@ -4,15 +4,25 @@ package openai
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/zoobzio/vex"
+	"github.com/zoobzio/vex/internal/batching"
+	"github.com/zoobzio/vex/internal/httputil"
 )
 
+// MaxTokensTextEmbedding is the input token limit for OpenAI embedding models.
+const MaxTokensTextEmbedding = 8191
+
 // Default model dimensions.
 const (
 	DimensionsAda002              = 1536
@@ -20,13 +30,60 @@ const (
 	DimensionsTextEmbedding3Large = 3072
 )
 
+// DefaultMaxBatchSize is the maximum number of inputs per API request.
+const DefaultMaxBatchSize = 2048
+
+// EncodingFormat specifies how the API encodes returned embeddings.
+type EncodingFormat string
+
+// Encoding format constants.
+const (
+	EncodingFormatFloat  EncodingFormat = "float"
+	EncodingFormatBase64 EncodingFormat = "base64"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when
+// AzureConfig.APIVersion is empty.
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// azureKeyHeader carries the API key for Azure OpenAI, which does not accept
+// Bearer authentication for key-based access.
+const azureKeyHeader = "api-key"
+
+// TokenCounter returns the number of tokens a text is expected to consume.
+type TokenCounter func(text string) int
+
+// EstimateTokens is the default TokenCounter. It assumes about three bytes per
+// token, which overestimates typical English text (about four characters per
+// token) so that truncation errs on the side of staying under the limit.
+func EstimateTokens(text string) int {
+	return (len(text) + 2) / 3
+}
+
 // Provider implements vex.Provider for OpenAI embeddings API.
 type Provider struct {
-	httpClient *http.Client
-	apiKey     string
-	model      string
-	baseURL    string
-	dimensions int
+	httpClient     *http.Client
+	apiKey         string
+	model          string
+	baseURL        string
+	encodingFormat EncodingFormat
+	user           string
+	tokenCounter   TokenCounter
+	dimensions     int
+	maxBatchSize   int
+	extra          map[string]any
+	headers        map[string]string
+	azure          *AzureConfig
+	truncate       bool
+}
+
+// AzureConfig targets an Azure OpenAI deployment. With it set, BaseURL is the
+// resource endpoint (e.g. "https://my-resource.openai.azure.com"), requests
+// go to the deployment's path with an api-version query parameter, and the
+// API key is sent in the api-key header instead of as a Bearer token.
+type AzureConfig struct {
+	Deployment string // Required, the deployment name rather than the model
+	APIVersion string // Optional, defaults to DefaultAzureAPIVersion
 }
 
 // Config holds configuration for the OpenAI embedding provider.
@@ -36,6 +93,47 @@ type Config struct {
 	BaseURL    string        // Optional, defaults to "https://api.openai.com/v1"
 	Dimensions int           // Optional, model-specific default
 	Timeout    time.Duration // Optional, defaults to 30s
+
+	// EncodingFormat selects the wire format for returned embeddings.
+	// EncodingFormatBase64 roughly halves response size for large vectors.
+	// Optional, defaults to EncodingFormatFloat.
+	EncodingFormat EncodingFormat
+
+	// User is an end-user identifier sent for abuse monitoring. Optional.
+	User string
+
+	// MaxBatchSize caps inputs per API request; larger batches are split
+	// into sequential sub-requests. Optional, defaults to DefaultMaxBatchSize.
+	MaxBatchSize int
+
+	// TruncateOverlong trims inputs that exceed the model's token limit
+	// instead of letting the API reject the whole batch. The number of
+	// trimmed inputs is reported in EmbeddingResponse.Truncated.
+	TruncateOverlong bool
+
+	// TokenCounter estimates input tokens for TruncateOverlong. Supply an
+	// exact tokenizer for tighter truncation. Optional, defaults to EstimateTokens.
+	TokenCounter TokenCounter
+
+	// Extra holds additional request body fields for parameters this package
+	// does not support yet, merged into every embeddings request, including
+	// those in Batch files. Fields the provider sets itself are not
+	// overridden. Optional.
+	Extra map[string]any
+
+	// Headers are set on every outgoing request, including Batch uploads,
+	// after the provider's own headers, so they override them on conflict.
+	// Optional.
+	Headers map[string]string
+
+	// Azure switches the provider to Azure OpenAI's deployment-style URLs
+	// and api-key authentication. BaseURL must then be set to the resource
+	// endpoint. Optional.
+	Azure *AzureConfig
+
+	// HTTPClient is used for all requests when set, e.g. for proxies, mTLS,
+	// or instrumented transports. Timeout is applied if the client has none.
+	HTTPClient *http.Client
 }
 
 // New creates a new OpenAI embedding provider.
@@ -52,15 +150,42 @@ func New(config Config) *Provider {
 	if config.Dimensions == 0 {
 		config.Dimensions = dimensionsForModel(config.Model)
 	}
+	if config.EncodingFormat == "" {
+		config.EncodingFormat = EncodingFormatFloat
+	}
+	if config.MaxBatchSize == 0 {
+		config.MaxBatchSize = DefaultMaxBatchSize
+	}
+	if config.TokenCounter == nil {
+		config.TokenCounter = EstimateTokens
+	}
+	httpClient := httputil.NewClient(config.HTTPClient, config.Timeout)
+	var azure *AzureConfig
+	if config.Azure != nil {
+		azure = &AzureConfig{
+			Deployment: config.Azure.Deployment,
+			APIVersion: config.Azure.APIVersion,
+		}
+		if azure.APIVersion == "" {
+			azure.APIVersion = DefaultAzureAPIVersion
+		}
+		httpClient = httputil.StripOnRedirect(httpClient, azureKeyHeader)
+	}
 
 	return &Provider{
-		apiKey:     config.APIKey,
-		model:      config.Model,
-		baseURL:    config.BaseURL,
-		dimensions: config.Dimensions,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+		apiKey:         config.APIKey,
+		model:          config.Model,
+		baseURL:        config.BaseURL,
+		dimensions:     config.Dimensions,
+		encodingFormat: config.EncodingFormat,
+		user:           config.User,
+		maxBatchSize:   config.MaxBatchSize,
+		truncate:       config.TruncateOverlong,
+		tokenCounter:   config.TokenCounter,
+		extra:          maps.Clone(config.Extra),
+		headers:        maps.Clone(config.Headers),
+		azure:          azure,
+		httpClient:     httpClient,
 	}
 }
 
@@ -74,7 +199,27 @@ func (p *Provider) Dimensions() int {
 	return p.dimensions
 }
 
+// MaxTokens returns the per-input token limit.
+// Implements vex.TokenLimiter.
+func (*Provider) MaxTokens() int {
+	return MaxTokensTextEmbedding
+}
+
+// Model returns the requested model identifier.
+// Implements vex.ModelReporter.
+func (p *Provider) Model() string {
+	return p.model
+}
+
+// WithUser returns a new provider with the specified end-user identifier.
+func (p *Provider) WithUser(user string) *Provider {
+	newP := *p
+	newP.user = user
+	return &newP
+}
+
 // Embed generates embeddings for the given texts.
+// Batches larger than the configured MaxBatchSize are split transparently.
 func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingResponse, error) {
 	if len(texts) == 0 {
 		return &vex.EmbeddingResponse{
@@ -84,23 +229,120 @@ func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingRes
 		}, nil
 	}
 
-	reqBody := embeddingRequest{
-		Model: p.model,
-		Input: texts,
+	truncated := 0
+	if p.truncate {
+		texts, truncated = p.truncateOverlong(texts)
 	}
 
+	resp, err := batching.Embed(ctx, texts, p.maxBatchSize, p.embed)
+	if err != nil {
+		return nil, err
+	}
+	resp.Truncated = truncated
+	return resp, nil
+}
+
+// truncateOverlong returns texts with any input over the token limit trimmed,
+// and the number of inputs trimmed. The input slice is never modified.
+func (p *Provider) truncateOverlong(texts []string) ([]string, int) {
+	limit := p.MaxTokens()
+	var out []string
+	truncated := 0
+	for i, text := range texts {
+		if p.tokenCounter(text) <= limit {
+			continue
+		}
+		if out == nil {
+			out = append([]string(nil), texts...)
+		}
+		out[i] = truncateToTokens(text, limit, p.tokenCounter)
+		truncated++
+	}
+	if out == nil {
+		return texts, 0
+	}
+	return out, truncated
+}
+
+// truncateToTokens returns the longest rune-aligned prefix of text that count
+// reports as within limit, found by binary search over rune offsets.
+func truncateToTokens(text string, limit int, count TokenCounter) string {
+	runes := []rune(text)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if count(string(runes[:mid])) <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[:lo])
+}
+
+// embed issues a single embeddings request.
+func (p *Provider) embed(ctx context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+	reqBody := p.newEmbeddingRequest(texts)
+
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/embeddings", bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint("/embeddings"), bytes.NewReader(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	httputil.SetRequestID(req)
+
+	body, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var embResp embeddingResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return toEmbeddingResponse(&embResp)
+}
+
+// newEmbeddingRequest builds the request body for texts.
+func (p *Provider) newEmbeddingRequest(texts []string) embeddingRequest {
+	return embeddingRequest{
+		Model:          p.model,
+		Input:          texts,
+		EncodingFormat: string(p.encodingFormat),
+		User:           p.user,
+		extra:          p.extra,
+	}
+}
+
+// endpoint returns the URL for an API path such as "/embeddings". On Azure,
+// embeddings are served under the deployment, other resources under the
+// resource-level /openai prefix, and every request carries the api-version.
+func (p *Provider) endpoint(path string) string {
+	if p.azure == nil {
+		return p.baseURL + path
+	}
+	if path == "/embeddings" {
+		path = "/deployments/" + url.PathEscape(p.azure.Deployment) + path
+	}
+	return p.baseURL + "/openai" + path + "?api-version=" + url.QueryEscape(p.azure.APIVersion)
+}
+
+// do authenticates and sends req, returning the response body.
+// Non-200 responses are returned as *vex.ProviderError.
+func (p *Provider) do(req *http.Request) ([]byte, error) {
+	authHeader := "Authorization"
+	if p.azure != nil {
+		authHeader = azureKeyHeader
+		req.Header.Set(azureKeyHeader, p.apiKey)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	httputil.SetHeaders(req, p.headers)
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
@@ -108,36 +350,58 @@ func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingRes
 	}
 	defer resp.Body.Close()
 
+	if err := httputil.CheckRedirectHeader(req, resp, authHeader); err != nil {
+		return nil, err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var errResp errorResponse
-		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
-			return nil, fmt.Errorf("openai error (%d): %s", resp.StatusCode, errResp.Error.Message)
-		}
-		return nil, fmt.Errorf("openai error: status %d", resp.StatusCode)
+		return nil, newProviderError(resp.StatusCode, resp.Header, body)
 	}
+	return body, nil
+}
 
-	var embResp embeddingResponse
-	if err := json.Unmarshal(body, &embResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// newProviderError builds a ProviderError from a non-200 response.
+func newProviderError(status int, header http.Header, body []byte) *vex.ProviderError {
+	perr := &vex.ProviderError{
+		Provider:   "openai",
+		StatusCode: status,
+		RetryAfter: httputil.RetryAfter(header, time.Now()),
 	}
+	var errResp errorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		perr.Message = errResp.Error.Message
+		perr.Type = errResp.Error.Type
+	}
+	return perr
+}
 
+// toEmbeddingResponse converts an API response, ordering vectors by index.
+func toEmbeddingResponse(embResp *embeddingResponse) (*vex.EmbeddingResponse, error) {
 	vectors := make([]vex.Vector, len(embResp.Data))
 	for _, d := range embResp.Data {
 		if d.Index < 0 || d.Index >= len(vectors) {
 			return nil, fmt.Errorf("invalid index %d from API", d.Index)
 		}
-		vectors[d.Index] = toFloat32(d.Embedding)
+		vectors[d.Index] = vex.Vector(d.Embedding)
+	}
+	if err := vex.ValidateVectors("openai", vectors); err != nil {
+		return nil, err
+	}
+
+	dims := 0
+	if len(vectors) > 0 {
+		dims = len(vectors[0])
 	}
 
 	return &vex.EmbeddingResponse{
 		Vectors:    vectors,
 		Model:      embResp.Model,
-		Dimensions: len(vectors[0]),
+		Dimensions: dims,
 		Usage: vex.Usage{
 			PromptTokens: embResp.Usage.PromptTokens,
 			TotalTokens:  embResp.Usage.TotalTokens,
@@ -158,20 +422,57 @@ func dimensionsForModel(model string) int {
 	}
 }
 
-// toFloat32 converts a float64 slice to a vex.Vector (float32).
-func toFloat32(f64 []float64) vex.Vector {
-	result := make(vex.Vector, len(f64))
-	for i, v := range f64 {
-		result[i] = float32(v)
+// embeddingValues decodes an embedding returned either as a JSON array of
+// floats or as a base64 string of little-endian float32 values.
+type embeddingValues []float32
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *embeddingValues) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		var values []float32
+		if err := json.Unmarshal(data, &values); err != nil {
+			return err
+		}
+		*e = values
+		return nil
+	}
+
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid base64 embedding: %w", err)
+	}
+	if len(raw)%4 != 0 {
+		return fmt.Errorf("invalid base64 embedding: %d bytes is not a multiple of 4", len(raw))
 	}
-	return result
+
+	values := make([]float32, len(raw)/4)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+	}
+	*e = values
+	return nil
 }
 
 // API types
 
 type embeddingRequest struct {
-	Model string   `json:"model"`
-	Input []string `json:"input"`
+	Model          string   `json:"model"`
+	EncodingFormat string   `json:"encoding_format,omitempty"`
+	User           string   `json:"user,omitempty"`
+	Input          []string `json:"input"`
+
+	extra map[string]any // Config.Extra, merged in by MarshalJSON
+}
+
+// MarshalJSON implements json.Marshaler. It merges Config.Extra here rather
+// than at each call site, so Batch files carry it too.
+func (r embeddingRequest) MarshalJSON() ([]byte, error) {
+	type plain embeddingRequest
+	return httputil.MarshalBody(plain(r), r.extra)
 }
 
 type embeddingResponse struct {
@@ -182,9 +483,9 @@ type embeddingResponse struct {
 }
 
 type embeddingData struct {
-	Object    string    `json:"object"`
-	Embedding []float64 `json:"embedding"`
-	Index     int       `json:"index"`
+	Object    string          `json:"object"`
+	Embedding embeddingValues `json:"embedding"`
+	Index     int             `json:"index"`
 }
 
 type usage struct {
@@ -2,10 +2,19 @@ package openai
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/zoobzio/vex"
 )
 
 func TestProvider_Name(t *testing.T) {
@@ -52,8 +61,8 @@ func TestProvider_Embed(t *testing.T) {
 			resp := embeddingResponse{
 				Object: "list",
 				Data: []embeddingData{
-					{Object: "embedding", Index: 0, Embedding: []float64{0.1, 0.2, 0.3}},
-					{Object: "embedding", Index: 1, Embedding: []float64{0.4, 0.5, 0.6}},
+					{Object: "embedding", Index: 0, Embedding: embeddingValues{0.1, 0.2, 0.3}},
+					{Object: "embedding", Index: 1, Embedding: embeddingValues{0.4, 0.5, 0.6}},
 				},
 				Model: "text-embedding-3-small",
 				Usage: usage{PromptTokens: 10, TotalTokens: 10},
@@ -116,13 +125,27 @@ func TestProvider_Embed(t *testing.T) {
 		})
 
 		_, err := p.Embed(context.Background(), []string{"test"})
-		if err == nil {
-			t.Error("expected error for invalid API key")
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected ProviderError, got %v", err)
+		}
+		if perr.Provider != "openai" || perr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected provider error fields: %+v", perr)
+		}
+		if perr.Message != "Invalid API key" {
+			t.Errorf("expected message 'Invalid API key', got %q", perr.Message)
+		}
+		if perr.Type != "invalid_request_error" {
+			t.Errorf("expected type 'invalid_request_error', got %q", perr.Type)
+		}
+		if perr.Retryable() {
+			t.Error("expected 401 to be non-retryable")
 		}
 	})
 
 	t.Run("handles rate limit error", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Retry-After", "20")
 			w.WriteHeader(http.StatusTooManyRequests)
 			//nolint:errcheck // test helper
 			json.NewEncoder(w).Encode(map[string]interface{}{
@@ -140,8 +163,15 @@ func TestProvider_Embed(t *testing.T) {
 		})
 
 		_, err := p.Embed(context.Background(), []string{"test"})
-		if err == nil {
-			t.Error("expected error for rate limit")
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected ProviderError, got %v", err)
+		}
+		if !perr.Retryable() {
+			t.Error("expected 429 to be retryable")
+		}
+		if perr.RetryAfter != 20*time.Second {
+			t.Errorf("expected RetryAfter 20s, got %v", perr.RetryAfter)
 		}
 	})
 
@@ -150,8 +180,8 @@ func TestProvider_Embed(t *testing.T) {
 			// Return vectors in reverse order
 			resp := embeddingResponse{
 				Data: []embeddingData{
-					{Index: 1, Embedding: []float64{0.4, 0.5}},
-					{Index: 0, Embedding: []float64{0.1, 0.2}},
+					{Index: 1, Embedding: embeddingValues{0.4, 0.5}},
+					{Index: 0, Embedding: embeddingValues{0.1, 0.2}},
 				},
 				Model: "test",
 			}
@@ -177,7 +207,7 @@ func TestProvider_Embed(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 			resp := embeddingResponse{
 				Data: []embeddingData{
-					{Index: 99, Embedding: []float64{0.1, 0.2}},
+					{Index: 99, Embedding: embeddingValues{0.1, 0.2}},
 				},
 				Model: "test",
 			}
@@ -197,7 +227,7 @@ func TestProvider_Embed(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 			resp := embeddingResponse{
 				Data: []embeddingData{
-					{Index: -1, Embedding: []float64{0.1, 0.2}},
+					{Index: -1, Embedding: embeddingValues{0.1, 0.2}},
 				},
 				Model: "test",
 			}
@@ -214,6 +244,313 @@ func TestProvider_Embed(t *testing.T) {
 	})
 }
 
+// encodeBase64 encodes values as base64 little-endian float32, as the API does.
+func encodeBase64(values []float32) string {
+	raw := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(v))
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestProvider_Embed_Base64(t *testing.T) {
+	t.Run("sends encoding format and decodes base64", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req embeddingRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			if req.EncodingFormat != "base64" {
+				t.Errorf("expected encoding_format 'base64', got %q", req.EncodingFormat)
+			}
+
+			//nolint:errcheck // test helper
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"object": "list",
+				"model":  "text-embedding-3-small",
+				"data": []map[string]interface{}{
+					{"object": "embedding", "index": 0, "embedding": encodeBase64([]float32{0.1, -0.2, 0.3})},
+					{"object": "embedding", "index": 1, "embedding": encodeBase64([]float32{1.5, 2.5, -3.5})},
+				},
+				"usage": map[string]int{"prompt_tokens": 4, "total_tokens": 4},
+			})
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test", BaseURL: server.URL, EncodingFormat: EncodingFormatBase64})
+		resp, err := p.Embed(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := [][]float32{{0.1, -0.2, 0.3}, {1.5, 2.5, -3.5}}
+		for i, vec := range resp.Vectors {
+			for j, v := range vec {
+				if v != expected[i][j] {
+					t.Errorf("vector %d[%d]: expected %f, got %f", i, j, expected[i][j], v)
+				}
+			}
+		}
+		if resp.Dimensions != 3 {
+			t.Errorf("expected 3 dimensions, got %d", resp.Dimensions)
+		}
+	})
+
+	t.Run("defaults to float encoding", func(t *testing.T) {
+		p := New(Config{APIKey: "test"})
+		if p.encodingFormat != EncodingFormatFloat {
+			t.Errorf("expected default encoding format 'float', got %q", p.encodingFormat)
+		}
+	})
+
+	t.Run("decodes known vector", func(t *testing.T) {
+		// 1.0, 2.0, -0.5 as little-endian float32.
+		var e embeddingValues
+		if err := json.Unmarshal([]byte(`"AACAPwAAAEAAAAC/"`), &e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(e) != 3 || e[0] != 1.0 || e[1] != 2.0 || e[2] != -0.5 {
+			t.Errorf("expected [1 2 -0.5], got %v", e)
+		}
+	})
+
+	t.Run("rejects malformed base64", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"data":[{"index":0,"embedding":"AAA"}]}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test", BaseURL: server.URL, EncodingFormat: EncodingFormatBase64})
+		_, err := p.Embed(context.Background(), []string{"a"})
+		if err == nil {
+			t.Error("expected error for malformed base64 embedding")
+		}
+	})
+}
+
+func BenchmarkEmbeddingDecode_Float(b *testing.B) {
+	values := make([]float32, 3072)
+	for i := range values {
+		values[i] = float32(i) / 3072.0
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var e embeddingValues
+		if err := json.Unmarshal(data, &e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEmbeddingDecode_Base64(b *testing.B) {
+	values := make([]float32, 3072)
+	for i := range values {
+		values[i] = float32(i) / 3072.0
+	}
+	data, err := json.Marshal(encodeBase64(values))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var e embeddingValues
+		if err := json.Unmarshal(data, &e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestProvider_Embed_SplitsBatches(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		sizes = append(sizes, len(req.Input))
+		if len(req.Input) > 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Encode each input's text as its vector so ordering can be checked
+		data := make([]embeddingData, len(req.Input))
+		for i, text := range req.Input {
+			data[i] = embeddingData{Index: i, Embedding: embeddingValues{float32(len(text))}}
+		}
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(embeddingResponse{
+			Data:  data,
+			Model: "text-embedding-3-small",
+			Usage: usage{PromptTokens: len(req.Input), TotalTokens: len(req.Input)},
+		})
+	}))
+	defer server.Close()
+
+	p := New(Config{APIKey: "test", BaseURL: server.URL, MaxBatchSize: 3})
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff", "ggggggg"}
+
+	resp, err := p.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sizes) != 3 {
+		t.Errorf("expected 3 sub-requests, got %v", sizes)
+	}
+	if len(resp.Vectors) != len(texts) {
+		t.Fatalf("expected %d vectors, got %d", len(texts), len(resp.Vectors))
+	}
+	for i, vec := range resp.Vectors {
+		if vec[0] != float32(i+1) {
+			t.Errorf("vector %d out of order: %v", i, vec)
+		}
+	}
+	if resp.Usage.PromptTokens != 7 || resp.Usage.TotalTokens != 7 {
+		t.Errorf("expected usage summed to 7, got %+v", resp.Usage)
+	}
+}
+
+func TestProvider_User(t *testing.T) {
+	t.Run("sends user when set", func(t *testing.T) {
+		var received map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			//nolint:errcheck // test helper
+			json.NewEncoder(w).Encode(embeddingResponse{
+				Data: []embeddingData{{Index: 0, Embedding: embeddingValues{0.1}}},
+			})
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test", BaseURL: server.URL, User: "tenant-1"})
+		if _, err := p.Embed(context.Background(), []string{"a"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if received["user"] != "tenant-1" {
+			t.Errorf("expected user 'tenant-1', got %v", received["user"])
+		}
+	})
+
+	t.Run("omits user when empty", func(t *testing.T) {
+		body, err := json.Marshal(embeddingRequest{Model: "m", Input: []string{"a"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := fields["user"]; ok {
+			t.Error("expected user to be omitted")
+		}
+	})
+
+	t.Run("WithUser does not mutate original", func(t *testing.T) {
+		base := New(Config{APIKey: "test", User: "base"})
+		tenant := base.WithUser("tenant-2")
+
+		if tenant.user != "tenant-2" {
+			t.Errorf("expected user 'tenant-2', got %q", tenant.user)
+		}
+		if base.user != "base" {
+			t.Errorf("expected original user 'base', got %q", base.user)
+		}
+	})
+}
+
+func TestProvider_Model(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+	if p.Model() != "text-embedding-3-small" {
+		t.Errorf("expected model 'text-embedding-3-small', got %q", p.Model())
+	}
+}
+
+// countingTransport records round trips and fails them without network access.
+type countingTransport struct {
+	calls int
+}
+
+var errIntercepted = errors.New("intercepted")
+
+func (c *countingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	c.calls++
+	return nil, errIntercepted
+}
+
+func TestConfig_HTTPClient(t *testing.T) {
+	t.Run("uses custom round tripper", func(t *testing.T) {
+		transport := &countingTransport{}
+		p := New(Config{
+			APIKey:     "test",
+			HTTPClient: &http.Client{Transport: transport},
+		})
+
+		_, err := p.Embed(context.Background(), []string{"test"})
+		if !errors.Is(err, errIntercepted) {
+			t.Errorf("expected intercepted error, got %v", err)
+		}
+		if transport.calls != 1 {
+			t.Errorf("expected 1 round trip, got %d", transport.calls)
+		}
+	})
+
+	t.Run("applies timeout when client has none", func(t *testing.T) {
+		p := New(Config{
+			APIKey:     "test",
+			Timeout:    5 * time.Second,
+			HTTPClient: &http.Client{},
+		})
+		if p.httpClient.Timeout != 5*time.Second {
+			t.Errorf("expected 5s timeout, got %v", p.httpClient.Timeout)
+		}
+	})
+}
+
+func TestConfig_Headers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Tenant-ID"); got != "acme" {
+			t.Errorf("expected custom header, got %q", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("expected custom header to override Content-Type, got %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("expected provider authentication to be kept, got %q", got)
+		}
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"data": [{"index": 0, "embedding": [0.1]}]}`))
+	}))
+	defer server.Close()
+
+	p := New(Config{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+		Headers: map[string]string{
+			"X-Tenant-ID":  "acme",
+			"Content-Type": "application/json; charset=utf-8",
+		},
+	})
+	if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestProvider_MaxTokens(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+	if p.MaxTokens() != 8191 {
+		t.Errorf("expected 8191 max tokens, got %d", p.MaxTokens())
+	}
+}
+
 func TestConfig_Defaults(t *testing.T) {
 	p := New(Config{APIKey: "test"})
 
@@ -223,4 +560,289 @@ func TestConfig_Defaults(t *testing.T) {
 	if p.baseURL != "https://api.openai.com/v1" {
 		t.Errorf("expected default base URL, got %q", p.baseURL)
 	}
+	if p.maxBatchSize != DefaultMaxBatchSize {
+		t.Errorf("expected default max batch size %d, got %d", DefaultMaxBatchSize, p.maxBatchSize)
+	}
+}
+
+func TestProvider_TruncateOverlong(t *testing.T) {
+	// Reject inputs the default estimator counts as over the model limit,
+	// mimicking the API's context-length error.
+	newServer := func(received *[]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req embeddingRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			*received = req.Input
+			for _, text := range req.Input {
+				if EstimateTokens(text) > MaxTokensTextEmbedding {
+					w.WriteHeader(http.StatusBadRequest)
+					//nolint:errcheck // test helper
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"error": map[string]string{
+							"message": "This model's maximum context length is 8191 tokens",
+							"type":    "invalid_request_error",
+						},
+					})
+					return
+				}
+			}
+			data := make([]embeddingData, len(req.Input))
+			for i := range req.Input {
+				data[i] = embeddingData{Index: i, Embedding: embeddingValues{0.1}}
+			}
+			//nolint:errcheck // test helper
+			json.NewEncoder(w).Encode(embeddingResponse{Data: data})
+		}))
+	}
+	overlong := strings.Repeat("word ", 10000)
+
+	t.Run("embeds overlong input when enabled", func(t *testing.T) {
+		var received []string
+		server := newServer(&received)
+		defer server.Close()
+
+		p := New(Config{APIKey: "test", BaseURL: server.URL, TruncateOverlong: true})
+		texts := []string{"short", overlong}
+		resp, err := p.Embed(context.Background(), texts)
+		if err != nil {
+			t.Fatalf("expected truncated input to embed, got: %v", err)
+		}
+		if resp.Truncated != 1 {
+			t.Errorf("expected 1 truncated input, got %d", resp.Truncated)
+		}
+		if received[0] != "short" || !strings.HasPrefix(overlong, received[1]) {
+			t.Error("expected inputs to be sent as prefixes of the originals")
+		}
+		if texts[1] != overlong {
+			t.Error("expected caller's slice to be left unmodified")
+		}
+	})
+
+	t.Run("fails clearly when disabled", func(t *testing.T) {
+		var received []string
+		server := newServer(&received)
+		defer server.Close()
+
+		p := New(Config{APIKey: "test", BaseURL: server.URL})
+		_, err := p.Embed(context.Background(), []string{overlong})
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) || perr.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected 400 ProviderError, got %v", err)
+		}
+		if !strings.Contains(perr.Message, "maximum context length") {
+			t.Errorf("expected context length message, got %q", perr.Message)
+		}
+	})
+
+	t.Run("uses custom token counter", func(t *testing.T) {
+		var received []string
+		server := newServer(&received)
+		defer server.Close()
+
+		bytes := func(text string) int { return len(text) }
+		p := New(Config{APIKey: "test", BaseURL: server.URL, TruncateOverlong: true, TokenCounter: bytes})
+		resp, err := p.Embed(context.Background(), []string{overlong})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Truncated != 1 {
+			t.Errorf("expected 1 truncated input, got %d", resp.Truncated)
+		}
+		if n := len(received[0]); n != MaxTokensTextEmbedding {
+			t.Errorf("expected %d bytes after truncation, got %d", MaxTokensTextEmbedding, n)
+		}
+	})
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if EstimateTokens("") != 0 {
+		t.Error("expected 0 tokens for empty text")
+	}
+	if EstimateTokens("abcd") != 2 {
+		t.Errorf("expected 2 tokens for 4 bytes, got %d", EstimateTokens("abcd"))
+	}
+}
+
+func TestProvider_RetryResendsBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+		bodies = append(bodies, string(body))
+
+		switch len(bodies) {
+		case 1:
+			// A 307 redirect must replay the body on the same attempt.
+			http.Redirect(w, r, r.URL.String(), http.StatusTemporaryRedirect)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"data":[{"index":0,"embedding":[0.6,0.8]}]}`)) //nolint:errcheck // test helper
+		}
+	}))
+	defer server.Close()
+
+	svc := vex.NewService(New(Config{APIKey: "test", BaseURL: server.URL}), vex.WithRetry(2))
+	if _, err := svc.Embed(context.Background(), "hello world"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(bodies))
+	}
+	for i, body := range bodies {
+		if body == "" || body != bodies[0] {
+			t.Errorf("request %d: expected full body %q, got %q", i, bodies[0], body)
+		}
+	}
+}
+
+func TestProvider_RedirectDroppingAuth(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"error": {"message": "You didn't provide an API key."}}`))
+		}
+	}))
+	defer target.Close()
+
+	// http:// base URL on one host permanently redirected to another, as a
+	// proxy enforcing a canonical host would.
+	crossHost := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, crossHost+r.URL.Path, http.StatusPermanentRedirect)
+	}))
+	defer origin.Close()
+
+	p := New(Config{APIKey: "test-key", BaseURL: origin.URL})
+	_, err := p.Embed(context.Background(), []string{"hello"})
+	if !errors.Is(err, vex.ErrRedirectAuthStripped) {
+		t.Fatalf("expected ErrRedirectAuthStripped, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "base URL") {
+		t.Errorf("expected error to point at the base URL, got %q", err)
+	}
+}
+
+func TestProvider_EmptyEmbedding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"model": "text-embedding-3-small", "data": [
+			{"index": 0, "embedding": [0.1, 0.2]},
+			{"index": 1, "embedding": []},
+			{"index": 2, "embedding": [0.3, 0.4]}
+		]}`))
+	}))
+	defer server.Close()
+
+	p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+	_, err := p.Embed(context.Background(), []string{"a", "b", "c"})
+	var emptyErr *vex.EmptyEmbeddingError
+	if !errors.As(err, &emptyErr) {
+		t.Fatalf("expected EmptyEmbeddingError, got %v", err)
+	}
+	if emptyErr.Index != 1 {
+		t.Errorf("expected index 1, got %d", emptyErr.Index)
+	}
+}
+
+func TestProvider_Extra(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var raw map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if raw["service_tier"] != "flex" {
+			t.Errorf("expected extra field service_tier, got %v", raw["service_tier"])
+		}
+		if raw["model"] != "text-embedding-3-small" {
+			t.Errorf("expected extra not to override model, got %v", raw["model"])
+		}
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"data": [{"index": 0, "embedding": [0.1]}]}`))
+	}))
+	defer server.Close()
+
+	p := New(Config{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+		Extra:   map[string]any{"service_tier": "flex", "model": "other"},
+	})
+	if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	line, err := json.Marshal(batchRequestLine{Body: p.newEmbeddingRequest([]string{"hello"})})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(line), `"service_tier":"flex"`) {
+		t.Errorf("expected batch request body to carry extra fields, got %s", line)
+	}
+}
+
+func TestProvider_Azure(t *testing.T) {
+	var gotPath, gotVersion, gotKey, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		gotAuth = r.Header.Get("Authorization")
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(embeddingResponse{
+			Data: []embeddingData{{Index: 0, Embedding: embeddingValues{0.1}}},
+		})
+	}))
+	defer server.Close()
+
+	t.Run("uses deployment URL and api-key header", func(t *testing.T) {
+		p := New(Config{
+			APIKey:  "azure-key",
+			BaseURL: server.URL,
+			Azure:   &AzureConfig{Deployment: "my-embeddings", APIVersion: "2024-06-01"},
+		})
+		if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotPath != "/openai/deployments/my-embeddings/embeddings" {
+			t.Errorf("unexpected path %q", gotPath)
+		}
+		if gotVersion != "2024-06-01" {
+			t.Errorf("expected api-version '2024-06-01', got %q", gotVersion)
+		}
+		if gotKey != "azure-key" {
+			t.Errorf("expected api-key header, got %q", gotKey)
+		}
+		if gotAuth != "" {
+			t.Errorf("expected no Authorization header, got %q", gotAuth)
+		}
+	})
+
+	t.Run("defaults api version", func(t *testing.T) {
+		p := New(Config{APIKey: "azure-key", BaseURL: server.URL, Azure: &AzureConfig{Deployment: "d"}})
+		if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotVersion != DefaultAzureAPIVersion {
+			t.Errorf("expected default api-version, got %q", gotVersion)
+		}
+	})
+
+	t.Run("standard path without Azure", func(t *testing.T) {
+		p := New(Config{APIKey: "openai-key", BaseURL: server.URL})
+		if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotPath != "/embeddings" || gotVersion != "" {
+			t.Errorf("unexpected path %q with api-version %q", gotPath, gotVersion)
+		}
+		if gotAuth != "Bearer openai-key" || gotKey != "" {
+			t.Errorf("expected Bearer auth only, got Authorization %q and api-key %q", gotAuth, gotKey)
+		}
+	})
 }
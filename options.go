@@ -1,6 +1,7 @@
 package vex
 
 import (
+	"context"
 	"time"
 
 	"github.com/zoobzio/pipz"
@@ -15,25 +16,73 @@ var (
 	rateLimitID      = pipz.NewIdentity("vex:rate-limit", "Rate limiting")
 	errorHandlerID   = pipz.NewIdentity("vex:error-handler", "Error handling")
 	fallbackID       = pipz.NewIdentity("vex:fallback", "Fallback alternatives")
+	hedgeID          = pipz.NewIdentity("vex:hedge", "Hedges slow embedding calls")
+	negativeCacheID  = pipz.NewIdentity("vex:negative-cache", "Short-circuits known-bad inputs")
+	retryTimeoutsID  = pipz.NewIdentity("vex:retry-timeouts", "Marks timed-out calls as retryable")
+	concurrencyID    = pipz.NewIdentity("vex:concurrency-limit", "Caps in-flight embedding calls")
+	jitterID         = pipz.NewIdentity("vex:jittered-backoff", "Retries with jittered exponential backoff")
+	spendLimitID     = pipz.NewIdentity("vex:spend-limit", "Caps tokens spent per window")
+	attemptTimeoutID = pipz.NewIdentity("vex:attempt-timeout", "Bounds each embedding attempt")
+	coalesceID       = pipz.NewIdentity("vex:coalesce", "Merges small concurrent requests")
 )
 
 // Option modifies a pipeline for reliability features.
 type Option func(pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest]
 
 // WithRetry adds retry logic to the pipeline.
-// Failed requests are retried up to maxAttempts times.
+// Failed requests are retried up to maxAttempts times. A ProviderError that
+// is not Retryable, such as invalid credentials or malformed input, is
+// returned without retrying, and a Retry-After sent with a retryable one is
+// waited out first. A call that timed out is not re-sent, since it may
+// have been processed and billed server-side; see WithRetryTimeouts.
 func WithRetry(maxAttempts int) Option {
 	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
-		return pipz.NewRetry(retryID, pipeline, maxAttempts)
+		return newRetry(retryID, pipeline, maxAttempts)
 	}
 }
 
 // WithBackoff adds retry logic with exponential backoff to the pipeline.
 // Failed requests are retried with increasing delays between attempts.
-// The delay starts at baseDelay and doubles after each failure.
+// The delay starts at baseDelay and doubles after each failure, or is the
+// provider's Retry-After if that is longer. Errors are classified as for
+// WithRetry, and timeouts are not retried unless enabled with
+// WithRetryTimeouts.
 func WithBackoff(maxAttempts int, baseDelay time.Duration) Option {
 	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
-		return pipz.NewBackoff(backoffID, pipeline, maxAttempts, baseDelay)
+		return newBackoff(backoffID, pipeline, maxAttempts, baseDelay)
+	}
+}
+
+// WithJitteredBackoff adds retry logic with exponential backoff and full
+// jitter to the pipeline. Before each retry it waits a random duration
+// between zero and baseDelay doubled per failed attempt, capped at maxDelay
+// (zero for no cap). Concurrent callers that fail together therefore retry
+// at different times instead of re-spiking the provider in lockstep; use
+// WithBackoff for deterministic delays. A provider's Retry-After overrides
+// a shorter jittered delay. Errors are classified as for WithRetry, and
+// timeouts are not retried unless enabled with WithRetryTimeouts.
+func WithJitteredBackoff(maxAttempts int, baseDelay, maxDelay time.Duration) Option {
+	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		return newJitteredBackoff(jitterID, pipeline, maxAttempts, baseDelay, maxDelay)
+	}
+}
+
+// WithRetryTimeouts controls whether calls that timed out may be re-sent by
+// WithRetry and WithBackoff. Timeouts are ambiguous: the provider may have
+// processed the request, so retrying can double-charge. By default they are
+// not retried; connection failures and error responses always are.
+func WithRetryTimeouts(enabled bool) Option {
+	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		// Retry stages listed before this option see the flag on the
+		// request; those listed after it see timeouts marked retryable.
+		return pipz.Apply(retryTimeoutsID, func(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+			req.retryTimeouts = enabled
+			out, err := pipeline.Process(ctx, req)
+			if enabled {
+				err = markRetryable(err)
+			}
+			return out, err
+		})
 	}
 }
 
@@ -45,6 +94,63 @@ func WithTimeout(duration time.Duration) Option {
 	}
 }
 
+// WithAttemptTimeout bounds each provider attempt at duration, where
+// WithTimeout bounds the whole request including retries. List it after a
+// retry option so it wraps the individual attempts, and combine it with an
+// outer WithTimeout for an overall budget:
+//
+//	NewService(p, WithTimeout(30*time.Second), WithRetry(3), WithAttemptTimeout(5*time.Second))
+//
+// Attempts it cuts off are retried even without WithRetryTimeouts, since
+// re-sending slow calls is its purpose; the abandoned call may still have
+// been processed and billed by the provider.
+func WithAttemptTimeout(duration time.Duration) Option {
+	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		return newAttemptTimeout(attemptTimeoutID, pipeline, duration)
+	}
+}
+
+// WithCoalescing merges requests of fewer than maxBatch texts that arrive
+// within window of each other, up to maxBatch texts in total, into a single
+// pass through the rest of the pipeline, and returns each caller its own
+// vectors and share of the usage. A request waits at most window before its
+// batch is sent. List it first so retries and limits apply to the merged
+// request. If the merged request is rejected with a non-retryable
+// ProviderError, typically caused by one bad input, each caller's request is
+// re-sent alone so that only the offending caller fails. Requests with
+// titles, tags (see WithTags) or a WithProviderOverride are not merged. The
+// merged request carries its own request ID, which is what retries and the
+// provider's X-Request-ID header see; callers' hooks keep their own IDs. It
+// runs until every caller has given up, within the latest caller deadline.
+// See MicroBatchingProvider for the provider-level equivalent.
+func WithCoalescing(window time.Duration, maxBatch int) Option {
+	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		return newCoalescer(coalesceID, pipeline, window, maxBatch)
+	}
+}
+
+// WithHedge adds hedged requests to the pipeline to cut tail latency.
+// If a call has not returned within delay, an identical second call is sent
+// and the first to succeed wins; the other is canceled via its context.
+// Only the winner's usage is reported, though both calls count as provider calls.
+func WithHedge(delay time.Duration) Option {
+	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		return newHedge(hedgeID, pipeline, delay)
+	}
+}
+
+// WithNegativeCache remembers requests rejected with a non-retryable
+// ProviderError (e.g. input too long) for ttl and fails identical requests
+// immediately with the cached error instead of calling the provider again.
+// Requests are matched on their exact texts, so prefer small batches when
+// individual inputs are suspect. List it after retry options so that retries
+// of a rejected request are short-circuited too.
+func WithNegativeCache(ttl time.Duration) Option {
+	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		return newNegativeCache(negativeCacheID, pipeline, ttl)
+	}
+}
+
 // WithCircuitBreaker adds circuit breaker protection to the pipeline.
 // After 'failures' consecutive failures, the circuit opens for 'recovery' duration.
 func WithCircuitBreaker(failures int, recovery time.Duration) Option {
@@ -61,6 +167,33 @@ func WithRateLimit(rps float64, burst int) Option {
 	}
 }
 
+// WithConcurrencyLimit caps the number of requests in flight through the
+// pipeline at n, across all goroutines sharing the service. Requests beyond
+// the limit wait for a free slot or until their context is done. Unlike
+// WithRateLimit, which governs throughput, this bounds simultaneous calls.
+// Retries and timeouts listed after it run within the caller's slot.
+func WithConcurrencyLimit(n int) Option {
+	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		return newConcurrencyLimit(concurrencyID, pipeline, n)
+	}
+}
+
+// WithSpendLimit caps the provider-reported TotalTokens spent within any
+// trailing window at maxTokens, as a guard against runaway loops. Requests
+// that would exceed it fail with ErrSpendLimitExceeded until older usage
+// slides out of the window. Usage is only known once a response arrives, so
+// requests in flight count at an estimate of four bytes per token, which
+// keeps concurrent bursts from overshooting by much; a single request
+// estimated above maxTokens is never admitted. Responses without reported
+// usage, such as Gemini's, are charged the estimate. The budget is shared by a
+// service's document and query pipelines and shown in Service.Stats.
+func WithSpendLimit(maxTokens int64, window time.Duration) Option {
+	budget := newSpendBudget(maxTokens, window)
+	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		return newSpendLimit(spendLimitID, pipeline, budget)
+	}
+}
+
 // WithErrorHandler adds error handling to the pipeline.
 // The error handler receives error context and can process/log/alert as needed.
 func WithErrorHandler(handler pipz.Chainable[*pipz.Error[*EmbedRequest]]) Option {
@@ -75,9 +208,14 @@ type ServiceProvider interface {
 }
 
 // WithFallback adds a fallback service for resilience.
-// If the primary fails, the fallback will be tried.
+// If the primary fails, the fallback will be tried, including after rate
+// limits and timeouts from options listed after this one. It is skipped
+// when the caller's context is canceled or past its deadline.
+//
+// The fallback should produce vectors of the primary's dimensionality; check
+// with AssertCompatibleDimensions when building the services.
 func WithFallback(fallback ServiceProvider) Option {
 	return func(pipeline pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
-		return pipz.NewFallback(fallbackID, pipeline, fallback.GetPipeline())
+		return newFallback(fallbackID, pipeline, fallback.GetPipeline())
 	}
 }
@@ -3,6 +3,15 @@ package vex
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -44,6 +53,194 @@ func TestWithRetry(t *testing.T) {
 			t.Error("expected error after max retries")
 		}
 	})
+
+	t.Run("does not retry non-retryable provider errors", func(t *testing.T) {
+		for name, opt := range map[string]Option{
+			"retry":    WithRetry(3),
+			"backoff":  WithBackoff(3, time.Millisecond),
+			"jittered": WithJitteredBackoff(3, time.Millisecond, time.Millisecond),
+		} {
+			provider := &erroringProvider{err: &ProviderError{Provider: "erroring", StatusCode: 401}}
+			svc := NewService(provider, opt)
+
+			var perr *ProviderError
+			if _, err := svc.Embed(context.Background(), "test"); !errors.As(err, &perr) || perr.StatusCode != 401 {
+				t.Errorf("%s: expected the 401, got %v", name, err)
+			}
+			if provider.calls != 1 {
+				t.Errorf("%s: expected 1 call, got %d", name, provider.calls)
+			}
+		}
+	})
+
+	t.Run("waits for Retry-After", func(t *testing.T) {
+		provider := &erroringProvider{err: &ProviderError{Provider: "erroring", StatusCode: 429, RetryAfter: 50 * time.Millisecond}}
+		svc := NewService(provider, WithRetry(2))
+
+		start := time.Now()
+		svc.Embed(context.Background(), "test") //nolint:errcheck // failure expected
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("expected the retry to wait for Retry-After, took %v", elapsed)
+		}
+		if provider.calls != 2 {
+			t.Errorf("expected 2 calls, got %d", provider.calls)
+		}
+	})
+
+	t.Run("gives up when Retry-After outlasts the deadline", func(t *testing.T) {
+		provider := &erroringProvider{err: &ProviderError{Provider: "erroring", StatusCode: 503, RetryAfter: time.Hour}}
+		svc := NewService(provider, WithBackoff(3, time.Millisecond))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		start := time.Now()
+		var perr *ProviderError
+		if _, err := svc.Embed(ctx, "test"); !errors.As(err, &perr) || perr.StatusCode != 503 {
+			t.Errorf("expected the 503, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("expected to give up without waiting, took %v", elapsed)
+		}
+		if provider.calls != 1 {
+			t.Errorf("expected 1 call, got %d", provider.calls)
+		}
+	})
+}
+
+// timeoutNetError is a net.Error reporting a timeout, as http.Client returns.
+type timeoutNetError struct{}
+
+func (timeoutNetError) Error() string   { return "i/o timeout" }
+func (timeoutNetError) Timeout() bool   { return true }
+func (timeoutNetError) Temporary() bool { return true }
+
+// erroringProvider fails every call with err.
+type erroringProvider struct {
+	err   error
+	calls int
+}
+
+func (*erroringProvider) Name() string    { return "erroring" }
+func (*erroringProvider) Dimensions() int { return 4 }
+func (p *erroringProvider) Embed(_ context.Context, _ []string) (*EmbeddingResponse, error) {
+	p.calls++
+	return nil, p.err
+}
+
+func TestWithRetryTimeouts(t *testing.T) {
+	t.Run("timeouts are not retried by default", func(t *testing.T) {
+		provider := &erroringProvider{err: &url.Error{Op: "Post", URL: "https://api", Err: timeoutNetError{}}}
+		svc := NewService(provider, WithRetry(3))
+
+		if _, err := svc.Embed(context.Background(), "test"); err == nil {
+			t.Fatal("expected error")
+		}
+		if provider.calls != 1 {
+			t.Errorf("expected 1 call, got %d", provider.calls)
+		}
+	})
+
+	t.Run("deadline exceeded is not retried by default", func(t *testing.T) {
+		provider := &erroringProvider{err: fmt.Errorf("request failed: %w", context.DeadlineExceeded)}
+		svc := NewService(provider, WithBackoff(3, time.Millisecond))
+
+		svc.Embed(context.Background(), "test") //nolint:errcheck // failure expected
+		if provider.calls != 1 {
+			t.Errorf("expected 1 call, got %d", provider.calls)
+		}
+	})
+
+	t.Run("backoff does not wait after a timeout", func(t *testing.T) {
+		for name, opt := range map[string]Option{
+			"backoff":  WithBackoff(4, 200*time.Millisecond),
+			"jittered": WithJitteredBackoff(4, 200*time.Millisecond, time.Second),
+		} {
+			provider := &erroringProvider{err: timeoutNetError{}}
+			svc := NewService(provider, opt)
+
+			start := time.Now()
+			svc.Embed(context.Background(), "test") //nolint:errcheck // failure expected
+			if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+				t.Errorf("%s: expected the timeout to be returned at once, took %v", name, elapsed)
+			}
+			if provider.calls != 1 {
+				t.Errorf("%s: expected 1 call, got %d", name, provider.calls)
+			}
+		}
+	})
+
+	t.Run("abandoned calls are not retried", func(t *testing.T) {
+		provider := &lateProvider{}
+		svc := NewService(provider, WithRetry(3), WithTimeout(10*time.Millisecond))
+
+		if _, err := svc.Embed(context.Background(), "test"); err == nil {
+			t.Fatal("expected timeout error")
+		}
+		time.Sleep(20 * time.Millisecond) // let the abandoned call finish
+		if got := provider.calls.Load(); got != 1 {
+			t.Errorf("expected 1 call, got %d", got)
+		}
+	})
+
+	t.Run("abandoned calls are retried when enabled", func(t *testing.T) {
+		provider := &lateProvider{}
+		svc := NewService(provider, WithRetry(3), WithRetryTimeouts(true), WithTimeout(10*time.Millisecond))
+
+		svc.Embed(context.Background(), "test") //nolint:errcheck // failure expected
+		time.Sleep(20 * time.Millisecond)
+		if got := provider.calls.Load(); got != 3 {
+			t.Errorf("expected 3 calls, got %d", got)
+		}
+	})
+
+	t.Run("connection refused is retried", func(t *testing.T) {
+		provider := &erroringProvider{err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+		svc := NewService(provider, WithRetry(3))
+
+		svc.Embed(context.Background(), "test") //nolint:errcheck // failure expected
+		if provider.calls != 3 {
+			t.Errorf("expected 3 calls, got %d", provider.calls)
+		}
+	})
+
+	t.Run("timeouts are retried when enabled", func(t *testing.T) {
+		for _, opts := range [][]Option{
+			{WithRetry(3), WithRetryTimeouts(true)},
+			{WithRetryTimeouts(true), WithRetry(3)},
+		} {
+			provider := &erroringProvider{err: timeoutNetError{}}
+			svc := NewService(provider, opts...)
+
+			svc.Embed(context.Background(), "test") //nolint:errcheck // failure expected
+			if provider.calls != 3 {
+				t.Errorf("expected 3 calls, got %d", provider.calls)
+			}
+		}
+	})
+
+	t.Run("fallback still receives timed-out request", func(t *testing.T) {
+		primary := &erroringProvider{err: timeoutNetError{}}
+		svc := NewService(primary, WithFallback(NewService(newMockProvider(4))))
+
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Errorf("expected fallback to succeed, got: %v", err)
+		}
+	})
+}
+
+// lateProvider blocks until its context is done and returns a little
+// later, as an HTTP client does, so a WithTimeout stage abandons it.
+type lateProvider struct {
+	calls atomic.Int32
+}
+
+func (*lateProvider) Name() string    { return "late" }
+func (*lateProvider) Dimensions() int { return 4 }
+func (p *lateProvider) Embed(ctx context.Context, _ []string) (*EmbeddingResponse, error) {
+	p.calls.Add(1)
+	<-ctx.Done()
+	time.Sleep(5 * time.Millisecond)
+	return nil, ctx.Err()
 }
 
 type retryTestProvider struct {
@@ -96,6 +293,53 @@ func TestWithTimeout(t *testing.T) {
 	})
 }
 
+// slowFirstProvider stalls its first call until canceled and answers later
+// calls immediately.
+type slowFirstProvider struct {
+	calls atomic.Int32
+}
+
+func (*slowFirstProvider) Name() string    { return "slow-first" }
+func (*slowFirstProvider) Dimensions() int { return 4 }
+func (p *slowFirstProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	if p.calls.Add(1) == 1 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	vecs := make([]Vector, len(texts))
+	for i := range vecs {
+		vecs[i] = Vector{1, 0, 0, 0}
+	}
+	return &EmbeddingResponse{Vectors: vecs, Dimensions: 4}, nil
+}
+
+func TestWithAttemptTimeout(t *testing.T) {
+	t.Run("retries an attempt that timed out", func(t *testing.T) {
+		provider := &slowFirstProvider{}
+		svc := NewService(provider, WithTimeout(time.Second), WithRetry(2), WithAttemptTimeout(20*time.Millisecond))
+
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Fatalf("expected second attempt to succeed, got %v", err)
+		}
+		if provider.calls.Load() != 2 {
+			t.Errorf("expected 2 calls, got %d", provider.calls.Load())
+		}
+	})
+
+	t.Run("overall timeout still bounds retries", func(t *testing.T) {
+		provider := &slowProvider{delay: time.Second, dims: 4}
+		svc := NewService(provider, WithTimeout(60*time.Millisecond), WithRetry(10), WithAttemptTimeout(25*time.Millisecond))
+
+		start := time.Now()
+		if _, err := svc.Embed(context.Background(), "test"); err == nil {
+			t.Fatal("expected timeout error")
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("expected overall timeout to stop retries, took %v", elapsed)
+		}
+	})
+}
+
 type slowProvider struct {
 	delay time.Duration
 	dims  int
@@ -164,6 +408,87 @@ func TestWithBackoff(t *testing.T) {
 	})
 }
 
+func TestWithJitteredBackoff(t *testing.T) {
+	t.Run("retries until success", func(t *testing.T) {
+		provider := &retryTestProvider{failUntil: 2, dims: 8}
+		svc := NewService(provider, WithJitteredBackoff(3, 10*time.Millisecond, 20*time.Millisecond))
+
+		start := time.Now()
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Fatalf("expected success, got: %v", err)
+		}
+		if provider.calls != 3 {
+			t.Errorf("expected 3 calls, got %d", provider.calls)
+		}
+		// Two delays of at most 10ms and 20ms.
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("delays exceeded their caps, elapsed: %v", elapsed)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		provider := &erroringProvider{err: errors.New("down")}
+		svc := NewService(provider, WithJitteredBackoff(3, time.Millisecond, time.Millisecond))
+
+		if _, err := svc.Embed(context.Background(), "test"); err == nil {
+			t.Fatal("expected error")
+		}
+		if provider.calls != 3 {
+			t.Errorf("expected 3 calls, got %d", provider.calls)
+		}
+	})
+
+	t.Run("honors context while waiting", func(t *testing.T) {
+		provider := &erroringProvider{err: errors.New("down")}
+		svc := NewService(provider, WithJitteredBackoff(5, time.Hour, time.Hour))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := svc.Embed(ctx, "test"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+		if provider.calls != 1 {
+			t.Errorf("expected 1 call, got %d", provider.calls)
+		}
+	})
+}
+
+func TestJitteredBackoff_Delay(t *testing.T) {
+	b := newJitteredBackoff(jitterID, nil, 10, 10*time.Millisecond, 50*time.Millisecond)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for attempt, ceiling := range []time.Duration{10, 20, 40, 50, 50} {
+		ceiling *= time.Millisecond
+		seen := make(map[time.Duration]bool)
+		for i := 0; i < 100; i++ {
+			d := b.delay(attempt, rng)
+			if d < 0 || d >= ceiling {
+				t.Fatalf("attempt %d: delay %v outside [0, %v)", attempt, d, ceiling)
+			}
+			seen[d] = true
+		}
+		if len(seen) < 50 {
+			t.Errorf("attempt %d: expected jittered delays, got %d distinct values", attempt, len(seen))
+		}
+	}
+
+	if d := b.delay(100, rng); d >= 50*time.Millisecond {
+		t.Errorf("expected large attempts capped at maxDelay, got %v", d)
+	}
+
+	// A second doubled 40 times overflows without saturation.
+	uncapped := newJitteredBackoff(jitterID, nil, 100, time.Second, 0)
+	for _, attempt := range []int{40, 62, 63, 100} {
+		if d := uncapped.delay(attempt, rng); d <= time.Hour {
+			t.Errorf("attempt %d: expected a saturated delay, got %v", attempt, d)
+		}
+	}
+	fixed := newBackoff(backoffID, nil, 100, time.Second)
+	if d := fixed.delay(40, rng); d != math.MaxInt64 {
+		t.Errorf("expected backoff to saturate, got %v", d)
+	}
+}
+
 func TestWithCircuitBreaker(t *testing.T) {
 	t.Run("opens after failures", func(_ *testing.T) {
 		provider := &retryTestProvider{
@@ -216,3 +541,426 @@ func TestWithFallback(t *testing.T) {
 		}
 	})
 }
+
+func TestWithFallback_ErrorOrigin(t *testing.T) {
+	t.Run("skips fallback when caller cancels", func(t *testing.T) {
+		primary := &hedgeTestProvider{stall: true, canceled: make(chan struct{})}
+		backup := newMockProvider(4)
+		svc := NewService(primary, WithFallback(NewService(backup)))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			for primary.calls.Load() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			cancel()
+		}()
+
+		if _, err := svc.Embed(ctx, "test"); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if backup.callCount != 0 {
+			t.Errorf("expected no fallback call, got %d", backup.callCount)
+		}
+	})
+
+	t.Run("falls back on per-attempt timeout", func(t *testing.T) {
+		primary := &slowProvider{delay: 500 * time.Millisecond, dims: 4}
+		backup := newMockProvider(4)
+		svc := NewService(primary, WithFallback(NewService(backup)), WithTimeout(20*time.Millisecond))
+
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Fatalf("expected fallback to succeed, got %v", err)
+		}
+		if backup.callCount != 1 {
+			t.Errorf("expected 1 fallback call, got %d", backup.callCount)
+		}
+	})
+
+	t.Run("falls back on rate limit", func(t *testing.T) {
+		primary := &rejectingProvider{status: http.StatusTooManyRequests}
+		backup := newMockProvider(4)
+		svc := NewService(primary, WithFallback(NewService(backup)))
+
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Fatalf("expected fallback to succeed, got %v", err)
+		}
+		if backup.callCount != 1 {
+			t.Errorf("expected 1 fallback call, got %d", backup.callCount)
+		}
+	})
+}
+
+// hedgeTestProvider stalls its first call until canceled; later calls return immediately.
+type hedgeTestProvider struct {
+	calls    atomic.Int32
+	canceled chan struct{}
+	stall    bool
+}
+
+func (*hedgeTestProvider) Name() string    { return "hedge-test" }
+func (*hedgeTestProvider) Dimensions() int { return 4 }
+func (p *hedgeTestProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	if p.calls.Add(1) == 1 && p.stall {
+		<-ctx.Done()
+		close(p.canceled)
+		return nil, ctx.Err()
+	}
+	vectors := make([]Vector, len(texts))
+	for i := range vectors {
+		vectors[i] = Vector{1, 0, 0, 0}
+	}
+	return &EmbeddingResponse{
+		Vectors:    vectors,
+		Model:      "hedge-model",
+		Dimensions: 4,
+		Usage:      Usage{PromptTokens: 5 * len(texts), TotalTokens: 5 * len(texts)},
+	}, nil
+}
+
+func TestWithHedge(t *testing.T) {
+	t.Run("hedges slow call and cancels loser", func(t *testing.T) {
+		provider := &hedgeTestProvider{stall: true, canceled: make(chan struct{})}
+		svc := NewService(provider, WithHedge(10*time.Millisecond))
+
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Fatalf("expected hedged call to succeed, got: %v", err)
+		}
+		if provider.calls.Load() != 2 {
+			t.Errorf("expected 2 calls, got %d", provider.calls.Load())
+		}
+
+		select {
+		case <-provider.canceled:
+		case <-time.After(time.Second):
+			t.Fatal("expected stalled call to be canceled")
+		}
+
+		stats := svc.Stats()
+		if stats.PromptTokens != 5 {
+			t.Errorf("expected usage from winner only (5 tokens), got %d", stats.PromptTokens)
+		}
+	})
+
+	t.Run("does not hedge fast call", func(t *testing.T) {
+		provider := &hedgeTestProvider{}
+		svc := NewService(provider, WithHedge(time.Second))
+
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if provider.calls.Load() != 1 {
+			t.Errorf("expected 1 call, got %d", provider.calls.Load())
+		}
+	})
+
+	t.Run("does not hedge fast failure", func(t *testing.T) {
+		provider := &retryTestProvider{failUntil: 100, dims: 4}
+		svc := NewService(provider, WithHedge(time.Second))
+
+		if _, err := svc.Embed(context.Background(), "test"); err == nil {
+			t.Error("expected error")
+		}
+		if provider.calls != 1 {
+			t.Errorf("expected 1 call, got %d", provider.calls)
+		}
+	})
+
+	t.Run("propagates context cancellation", func(t *testing.T) {
+		provider := &hedgeTestProvider{stall: true, canceled: make(chan struct{})}
+		svc := NewService(provider, WithHedge(time.Second))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if _, err := svc.Embed(ctx, "test"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got: %v", err)
+		}
+	})
+}
+
+// rejectingProvider fails every call with a fixed status code.
+type rejectingProvider struct {
+	calls  atomic.Int32
+	status int
+}
+
+func (*rejectingProvider) Name() string    { return "rejecting" }
+func (*rejectingProvider) Dimensions() int { return 4 }
+func (p *rejectingProvider) Embed(_ context.Context, _ []string) (*EmbeddingResponse, error) {
+	p.calls.Add(1)
+	return nil, &ProviderError{Provider: "rejecting", StatusCode: p.status, Message: "rejected"}
+}
+
+func TestWithNegativeCache(t *testing.T) {
+	t.Run("does not resend permanently failing input within TTL", func(t *testing.T) {
+		provider := &rejectingProvider{status: 400}
+		svc := NewService(provider, WithNegativeCache(time.Minute))
+
+		for i := 0; i < 3; i++ {
+			_, err := svc.Embed(context.Background(), "bad input")
+			var perr *ProviderError
+			if !errors.As(err, &perr) || perr.StatusCode != 400 {
+				t.Fatalf("attempt %d: expected cached ProviderError, got %v", i, err)
+			}
+		}
+		if provider.calls.Load() != 1 {
+			t.Errorf("expected 1 provider call, got %d", provider.calls.Load())
+		}
+
+		if _, err := svc.Embed(context.Background(), "other input"); err == nil {
+			t.Error("expected error for other input")
+		}
+		if provider.calls.Load() != 2 {
+			t.Errorf("expected other input to reach provider, got %d calls", provider.calls.Load())
+		}
+	})
+
+	t.Run("resends after TTL expires", func(t *testing.T) {
+		provider := &rejectingProvider{status: 400}
+		svc := NewService(provider, WithNegativeCache(time.Minute))
+
+		now := time.Now()
+		cache := svc.pipes.Load().document.(*negativeCache)
+		cache.now = func() time.Time { return now }
+
+		svc.Embed(context.Background(), "bad input") //nolint:errcheck // failure expected
+		now = now.Add(2 * time.Minute)
+		svc.Embed(context.Background(), "bad input") //nolint:errcheck // failure expected
+
+		if provider.calls.Load() != 2 {
+			t.Errorf("expected 2 provider calls, got %d", provider.calls.Load())
+		}
+	})
+
+	t.Run("does not cache retryable errors", func(t *testing.T) {
+		provider := &rejectingProvider{status: 503}
+		svc := NewService(provider, WithNegativeCache(time.Minute))
+
+		svc.Embed(context.Background(), "input") //nolint:errcheck // failure expected
+		svc.Embed(context.Background(), "input") //nolint:errcheck // failure expected
+
+		if provider.calls.Load() != 2 {
+			t.Errorf("expected 2 provider calls, got %d", provider.calls.Load())
+		}
+	})
+}
+
+func TestNegativeCacheKey(t *testing.T) {
+	if negativeCacheKey([]string{"ab", "c"}) == negativeCacheKey([]string{"a", "bc"}) {
+		t.Error("expected different splits to produce different keys")
+	}
+}
+
+// countingProvider tracks the peak number of concurrent Embed calls.
+type countingProvider struct {
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (*countingProvider) Name() string    { return "counting" }
+func (*countingProvider) Dimensions() int { return 4 }
+func (p *countingProvider) Embed(_ context.Context, texts []string) (*EmbeddingResponse, error) {
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	vectors := make([]Vector, len(texts))
+	for i := range vectors {
+		vectors[i] = Vector{1, 0, 0, 0}
+	}
+	return &EmbeddingResponse{Vectors: vectors, Dimensions: 4}, nil
+}
+
+func TestWithConcurrencyLimit(t *testing.T) {
+	t.Run("caps in-flight calls", func(t *testing.T) {
+		provider := &countingProvider{}
+		svc := NewService(provider, WithConcurrencyLimit(3))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := svc.Embed(context.Background(), "text"); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if peak := provider.peak.Load(); peak > 3 {
+			t.Errorf("expected at most 3 concurrent calls, got %d", peak)
+		}
+		if provider.peak.Load() < 2 {
+			t.Errorf("expected calls to overlap, peak was %d", provider.peak.Load())
+		}
+	})
+
+	t.Run("honors context while waiting for a slot", func(t *testing.T) {
+		provider := &hedgeTestProvider{stall: true, canceled: make(chan struct{})}
+		svc := NewService(provider, WithConcurrencyLimit(1))
+
+		holdCtx, release := context.WithCancel(context.Background())
+		go svc.Embed(holdCtx, "holder") //nolint:errcheck // canceled below
+		for provider.calls.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := svc.Embed(ctx, "waiter"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+		if provider.calls.Load() != 1 {
+			t.Errorf("expected waiter not to reach provider, got %d calls", provider.calls.Load())
+		}
+
+		release()
+		<-provider.canceled
+	})
+
+	t.Run("composes with retry", func(t *testing.T) {
+		provider := &retryTestProvider{failUntil: 1, dims: 4}
+		svc := NewService(provider, WithConcurrencyLimit(1), WithRetry(3))
+
+		if _, err := svc.Embed(context.Background(), "text"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if provider.calls != 2 {
+			t.Errorf("expected 2 calls within one slot, got %d", provider.calls)
+		}
+	})
+}
+
+// usageProvider reports a scripted TotalTokens per call and can hold calls
+// until released.
+type usageProvider struct {
+	tokens  int
+	calls   atomic.Int32
+	hold    chan struct{}
+	started chan struct{}
+}
+
+func (*usageProvider) Name() string    { return "usage" }
+func (*usageProvider) Dimensions() int { return 4 }
+func (p *usageProvider) Embed(_ context.Context, texts []string) (*EmbeddingResponse, error) {
+	p.calls.Add(1)
+	if p.hold != nil {
+		p.started <- struct{}{}
+		<-p.hold
+	}
+	vectors := make([]Vector, len(texts))
+	for i := range vectors {
+		vectors[i] = Vector{1, 0, 0, 0}
+	}
+	return &EmbeddingResponse{
+		Vectors:    vectors,
+		Dimensions: 4,
+		Usage:      Usage{PromptTokens: p.tokens, TotalTokens: p.tokens},
+	}, nil
+}
+
+func TestWithSpendLimit(t *testing.T) {
+	t.Run("rejects once reported usage reaches the limit", func(t *testing.T) {
+		provider := &usageProvider{tokens: 40}
+		svc := NewService(provider, WithSpendLimit(100, time.Minute))
+		budget := svc.pipes.Load().document.(*spendLimit).budget
+		now := time.Now()
+		budget.now = func() time.Time { return now }
+
+		// A loop over batches crosses the limit on its fourth batch.
+		batches := [][]string{{"a", "b"}, {"c"}, {"d", "e"}, {"f"}, {"g"}}
+		var succeeded int
+		var err error
+		for _, batch := range batches {
+			if _, err = svc.Batch(context.Background(), batch); err != nil {
+				break
+			}
+			succeeded++
+		}
+		if succeeded != 3 || !errors.Is(err, ErrSpendLimitExceeded) {
+			t.Fatalf("expected 3 batches then ErrSpendLimitExceeded, got %d and %v", succeeded, err)
+		}
+		if provider.calls.Load() != 3 {
+			t.Errorf("expected rejected batch not to reach provider, got %d calls", provider.calls.Load())
+		}
+		if got := svc.Stats().SpendWindowTokens; got != 120 {
+			t.Errorf("expected 120 tokens in window, got %d", got)
+		}
+
+		now = now.Add(time.Minute + time.Second)
+		if _, err := svc.Embed(context.Background(), "h"); err != nil {
+			t.Errorf("expected request to pass after window slides, got %v", err)
+		}
+		if got := svc.Stats().SpendWindowTokens; got != 40 {
+			t.Errorf("expected 40 tokens in window, got %d", got)
+		}
+	})
+
+	t.Run("counts in-flight estimates", func(t *testing.T) {
+		provider := &usageProvider{tokens: 1, hold: make(chan struct{}), started: make(chan struct{}, 5)}
+		svc := NewService(provider, WithSpendLimit(10, time.Minute))
+		text := "sixteen byte str" // estimated at 4 tokens
+
+		errs := make(chan error, 5)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := svc.Embed(context.Background(), text)
+				errs <- err
+			}()
+			<-provider.started
+		}
+		if got := svc.Stats().SpendWindowTokens; got != 8 {
+			t.Errorf("expected 8 reserved tokens, got %d", got)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := svc.Embed(context.Background(), text); !errors.Is(err, ErrSpendLimitExceeded) {
+				t.Errorf("expected burst request to be rejected, got %v", err)
+			}
+		}
+
+		close(provider.hold)
+		for i := 0; i < 2; i++ {
+			if err := <-errs; err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+		if got := svc.Stats().SpendWindowTokens; got != 2 {
+			t.Errorf("expected reservations replaced by reported usage, got %d", got)
+		}
+	})
+
+	t.Run("charges the estimate when usage is not reported", func(t *testing.T) {
+		svc := NewService(&usageProvider{}, WithSpendLimit(10, time.Minute))
+		text := "sixteen byte str" // estimated at 4 tokens
+
+		for i := 0; i < 2; i++ {
+			if _, err := svc.Embed(context.Background(), text); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if got := svc.Stats().SpendWindowTokens; got != 8 {
+			t.Errorf("expected estimates recorded as spend, got %d", got)
+		}
+		if _, err := svc.Embed(context.Background(), text); !errors.Is(err, ErrSpendLimitExceeded) {
+			t.Errorf("expected ErrSpendLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("shares the budget with queries", func(t *testing.T) {
+		svc := NewService(newMockQueryProvider(4), WithSpendLimit(6, time.Minute)) // the mock reports 5 tokens per text
+		if _, err := svc.Embed(context.Background(), "document"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := svc.EmbedQuery(context.Background(), "query"); !errors.Is(err, ErrSpendLimitExceeded) {
+			t.Errorf("expected query to be rejected by shared budget, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrIncompatibleServices is returned when ingest and query services would
+// produce vectors that cannot be meaningfully compared.
+var ErrIncompatibleServices = errors.New("incompatible ingest and query services")
+
+// RetrievalProfile pairs an ingest service with a query service and guarantees
+// both produce comparable vectors (same provider, models, dimensions,
+// normalization, and chunking).
+type RetrievalProfile struct {
+	ingest *Service
+	query  *Service
+}
+
+// NewRetrievalProfile creates a RetrievalProfile, validating that ingest and
+// query are compatible.
+func NewRetrievalProfile(ingest, query *Service) (*RetrievalProfile, error) {
+	if ingest == nil || query == nil {
+		return nil, fmt.Errorf("%w: services must not be nil", ErrIncompatibleServices)
+	}
+	p := &RetrievalProfile{ingest: ingest, query: query}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks that the ingest and query services are still compatible.
+// Services are mutable via their builder methods, so compatibility is
+// re-checked before every operation rather than only at construction.
+func (p *RetrievalProfile) Validate() error {
+	ingestName, queryName := p.ingest.provider.Name(), p.query.provider.Name()
+	if ingestName != queryName {
+		return fmt.Errorf("%w: provider %q vs %q", ErrIncompatibleServices, ingestName, queryName)
+	}
+	if p.ingest.Dimensions() != p.query.Dimensions() {
+		return fmt.Errorf("%w: dimensions %d vs %d", ErrIncompatibleServices, p.ingest.Dimensions(), p.query.Dimensions())
+	}
+	if p.ingest.normalize != p.query.normalize {
+		return fmt.Errorf("%w: normalization %t vs %t", ErrIncompatibleServices, p.ingest.normalize, p.query.normalize)
+	}
+	ingest, query := p.ingest.FingerprintDetails(), p.query.FingerprintDetails()
+	if ingest.Model != query.Model {
+		return fmt.Errorf("%w: model %q vs %q", ErrIncompatibleServices, ingest.Model, query.Model)
+	}
+	if ingest.QueryModel != query.QueryModel {
+		return fmt.Errorf("%w: query model %q vs %q", ErrIncompatibleServices, ingest.QueryModel, query.QueryModel)
+	}
+	if !sameChunking(ingest.Chunking, query.Chunking) {
+		return fmt.Errorf("%w: chunking %+v vs %+v", ErrIncompatibleServices, ingest.Chunking, query.Chunking)
+	}
+	return nil
+}
+
+// sameChunking reports whether a and b describe the same chunker
+// configuration.
+func sameChunking(a, b ChunkingDetails) bool {
+	if (a.Auto == nil) != (b.Auto == nil) || (a.Auto != nil && *a.Auto != *b.Auto) {
+		return false
+	}
+	return slices.Equal(a.Abbreviations, b.Abbreviations) &&
+		a.Strategy == b.Strategy &&
+		a.MaxSize == b.MaxSize &&
+		a.Overlap == b.Overlap &&
+		a.UnitOverlap == b.UnitOverlap &&
+		a.TrimSpace == b.TrimSpace &&
+		a.DropIncompleteTrailing == b.DropIncompleteTrailing &&
+		a.PoolExcludeOverlap == b.PoolExcludeOverlap
+}
+
+// IndexDocuments embeds documents using the ingest service.
+func (p *RetrievalProfile) IndexDocuments(ctx context.Context, texts []string) ([]Vector, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p.ingest.Batch(ctx, texts)
+}
+
+// Query embeds a search query using the query service.
+func (p *RetrievalProfile) Query(ctx context.Context, text string) (Vector, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p.query.EmbedQuery(ctx, text)
+}
+
+// Ingest returns the ingest service.
+func (p *RetrievalProfile) Ingest() *Service {
+	return p.ingest
+}
+
+// QueryService returns the query service.
+func (p *RetrievalProfile) QueryService() *Service {
+	return p.query
+}
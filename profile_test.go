@@ -0,0 +1,135 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewRetrievalProfile(t *testing.T) {
+	t.Run("accepts compatible services", func(t *testing.T) {
+		provider := newMockQueryProvider(256)
+		profile, err := NewRetrievalProfile(NewService(provider), NewService(provider))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		vecs, err := profile.IndexDocuments(context.Background(), []string{"doc one", "doc two"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(vecs) != 2 {
+			t.Errorf("expected 2 vectors, got %d", len(vecs))
+		}
+
+		vec, err := profile.Query(context.Background(), "query")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(vec) != 256 {
+			t.Errorf("expected 256 dimensions, got %d", len(vec))
+		}
+	})
+
+	t.Run("rejects nil services", func(t *testing.T) {
+		_, err := NewRetrievalProfile(nil, NewService(newMockProvider(256)))
+		if !errors.Is(err, ErrIncompatibleServices) {
+			t.Errorf("expected ErrIncompatibleServices, got %v", err)
+		}
+	})
+
+	t.Run("rejects different providers", func(t *testing.T) {
+		other := newMockProvider(256)
+		other.name = "other"
+		_, err := NewRetrievalProfile(NewService(newMockProvider(256)), NewService(other))
+		if !errors.Is(err, ErrIncompatibleServices) {
+			t.Errorf("expected ErrIncompatibleServices, got %v", err)
+		}
+	})
+
+	t.Run("rejects different dimensions", func(t *testing.T) {
+		_, err := NewRetrievalProfile(NewService(newMockProvider(256)), NewService(newMockProvider(512)))
+		if !errors.Is(err, ErrIncompatibleServices) {
+			t.Errorf("expected ErrIncompatibleServices, got %v", err)
+		}
+	})
+
+	t.Run("rejects different models", func(t *testing.T) {
+		ingest := NewService(&modelReportingProvider{mockProvider: newMockProvider(256), requested: "model-a"})
+		query := NewService(&modelReportingProvider{mockProvider: newMockProvider(256), requested: "model-b"})
+		_, err := NewRetrievalProfile(ingest, query)
+		if !errors.Is(err, ErrIncompatibleServices) {
+			t.Errorf("expected ErrIncompatibleServices, got %v", err)
+		}
+	})
+
+	t.Run("rejects different query models", func(t *testing.T) {
+		ingest := NewService(&queryModelProvider{mockProvider: newMockProvider(256), queryModel: "query-a"})
+		query := NewService(&queryModelProvider{mockProvider: newMockProvider(256), queryModel: "query-b"})
+		_, err := NewRetrievalProfile(ingest, query)
+		if !errors.Is(err, ErrIncompatibleServices) {
+			t.Errorf("expected ErrIncompatibleServices, got %v", err)
+		}
+	})
+
+	t.Run("rejects different chunking", func(t *testing.T) {
+		ingest := NewService(newMockProvider(256)).WithChunker(&Chunker{Strategy: ChunkSentence, MaxSize: 256})
+		query := NewService(newMockProvider(256)).WithChunker(&Chunker{Strategy: ChunkSentence, MaxSize: 512})
+		_, err := NewRetrievalProfile(ingest, query)
+		if !errors.Is(err, ErrIncompatibleServices) {
+			t.Errorf("expected ErrIncompatibleServices, got %v", err)
+		}
+	})
+
+	t.Run("accepts equal chunkers", func(t *testing.T) {
+		ingest := NewService(newMockProvider(256)).WithChunker(&Chunker{Strategy: ChunkAuto, Abbreviations: []string{"Dr."}})
+		query := NewService(newMockProvider(256)).WithChunker(&Chunker{Strategy: ChunkAuto, Abbreviations: []string{"Dr."}})
+		if _, err := NewRetrievalProfile(ingest, query); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("rejects different normalization", func(t *testing.T) {
+		ingest := NewService(newMockProvider(256))
+		query := NewService(newMockProvider(256)).WithNormalize(false)
+		_, err := NewRetrievalProfile(ingest, query)
+		if !errors.Is(err, ErrIncompatibleServices) {
+			t.Errorf("expected ErrIncompatibleServices, got %v", err)
+		}
+	})
+}
+
+// queryModelProvider embeds queries with a separately reported model.
+type queryModelProvider struct {
+	*mockProvider
+	queryModel string
+}
+
+func (p *queryModelProvider) ForQuery() Provider {
+	return &modelReportingProvider{mockProvider: p.mockProvider, requested: p.queryModel}
+}
+
+func TestRetrievalProfile_Invalidation(t *testing.T) {
+	provider := newMockProvider(256)
+	ingest := NewService(provider)
+	query := NewService(provider)
+
+	profile, err := NewRetrievalProfile(ingest, query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Reconfigure the query side after construction
+	query.WithNormalize(false)
+	calls := provider.callCount
+
+	if _, err := profile.Query(context.Background(), "query"); !errors.Is(err, ErrIncompatibleServices) {
+		t.Errorf("expected ErrIncompatibleServices, got %v", err)
+	}
+	if _, err := profile.IndexDocuments(context.Background(), []string{"doc"}); !errors.Is(err, ErrIncompatibleServices) {
+		t.Errorf("expected ErrIncompatibleServices, got %v", err)
+	}
+	if provider.callCount != calls {
+		t.Error("expected no provider calls after invalidation")
+	}
+}
@@ -0,0 +1,62 @@
+package vex
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Match is a ranked search result: an item identifier and its similarity
+// score against the query, higher being more similar.
+type Match struct {
+	ID    string
+	Score float64
+}
+
+// TopK ranks candidates, keyed by ID, by similarity to query under metric
+// and returns at most k matches, most similar first (all when k <= 0). Ties
+// are ordered by ID. It is an exact, brute-force search, suited to small
+// corpora or to producing the exact ranking for RecallAtK.
+func TopK(query Vector, candidates map[string]Vector, metric SimilarityMetric, k int) []Match {
+	matches := make([]Match, 0, len(candidates))
+	for id, vec := range candidates {
+		matches = append(matches, Match{ID: id, Score: query.Similarity(vec, metric)})
+	}
+	slices.SortFunc(matches, func(a, b Match) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// RecallAtK returns the fraction of the first k exact matches that also
+// appear in the first k approximate matches, comparing by ID. Rankings
+// shorter than k are used as-is; the denominator is the number of exact
+// matches considered. Returns 1 if there are no exact matches.
+func RecallAtK(approx, exact []Match, k int) float64 {
+	if k <= 0 {
+		return 1
+	}
+	exact = exact[:min(k, len(exact))]
+	approx = approx[:min(k, len(approx))]
+	if len(exact) == 0 {
+		return 1
+	}
+
+	found := make(map[string]struct{}, len(approx))
+	for _, m := range approx {
+		found[m.ID] = struct{}{}
+	}
+
+	hits := 0
+	for _, m := range exact {
+		if _, ok := found[m.ID]; ok {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(exact))
+}
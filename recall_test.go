@@ -0,0 +1,75 @@
+package vex
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func matches(ids ...string) []Match {
+	out := make([]Match, len(ids))
+	for i, id := range ids {
+		out[i] = Match{ID: id, Score: 1 - float64(i)/10}
+	}
+	return out
+}
+
+func TestRecallAtK(t *testing.T) {
+	exact := matches("a", "b", "c", "d", "e")
+
+	tests := []struct {
+		name     string
+		approx   []Match
+		k        int
+		expected float64
+	}{
+		{"identical rankings", matches("a", "b", "c", "d", "e"), 5, 1},
+		{"partial overlap", matches("a", "x", "c", "y", "e"), 5, 0.6},
+		{"order within top-k ignored", matches("c", "b", "a"), 3, 1},
+		{"matches beyond k ignored", matches("x", "y", "a"), 2, 0},
+		{"short approximate ranking", matches("a", "b"), 4, 0.5},
+		{"no overlap", matches("x", "y", "z"), 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RecallAtK(tt.approx, exact, tt.k); math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("expected %f, got %f", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("empty exact ranking", func(t *testing.T) {
+		if RecallAtK(matches("a"), nil, 3) != 1 {
+			t.Error("expected recall 1 with no exact matches")
+		}
+	})
+}
+
+func TestTopK(t *testing.T) {
+	query := Vector{1, 0}
+	candidates := map[string]Vector{
+		"near":    {0.9, 0.1},
+		"far":     {0, 1},
+		"exact":   {1, 0},
+		"exact-2": {2, 0},
+	}
+
+	all := TopK(query, candidates, Cosine, 0)
+	var ids []string
+	for _, m := range all {
+		ids = append(ids, m.ID)
+	}
+	if want := []string{"exact", "exact-2", "near", "far"}; !slices.Equal(ids, want) {
+		t.Errorf("expected %v, got %v", want, ids)
+	}
+
+	top := TopK(query, candidates, Cosine, 2)
+	if len(top) != 2 || top[0].ID != "exact" || math.Abs(top[0].Score-1) > 1e-6 {
+		t.Errorf("expected top-2 led by exact, got %v", top)
+	}
+
+	if got := TopK(query, nil, Cosine, 3); len(got) != 0 {
+		t.Errorf("expected no matches without candidates, got %v", got)
+	}
+}
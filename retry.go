@@ -0,0 +1,200 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/zoobzio/pipz"
+)
+
+// retry re-sends failed requests through processor, backing off between
+// attempts when baseDelay is set. It replaces pipz.Retry and pipz.Backoff so
+// that it can tell errors apart: a ProviderError that is not Retryable, such
+// as a 401 or 400, is returned at once, and a server-requested Retry-After
+// is waited out before the next attempt.
+//
+// With jitter, the delay before retry n is a random duration in
+// [0, baseDelay*2^n), capped at maxDelay. Randomizing the whole delay
+// spreads out callers that failed together, where a fixed schedule would
+// have them retry in lockstep.
+type retry struct {
+	identity    pipz.Identity
+	processor   pipz.Chainable[*EmbedRequest]
+	kind        string
+	maxAttempts int
+	baseDelay   time.Duration // zero retries immediately
+	maxDelay    time.Duration // zero for no cap
+	jitter      bool
+	closeOnce   sync.Once
+	closeErr    error
+}
+
+// newRetry retries immediately, as WithRetry.
+func newRetry(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], maxAttempts int) *retry {
+	return newRetryStage(identity, processor, "retry", maxAttempts, 0, 0, false)
+}
+
+// newBackoff doubles baseDelay after each failure, as WithBackoff.
+func newBackoff(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], maxAttempts int, baseDelay time.Duration) *retry {
+	return newRetryStage(identity, processor, "backoff", maxAttempts, baseDelay, 0, false)
+}
+
+// newJitteredBackoff randomizes each doubled delay, as WithJitteredBackoff.
+func newJitteredBackoff(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], maxAttempts int, baseDelay, maxDelay time.Duration) *retry {
+	return newRetryStage(identity, processor, "jittered-backoff", maxAttempts, baseDelay, maxDelay, true)
+}
+
+func newRetryStage(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], kind string, maxAttempts int, baseDelay, maxDelay time.Duration, jitter bool) *retry {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &retry{
+		identity:    identity,
+		processor:   processor,
+		kind:        kind,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		jitter:      jitter,
+	}
+}
+
+// Process implements pipz.Chainable.
+func (r *retry) Process(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+	var rng *rand.Rand
+	if r.jitter {
+		// A source per call keeps concurrent callers' delays uncorrelated.
+		rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())) //nolint:gosec // jitter needs no cryptographic randomness
+	}
+
+	var err error
+	for attempt := 0; attempt < r.maxAttempts; attempt++ {
+		// Each attempt gets its own copy: one abandoned by a WithTimeout
+		// stage may still be writing to it while the next runs.
+		attemptReq := *req
+		var out *EmbedRequest
+		out, err = r.processor.Process(ctx, &attemptReq)
+		if err == nil {
+			return out, nil
+		}
+		if attempt == r.maxAttempts-1 || !retryable(err) || abandoned(req, err) {
+			break
+		}
+
+		wait := r.delay(attempt, rng)
+		var perr *ProviderError
+		if errors.As(err, &perr) && perr.RetryAfter > 0 {
+			// Waiting past the deadline would only trade the provider's
+			// error for a less useful one.
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < perr.RetryAfter {
+				break
+			}
+			wait = max(wait, perr.RetryAfter)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			req.Error = err
+			return req, err
+		}
+		req.stats.recordRetry()
+	}
+	var marked *retryableTimeout
+	if errors.As(err, &marked) && err == error(marked) {
+		err = marked.err
+	}
+	req.Error = err
+	return req, err
+}
+
+// retryable reports whether a failed attempt is worth repeating: anything
+// but a ProviderError the provider would reject again.
+func retryable(err error) bool {
+	var perr *ProviderError
+	return !errors.As(err, &perr) || perr.Retryable()
+}
+
+// abandoned reports whether err is a timeout that must not be re-sent: the
+// provider may have processed and billed the call. It is decided from the
+// returned error alone, as a call abandoned by WithTimeout may still be
+// running. Timeouts marked by WithRetryTimeouts or WithAttemptTimeout, or
+// a request flagged by an outer WithRetryTimeouts, are retried.
+func abandoned(req *EmbedRequest, err error) bool {
+	var marked *retryableTimeout
+	return isTimeout(err) && !req.retryTimeouts && !errors.As(err, &marked)
+}
+
+// sleep waits for d, or returns ctx's error if it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// delay returns the delay before the retry following attempt.
+func (r *retry) delay(attempt int, rng *rand.Rand) time.Duration {
+	ceiling := doubled(r.baseDelay, attempt)
+	if r.maxDelay > 0 {
+		ceiling = min(ceiling, r.maxDelay)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	if !r.jitter {
+		return ceiling
+	}
+	return time.Duration(rng.Int64N(int64(ceiling)))
+}
+
+// doubled returns base doubled n times, saturating at the largest
+// Duration instead of overflowing.
+func doubled(base time.Duration, n int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	if n >= 63 || base > math.MaxInt64>>n {
+		return math.MaxInt64
+	}
+	return base << n
+}
+
+// Identity implements pipz.Chainable.
+func (r *retry) Identity() pipz.Identity {
+	return r.identity
+}
+
+// Schema implements pipz.Chainable.
+func (r *retry) Schema() pipz.Node {
+	var flow pipz.Flow = pipz.RetryFlow{Processor: r.processor.Schema()}
+	if r.baseDelay > 0 {
+		flow = pipz.BackoffFlow{Processor: r.processor.Schema()}
+	}
+	return pipz.Node{
+		Identity: r.identity,
+		Type:     r.kind,
+		Flow:     flow,
+		Metadata: map[string]any{
+			"max_attempts": r.maxAttempts,
+			"base_delay":   r.baseDelay.String(),
+			"max_delay":    r.maxDelay.String(),
+		},
+	}
+}
+
+// Close implements pipz.Chainable.
+func (r *retry) Close() error {
+	r.closeOnce.Do(func() {
+		r.closeErr = r.processor.Close()
+	})
+	return r.closeErr
+}
@@ -2,7 +2,17 @@ package vex
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/zoobzio/pipz"
@@ -18,19 +28,54 @@ type EmbedRequest struct {
 	RequestID string
 	Provider  string
 	Texts     []string
+	Titles    []string // Titles of Texts for TitledEmbedder providers, if any
+
+	// Tags are caller-supplied labels set with WithTags, for custom
+	// processors such as caches or routers to read. Nil if none were set.
+	// The map is shared by every request made with the context and must not
+	// be modified.
+	Tags map[string]string
+
+	stats         *serviceStats // the issuing service's counters, for WithSpendLimit
+	retryTimeouts bool          // set by WithRetryTimeouts
 }
 
 // Service wraps an embedding provider with pipeline-based reliability.
 type Service struct {
-	pipeline      pipz.Chainable[*EmbedRequest]
-	queryPipeline pipz.Chainable[*EmbedRequest]
+	pipes         atomic.Pointer[pipelines] // swapped by WrapPipeline
+	wrapMu        sync.Mutex                // serializes WrapPipeline
+	fingerprint   atomic.Pointer[string]    // cached Fingerprint, cleared by builder methods
 	provider      Provider
 	queryProvider Provider
 	chunker       *Chunker
+	stats         *serviceStats
+	minimal       *bool // shared with terminals; see WithMinimalOverhead
+	requestSeq    atomic.Uint64
+	observedDims  atomic.Int64 // first dimension seen; see checkDimensions
+	unitOutput    sync.Map     // provider name -> bool; see outputIsUnit
+	queryFallback sync.Once    // emits QueryModeFallback once; see BatchQuery
+	failOnDrift   bool
+	strict        bool
+	lengthSorted  bool
+	instruction   string
+	instructTmpl  string
+	titleHandling TitleHandling
+	zeroPolicy    ZeroVectorPolicy
+	maxInputChars int
+	truncation    TruncationMode
 	poolingMode   PoolingMode
+	adaptiveLimit int // PoolAdaptive threshold
+	maxBatch      int // overrides BatchLimiter; see WithMaxBatchSize
 	normalize     bool
 }
 
+// pipelines holds a service's document pipeline and its query pipeline, which
+// is nil for providers without a query mode.
+type pipelines struct {
+	document pipz.Chainable[*EmbedRequest]
+	query    pipz.Chainable[*EmbedRequest]
+}
+
 // ServiceConfig configures a Service.
 type ServiceConfig struct {
 	Chunker     *Chunker
@@ -40,7 +85,9 @@ type ServiceConfig struct {
 
 // NewService creates a new embedding Service with the given provider and options.
 func NewService(provider Provider, opts ...Option) *Service {
-	terminal := NewTerminal(provider)
+	stats := &serviceStats{}
+	minimal := new(bool)
+	terminal := newTerminal(provider, stats, minimal)
 
 	// Apply options in reverse order (outermost first)
 	pipeline := terminal
@@ -48,44 +95,145 @@ func NewService(provider Provider, opts ...Option) *Service {
 		pipeline = opts[i](pipeline)
 	}
 
+	pipes := &pipelines{document: pipeline}
 	svc := &Service{
-		pipeline:    pipeline,
-		provider:    provider,
-		chunker:     DefaultChunker(),
-		poolingMode: PoolMean,
-		normalize:   true,
+		provider:      provider,
+		chunker:       DefaultChunker(),
+		stats:         stats,
+		minimal:       minimal,
+		poolingMode:   PoolMean,
+		adaptiveLimit: DefaultAdaptivePoolThreshold,
+		normalize:     true,
 	}
 
 	// Auto-detect query provider for supporting backends
 	if qp, ok := provider.(QueryProviderFactory); ok {
 		svc.queryProvider = qp.ForQuery()
-		queryTerminal := NewTerminal(svc.queryProvider)
+		queryTerminal := newTerminal(svc.queryProvider, stats, minimal)
 		queryPipeline := queryTerminal
 		for i := len(opts) - 1; i >= 0; i-- {
 			queryPipeline = opts[i](queryPipeline)
 		}
-		svc.queryPipeline = queryPipeline
+		pipes.query = queryPipeline
 	}
+	svc.pipes.Store(pipes)
 
 	return svc
 }
 
-// NewTerminal creates a terminal processor that calls the embedding provider.
+// NewTerminal creates a terminal processor that calls the embedding provider,
+// or the provider set on the request context with WithProviderOverride.
 func NewTerminal(provider Provider) pipz.Chainable[*EmbedRequest] {
+	return newTerminal(provider, nil, nil)
+}
+
+// providerOverrideKey is the context key for WithProviderOverride.
+type providerOverrideKey struct{}
+
+// WithProviderOverride returns a context that makes service terminals call
+// provider instead of the provider they were built with, e.g. to route
+// requests to a model tier chosen per user without building a Service per
+// tier. Options such as retry, timeout and circuit breaking still wrap the
+// overridden call, and their state (breaker counts, rate limits) is shared
+// with requests using the bound provider. A WithFallback service ignores the
+// override and calls its own provider.
+//
+// The override is used as given for both Embed and EmbedQuery, so pass the
+// provider's ForQuery variant for query requests where that matters. It
+// should produce vectors of the same dimensionality as the bound provider;
+// otherwise DimensionDrift fires. The provider may be called concurrently
+// by any requests sharing the context and must be safe for that.
+func WithProviderOverride(ctx context.Context, provider Provider) context.Context {
+	return context.WithValue(ctx, providerOverrideKey{}, provider)
+}
+
+// withoutProviderOverride returns ctx with any WithProviderOverride cleared.
+func withoutProviderOverride(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(providerOverrideKey{}).(Provider); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, providerOverrideKey{}, nil)
+}
+
+// hasProviderOverride reports whether ctx carries a WithProviderOverride.
+func hasProviderOverride(ctx context.Context) bool {
+	p, ok := ctx.Value(providerOverrideKey{}).(Provider)
+	return ok && p != nil
+}
+
+// providerFor returns the override provider from ctx, or bound if none.
+func providerFor(ctx context.Context, bound Provider) Provider {
+	if p, ok := ctx.Value(providerOverrideKey{}).(Provider); ok && p != nil {
+		return p
+	}
+	return bound
+}
+
+// tagsKey is the context key for WithTags.
+type tagsKey struct{}
+
+// WithTags returns a context whose service requests carry tags in
+// EmbedRequest.Tags, so processors added with WrapPipeline or custom options
+// can act on per-request context such as a tenant or cache namespace. Tags
+// already set on ctx are kept unless tags overrides them.
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := maps.Clone(tagsFrom(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(tags))
+	}
+	maps.Copy(merged, tags)
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// tagsFrom returns the tags set on ctx by WithTags, or nil.
+func tagsFrom(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	return tags
+}
+
+// requestIDKey is the context key for the ID of the request being processed.
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID of the service request ctx belongs to,
+// as reported under RequestIDKey in hooks, or "" outside a request. Providers
+// send it to the API as an X-Request-ID header, so fallback and retry attempts
+// for one request can be correlated server-side.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newTerminal creates a terminal processor that records provider calls in stats.
+// Success hooks are skipped while *minimal is true; failures are always emitted.
+func newTerminal(bound Provider, stats *serviceStats, minimal *bool) pipz.Chainable[*EmbedRequest] {
 	return pipz.Apply(terminalID, func(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+		provider := providerFor(ctx, bound)
+		quiet := minimal != nil && *minimal
 		start := time.Now()
-		emitProviderCallStarted(ctx, provider.Name(), len(req.Texts))
+		if !quiet {
+			emitProviderCallStarted(ctx, provider.Name(), len(req.Texts))
+		}
+		stats.recordProviderCall()
 
-		resp, err := provider.Embed(ctx, req.Texts)
+		var resp *EmbeddingResponse
+		var err error
+		if titled, ok := provider.(TitledEmbedder); ok && req.Titles != nil {
+			resp, err = titled.EmbedTitled(ctx, req.Texts, req.Titles)
+		} else {
+			resp, err = provider.Embed(ctx, req.Texts)
+		}
 		duration := time.Since(start)
 
 		if err != nil {
 			emitProviderCallFailed(ctx, provider.Name(), err, duration)
+			stats.recordProviderFailed()
 			req.Error = err
 			return req, err
 		}
 
-		emitProviderCallCompleted(ctx, provider.Name(), resp, duration)
+		if !quiet {
+			emitProviderCallCompleted(ctx, provider.Name(), resp, duration)
+		}
 		req.Response = resp
 		return req, nil
 	})
@@ -93,24 +241,167 @@ func NewTerminal(provider Provider) pipz.Chainable[*EmbedRequest] {
 
 // GetPipeline returns the internal pipeline for composition.
 func (s *Service) GetPipeline() pipz.Chainable[*EmbedRequest] {
-	return s.pipeline
+	return s.pipes.Load().document
+}
+
+// WrapPipeline wraps the document and query pipelines with wrap, e.g. to add
+// middleware after NewService. Both are swapped at once, so each request runs
+// entirely through the old or the new pipelines, and requests already in
+// flight finish on the old ones. The wrapper becomes outermost: it runs
+// before the options passed to NewService and any earlier WrapPipeline.
+// Returns an error, leaving the pipelines unchanged, if wrap returns nil.
+func (s *Service) WrapPipeline(wrap func(pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest]) error {
+	s.wrapMu.Lock()
+	defer s.wrapMu.Unlock()
+
+	current := s.pipes.Load()
+	next := &pipelines{document: wrap(current.document)}
+	if next.document == nil {
+		return errors.New("vex: pipeline wrapper returned nil")
+	}
+	if current.query != nil {
+		if next.query = wrap(current.query); next.query == nil {
+			return errors.New("vex: pipeline wrapper returned nil")
+		}
+	}
+	s.pipes.Store(next)
+	s.fingerprint.Store(nil)
+	return nil
 }
 
 // WithChunker sets the chunking strategy.
 func (s *Service) WithChunker(c *Chunker) *Service {
 	s.chunker = c
+	s.fingerprint.Store(nil)
 	return s
 }
 
 // WithPooling sets the pooling mode for chunked embeddings.
 func (s *Service) WithPooling(mode PoolingMode) *Service {
 	s.poolingMode = mode
+	s.fingerprint.Store(nil)
 	return s
 }
 
-// WithNormalize sets whether to L2-normalize output vectors.
+// WithAdaptivePooling selects PoolAdaptive with threshold as the largest
+// number of chunks mean-pooled; texts with more chunks are max-pooled.
+func (s *Service) WithAdaptivePooling(threshold int) *Service {
+	s.poolingMode = PoolAdaptive
+	s.adaptiveLimit = threshold
+	s.fingerprint.Store(nil)
+	return s
+}
+
+// WithNormalize sets whether to L2-normalize output vectors. Providers whose
+// first response is already unit length have their unpooled vectors passed
+// through as-is; pooled vectors are always normalized.
 func (s *Service) WithNormalize(normalize bool) *Service {
 	s.normalize = normalize
+	s.fingerprint.Store(nil)
+	return s
+}
+
+// WithMaxInputChars limits each chunk sent to the provider to n characters,
+// checked before the request is built so overlong inputs never cost a round
+// trip. mode selects whether overlong chunks fail the request or are cut
+// down; each truncation emits InputTruncated. Zero disables the limit.
+func (s *Service) WithMaxInputChars(n int, mode TruncationMode) *Service {
+	s.maxInputChars = n
+	s.truncation = mode
+	s.fingerprint.Store(nil)
+	return s
+}
+
+// WithMinimalOverhead skips success hooks (EmbedStarted, EmbedCompleted, and
+// the provider call equivalents) and replaces UUID request IDs with a cheap
+// per-service counter. Failures still emit EmbedFailed and ProviderCallFailed.
+// Intended for latency-sensitive paths backed by fast local models.
+func (s *Service) WithMinimalOverhead() *Service {
+	*s.minimal = true
+	return s
+}
+
+// WithFailOnDimensionDrift makes requests fail with ErrDimensionDrift when
+// the provider returns vectors whose size differs from the first response
+// the service saw. DimensionDrift is emitted either way.
+func (s *Service) WithFailOnDimensionDrift() *Service {
+	s.failOnDrift = true
+	return s
+}
+
+// WithMaxBatchSize caps the inputs sent through the pipeline per request,
+// overriding the limit a BatchLimiter provider reports. Requests with more
+// chunks, including a single text chunked into more pieces than the cap, are
+// sent as consecutive sub-requests and merged before pooling, so each text
+// still gets one vector. The first sub-request to fail cancels the rest.
+// Zero restores the provider's limit; a negative n disables splitting.
+func (s *Service) WithMaxBatchSize(n int) *Service {
+	s.maxBatch = n
+	return s
+}
+
+// WithStrictResults makes Batch and its variants return exactly one non-nil
+// vector per input text, or an error. The empty-response case returns
+// ErrEmptyProviderResponse instead of nil, nil. Texts that produced no vector,
+// whether dropped under ZeroDrop or lost to failed provider sub-batches, are
+// reported by a *BatchError with one single-text failure per such text, and
+// the len(texts) result holding the rest is returned alongside it, as well
+// as from the error's Partial. An empty input returns an empty, non-nil slice.
+func (s *Service) WithStrictResults() *Service {
+	s.strict = true
+	return s
+}
+
+// WithLengthSortedBatching sorts each request's chunks by length before they
+// are sent, so the sub-batches a provider splits them into hold inputs of
+// similar length and waste less padding on backends that pad to the longest
+// input. Results are restored to the original order. Most useful for batches
+// mixing very short and very long texts.
+func (s *Service) WithLengthSortedBatching() *Service {
+	s.lengthSorted = true
+	return s
+}
+
+// DefaultInstructionTemplate is the input format used by WithInstruction,
+// as expected by e5-mistral and similar instruction-tuned models.
+const DefaultInstructionTemplate = "Instruct: {instruction}\nQuery: {text}"
+
+// WithInstruction formats every input sent to the provider with a task
+// instruction for instruction-tuned models, using DefaultInstructionTemplate
+// unless WithInstructionTemplate sets another. The formatting happens after
+// chunking and input limits, so it applies to each chunk and chunks returned
+// by EmbedChunks are unformatted. An empty instruction disables it.
+func (s *Service) WithInstruction(instruction string) *Service {
+	s.instruction = instruction
+	s.fingerprint.Store(nil)
+	return s
+}
+
+// WithInstructionTemplate sets the input format used by WithInstruction.
+// "{instruction}" and "{text}" in template are replaced by the instruction
+// and the input text, e.g. "Represent the question for retrieval: {text}"
+// where the model expects the instruction inline. An empty template restores
+// DefaultInstructionTemplate.
+func (s *Service) WithInstructionTemplate(template string) *Service {
+	s.instructTmpl = template
+	s.fingerprint.Store(nil)
+	return s
+}
+
+// WithTitleHandling sets how BatchDocuments passes document titles to the
+// provider. Defaults to TitleNative.
+func (s *Service) WithTitleHandling(mode TitleHandling) *Service {
+	s.titleHandling = mode
+	s.fingerprint.Store(nil)
+	return s
+}
+
+// WithZeroVectorPolicy sets how all-zero vectors from the provider, which
+// normalize to themselves and score 0 against everything, are handled by
+// Embed, Batch and their query and raw variants. ZeroVectorDetected is
+// emitted for each regardless. Defaults to ZeroAllow.
+func (s *Service) WithZeroVectorPolicy(policy ZeroVectorPolicy) *Service {
+	s.zeroPolicy = policy
 	return s
 }
 
@@ -144,144 +435,700 @@ func (s *Service) EmbedQuery(ctx context.Context, text string) (Vector, error) {
 
 // Batch generates embeddings for multiple texts.
 func (s *Service) Batch(ctx context.Context, texts []string) ([]Vector, error) {
+	return s.batch(ctx, texts, nil, s.pipes.Load().document, s.provider, s.normalize)
+}
+
+// BatchDocuments generates document embeddings for docs, passing each title
+// to the provider as set by WithTitleHandling. Documents without a title are
+// embedded as their text alone.
+func (s *Service) BatchDocuments(ctx context.Context, docs []Document) ([]Vector, error) {
+	texts := make([]string, len(docs))
+	titles := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Text
+		titles[i] = doc.Title
+	}
+	return s.batch(ctx, texts, titles, s.pipes.Load().document, s.provider, s.normalize)
+}
+
+// BatchQuery generates query-optimized embeddings for multiple texts.
+// For providers that distinguish query vs document embeddings, this uses
+// query-optimized mode. Otherwise behaves identically to Batch, and the
+// first such call emits QueryModeFallback; see SupportsQueryMode.
+func (s *Service) BatchQuery(ctx context.Context, texts []string) ([]Vector, error) {
+	// Fall back to regular Batch if no query provider
+	if s.queryProvider == nil {
+		s.queryFallback.Do(func() {
+			emitQueryModeFallback(ctx, s.provider.Name())
+		})
+		return s.Batch(ctx, texts)
+	}
+	return s.batch(ctx, texts, nil, s.pipes.Load().query, s.queryProvider, s.normalize)
+}
+
+// SupportsQueryMode reports whether the provider embeds queries differently
+// from documents, i.e. implements QueryProviderFactory. When it doesn't,
+// EmbedQuery and BatchQuery return document embeddings. That is harmless for
+// symmetric models, but costs retrieval quality when an asymmetric provider
+// is wrapped in a type that hides its ForQuery method.
+func (s *Service) SupportsQueryMode() bool {
+	return s.queryProvider != nil
+}
+
+// EmbedRaw generates an embedding for a single text without normalization,
+// regardless of the service's normalize setting.
+func (s *Service) EmbedRaw(ctx context.Context, text string) (Vector, error) {
+	vectors, err := s.BatchRaw(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+	return vectors[0], nil
+}
+
+// BatchRaw generates embeddings for multiple texts without normalization,
+// regardless of the service's normalize setting. Chunk pooling still applies.
+func (s *Service) BatchRaw(ctx context.Context, texts []string) ([]Vector, error) {
+	return s.batch(ctx, texts, nil, s.pipes.Load().document, s.provider, false)
+}
+
+// EmbedChunks chunks text and returns each chunk's vector alongside the chunk
+// text, without pooling. Vectors are normalized per chunk when enabled.
+// Useful for multi-vector retrieval and chunk-level highlighting.
+func (s *Service) EmbedChunks(ctx context.Context, text string) ([]Vector, []string, error) {
+	chunks := s.chunker.Chunk(text)
+	if len(chunks) == 0 {
+		return nil, nil, nil
+	}
+
+	resp, err := s.process(ctx, 1, chunks, nil, s.pipes.Load().document, s.provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp == nil {
+		return nil, nil, nil
+	}
+
+	vectors := make([]Vector, len(resp.Vectors))
+	unit := s.normalize && s.outputIsUnit(providerFor(ctx, s.provider).Name(), resp.Vectors)
+	for i, v := range resp.Vectors {
+		if s.normalize && !unit {
+			v = v.Normalize()
+		}
+		vectors[i] = v
+	}
+	return vectors, chunks, nil
+}
+
+// BatchResponse embeds texts without chunking or pooling and returns the full
+// provider response, including quantized Int8Vectors and BinaryVectors when
+// the provider is configured to return them, and any provider Warnings.
+// Quantized vectors are passed through untouched; float Vectors are
+// normalized only when enabled.
+func (s *Service) BatchResponse(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
 	if len(texts) == 0 {
 		return nil, nil
 	}
 
-	requestID := uuid.New().String()
-	start := time.Now()
+	resp, err := s.process(ctx, len(texts), slices.Clone(texts), nil, s.pipes.Load().document, s.provider)
+	if err != nil || resp == nil {
+		return nil, err
+	}
+	if s.normalize && len(resp.Vectors) > 0 && !s.outputIsUnit(providerFor(ctx, s.provider).Name(), resp.Vectors) {
+		normalized := *resp
+		normalized.Vectors = make([]Vector, len(resp.Vectors))
+		for i, v := range resp.Vectors {
+			normalized.Vectors[i] = v.Normalize()
+		}
+		resp = &normalized
+	}
+	return resp, nil
+}
 
-	emitEmbedStarted(ctx, requestID, s.provider.Name(), len(texts))
+// batch chunks texts, runs them through pipeline, and pools the results.
+// titles, if not nil, holds the title of each text; see WithTitleHandling.
+func (s *Service) batch(ctx context.Context, texts, titles []string, pipeline pipz.Chainable[*EmbedRequest], provider Provider, normalize bool) ([]Vector, error) {
+	if len(texts) == 0 {
+		if s.strict {
+			return []Vector{}, nil
+		}
+		return nil, nil
+	}
+
+	mode := s.titleHandling
+	if _, ok := providerFor(ctx, provider).(TitledEmbedder); mode == TitleNative && !ok {
+		mode = TitlePrepend
+	}
 
 	// Chunk texts if needed
 	var allChunks []string
-	var chunkMapping []int // maps chunk index to original text index
+	var chunkTitles []string   // title of each chunk, sent natively
+	var chunkMapping []int     // maps chunk index to original text index
+	var chunkWeights []float64 // mean-pooling weight of each chunk
 	for i, text := range texts {
-		chunks := s.chunker.Chunk(text)
-		for range chunks {
+		var title string
+		if titles != nil {
+			title = titles[i]
+		}
+		if title != "" && mode == TitlePrepend {
+			text = withTitle(title, text)
+		}
+		chunks, strategy := s.chunker.chunk(text)
+		if s.chunker.Strategy == ChunkAuto && !*s.minimal {
+			emitChunkStrategySelected(ctx, providerFor(ctx, provider).Name(), i, strategy, len(chunks))
+		}
+		for j := range chunks {
 			chunkMapping = append(chunkMapping, i)
+			chunkWeights = append(chunkWeights, s.chunker.poolWeight(strategy, j))
+			if title != "" && mode == TitlePrependChunks {
+				chunks[j] = withTitle(title, chunks[j])
+			}
+			if titles != nil && mode == TitleNative {
+				chunkTitles = append(chunkTitles, title)
+			}
 		}
 		allChunks = append(allChunks, chunks...)
 	}
 
-	// Create and process request
-	req := &EmbedRequest{
-		Texts:     allChunks,
-		RequestID: requestID,
-		Provider:  s.provider.Name(),
+	resp, err := s.process(ctx, len(texts), allChunks, chunkTitles, pipeline, provider)
+	if s.strict {
+		var berr *BatchError
+		switch {
+		case errors.As(err, &berr):
+			return s.poolPartial(texts, berr, chunkMapping, chunkWeights, normalize)
+		case err == nil && resp == nil:
+			return nil, fmt.Errorf("%w for %d texts", ErrEmptyProviderResponse, len(texts))
+		}
+	}
+	if err != nil || resp == nil {
+		return nil, err
 	}
 
-	processed, err := s.pipeline.Process(ctx, req)
-	duration := time.Since(start)
-
-	if err != nil {
-		emitEmbedFailed(ctx, requestID, s.provider.Name(), err, duration)
+	if err := ValidateVectors(providerFor(ctx, provider).Name(), resp.Vectors); err != nil {
 		return nil, err
 	}
 
-	if processed.Response == nil || len(processed.Response.Vectors) == 0 {
-		return nil, nil
+	dropped, err := s.checkZeroVectors(ctx, providerFor(ctx, provider).Name(), resp.Vectors, chunkMapping)
+	if err != nil {
+		return nil, err
 	}
 
 	// Pool chunks back to original texts
-	vectors := s.poolChunks(texts, processed.Response.Vectors, chunkMapping)
+	vectors, err := s.poolChunks(texts, resp.Vectors, chunkMapping, chunkWeights)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range dropped {
+		vectors[i] = nil
+	}
 
-	// Normalize if configured
-	if s.normalize {
+	// Normalize if configured. Unpooled vectors from a provider that already
+	// returns unit vectors are left as they are.
+	if normalize {
+		unit := s.outputIsUnit(providerFor(ctx, provider).Name(), resp.Vectors)
+		chunkCounts := make([]int, len(texts))
+		for _, textIdx := range chunkMapping {
+			chunkCounts[textIdx]++
+		}
 		for i, v := range vectors {
+			if unit && chunkCounts[i] == 1 {
+				continue
+			}
 			vectors[i] = v.Normalize()
 		}
 	}
 
-	emitEmbedCompleted(ctx, requestID, s.provider.Name(), processed.Response, duration)
-
+	if s.strict && len(dropped) > 0 {
+		failures := make([]BatchFailure, 0, len(dropped))
+		for _, i := range dropped {
+			failures = append(failures, BatchFailure{Err: fmt.Errorf("%w for input %d", ErrZeroVector, i), Start: i, End: i + 1})
+		}
+		slices.SortFunc(failures, func(a, b BatchFailure) int { return a.Start - b.Start })
+		return vectors, NewBatchError(failures, vectors)
+	}
 	return vectors, nil
 }
 
-// BatchQuery generates query-optimized embeddings for multiple texts.
-// For providers that distinguish query vs document embeddings, this uses
-// query-optimized mode. Otherwise behaves identically to Batch.
-func (s *Service) BatchQuery(ctx context.Context, texts []string) ([]Vector, error) {
-	if len(texts) == 0 {
-		return nil, nil
+// poolPartial turns berr, whose failures and partial vectors cover chunks,
+// into one covering texts for WithStrictResults: texts with a chunk in a
+// failed sub-batch get a single-text failure and a nil vector, and the rest
+// are pooled and normalized as usual.
+func (s *Service) poolPartial(texts []string, berr *BatchError, mapping []int, weights []float64, normalize bool) ([]Vector, error) {
+	textErrs := make([]error, len(texts))
+	for _, f := range berr.Failures {
+		for k := f.Start; k < f.End && k < len(mapping); k++ {
+			if textErrs[mapping[k]] == nil {
+				textErrs[mapping[k]] = f.Err
+			}
+		}
 	}
 
-	// Fall back to regular Batch if no query provider
-	if s.queryProvider == nil {
-		return s.Batch(ctx, texts)
+	partial := berr.Partial()
+	var chunkVectors []Vector
+	var chunkMapping []int
+	var chunkWeights []float64
+	for k, textIdx := range mapping {
+		if textErrs[textIdx] != nil || k >= len(partial) {
+			continue
+		}
+		chunkVectors = append(chunkVectors, partial[k])
+		chunkMapping = append(chunkMapping, textIdx)
+		chunkWeights = append(chunkWeights, weights[k])
+	}
+	vectors, err := s.poolChunks(texts, chunkVectors, chunkMapping, chunkWeights)
+	if err != nil {
+		return nil, err
 	}
 
-	requestID := uuid.New().String()
+	var failures []BatchFailure
+	for i, textErr := range textErrs {
+		if textErr != nil {
+			failures = append(failures, BatchFailure{Err: textErr, Start: i, End: i + 1})
+			continue
+		}
+		if vectors[i] == nil {
+			failures = append(failures, BatchFailure{Err: fmt.Errorf("%w for input %d", ErrEmptyProviderResponse, i), Start: i, End: i + 1})
+			continue
+		}
+		if normalize {
+			vectors[i] = vectors[i].Normalize()
+		}
+	}
+	if len(failures) == 0 {
+		return vectors, nil
+	}
+	return vectors, NewBatchError(failures, vectors)
+}
+
+// unitNormTolerance is how far from 1 a vector's norm may be for
+// outputIsUnit to treat it as already normalized.
+const unitNormTolerance = 1e-4
+
+// outputIsUnit reports whether provider returns unit-length vectors, so that
+// normalizing its unpooled output is redundant. The first response with a
+// non-zero vector decides, and the answer is cached for the service's
+// lifetime; a provider configured for truncated (Matryoshka) output fails
+// the probe and keeps being normalized.
+func (s *Service) outputIsUnit(provider string, vectors []Vector) bool {
+	if unit, ok := s.unitOutput.Load(provider); ok {
+		return unit.(bool)
+	}
+	probed := false
+	for _, v := range vectors {
+		if v.isZero() {
+			continue
+		}
+		probed = true
+		if math.Abs(v.Norm()-1) > unitNormTolerance {
+			unit, _ := s.unitOutput.LoadOrStore(provider, false)
+			return unit.(bool)
+		}
+	}
+	if !probed {
+		return false
+	}
+	unit, _ := s.unitOutput.LoadOrStore(provider, true)
+	return unit.(bool)
+}
+
+// checkZeroVectors emits ZeroVectorDetected for each all-zero chunk vector
+// and applies the zero vector policy. It returns the indices of texts to drop
+// under ZeroDrop, or an error wrapping ErrZeroVector under ZeroError.
+func (s *Service) checkZeroVectors(ctx context.Context, provider string, chunkVectors []Vector, mapping []int) ([]int, error) {
+	var dropped []int
+	for i, vec := range chunkVectors {
+		if !vec.isZero() || i >= len(mapping) {
+			continue
+		}
+		textIdx := mapping[i]
+		emitZeroVectorDetected(ctx, provider, textIdx)
+		switch s.zeroPolicy {
+		case ZeroError:
+			return nil, fmt.Errorf("%w for input %d", ErrZeroVector, textIdx)
+		case ZeroDrop:
+			dropped = append(dropped, textIdx)
+		}
+	}
+	return dropped, nil
+}
+
+// withTitle prepends title to text for TitlePrepend and TitlePrependChunks.
+func withTitle(title, text string) string {
+	return title + "\n\n" + text
+}
+
+// process sends chunks through pipeline, emitting hooks and recording stats.
+// textCount is the number of caller texts the chunks were derived from.
+// titles, if not nil, holds the title of each chunk for TitledEmbedder
+// providers. Returns a nil response if the provider returned no embeddings of
+// any kind.
+func (s *Service) process(ctx context.Context, textCount int, chunks, titles []string, pipeline pipz.Chainable[*EmbedRequest], provider Provider) (*EmbeddingResponse, error) {
+	provider = providerFor(ctx, provider)
+	quiet := *s.minimal
+	var requestID string
+	if quiet {
+		requestID = strconv.FormatUint(s.requestSeq.Add(1), 10)
+	} else {
+		requestID = uuid.New().String()
+	}
 	start := time.Now()
 
-	emitEmbedStarted(ctx, requestID, s.queryProvider.Name(), len(texts))
+	if !quiet {
+		emitEmbedStarted(ctx, requestID, provider.Name(), textCount)
+	}
+	s.stats.recordStarted()
 
-	// Chunk texts if needed
-	var allChunks []string
-	var chunkMapping []int
-	for i, text := range texts {
-		chunks := s.chunker.Chunk(text)
-		for range chunks {
-			chunkMapping = append(chunkMapping, i)
+	if err := s.limitInputs(ctx, requestID, provider.Name(), chunks); err != nil {
+		emitEmbedFailed(ctx, requestID, provider.Name(), err, time.Since(start))
+		s.stats.recordFailed()
+		return nil, err
+	}
+
+	texts := s.instruct(chunks)
+	var order []int
+	if s.lengthSorted {
+		texts, order = sortByLength(texts)
+		if titles != nil {
+			titles = permute(titles, order)
 		}
-		allChunks = append(allChunks, chunks...)
 	}
 
-	// Create and process request using query pipeline
+	// Create and process request
 	req := &EmbedRequest{
-		Texts:     allChunks,
+		Texts:     texts,
+		Titles:    titles,
 		RequestID: requestID,
-		Provider:  s.queryProvider.Name(),
+		Provider:  provider.Name(),
+		Tags:      tagsFrom(ctx),
+		stats:     s.stats,
 	}
 
-	processed, err := s.queryPipeline.Process(ctx, req)
+	processed, err := s.dispatch(context.WithValue(ctx, requestIDKey{}, requestID), req, pipeline, provider)
 	duration := time.Since(start)
 
 	if err != nil {
-		emitEmbedFailed(ctx, requestID, s.queryProvider.Name(), err, duration)
+		emitEmbedFailed(ctx, requestID, provider.Name(), err, duration)
+		s.stats.recordFailed()
+		var berr *BatchError
+		if order != nil && errors.As(err, &berr) {
+			err = unsortBatchError(berr, order)
+		}
 		return nil, err
 	}
 
-	if processed.Response == nil || len(processed.Response.Vectors) == 0 {
+	if processed.Response == nil || processed.Response.count() == 0 {
 		return nil, nil
 	}
+	if order != nil {
+		processed.Response = unsortResponse(processed.Response, order)
+	}
 
-	// Pool chunks back to original texts
-	vectors := s.poolChunks(texts, processed.Response.Vectors, chunkMapping)
+	if err := s.checkDimensions(ctx, requestID, provider.Name(), processed.Response); err != nil {
+		emitEmbedFailed(ctx, requestID, provider.Name(), err, duration)
+		s.stats.recordFailed()
+		return nil, err
+	}
 
-	// Normalize if configured
-	if s.normalize {
-		for i, v := range vectors {
-			vectors[i] = v.Normalize()
+	if !quiet {
+		emitEmbedCompleted(ctx, requestID, provider.Name(), requestedModel(provider), s.Fingerprint(), processed.Response, duration)
+	}
+	s.stats.recordCompleted(textCount, len(chunks), processed.Response)
+
+	return processed.Response, nil
+}
+
+// dispatch runs req through pipeline, split into sub-requests of at most the
+// batch limit (see WithMaxBatchSize) whose responses are merged in order.
+// Sub-requests share a context derived from ctx, so the first failure,
+// including ctx's deadline expiring, cancels the ones still pending. Every
+// failed or cancelled range is returned together in a *BatchError.
+func (s *Service) dispatch(ctx context.Context, req *EmbedRequest, pipeline pipz.Chainable[*EmbedRequest], provider Provider) (*EmbedRequest, error) {
+	limit := s.maxBatch
+	if bl, ok := provider.(BatchLimiter); ok && limit == 0 {
+		limit = bl.MaxBatchSize()
+	}
+	if limit <= 0 || len(req.Texts) <= limit {
+		return pipeline.Process(ctx, req)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	merged := &EmbeddingResponse{}
+	var failures []BatchFailure
+	for start := 0; start < len(req.Texts); start += limit {
+		end := min(start+limit, len(req.Texts))
+		if ctx.Err() != nil {
+			failures = append(failures, BatchFailure{Err: ctx.Err(), Start: start, End: end})
+			continue
+		}
+		sub := &EmbedRequest{
+			Texts:     req.Texts[start:end],
+			RequestID: req.RequestID,
+			Provider:  req.Provider,
+			Tags:      req.Tags,
+			stats:     req.stats,
+		}
+		if req.Titles != nil {
+			sub.Titles = req.Titles[start:end]
+		}
+		out, err := pipeline.Process(ctx, sub)
+		if err == nil && (out.Response == nil || out.Response.count() != end-start) {
+			err = fmt.Errorf("expected %d embeddings from provider for inputs [%d:%d]", end-start, start, end)
+		}
+		if err != nil {
+			failures = append(failures, BatchFailure{Err: err, Start: start, End: end})
+			cancel()
+			continue
 		}
+		mergeResponse(merged, out.Response, start, len(req.Texts))
 	}
 
-	emitEmbedCompleted(ctx, requestID, s.queryProvider.Name(), processed.Response, duration)
+	if len(failures) > 0 {
+		partial := merged.Vectors
+		if partial == nil {
+			partial = make([]Vector, len(req.Texts))
+		}
+		err := NewBatchError(failures, partial)
+		req.Error = err
+		return req, err
+	}
+	req.Response = merged
+	return req, nil
+}
 
-	return vectors, nil
+// mergeResponse copies part, the response for inputs starting at offset,
+// into merged, a response for total inputs, summing usage.
+func mergeResponse(merged, part *EmbeddingResponse, offset, total int) {
+	if merged.Model == "" {
+		merged.Model = part.Model
+		merged.Dimensions = part.Dimensions
+	}
+	if part.Vectors != nil {
+		if merged.Vectors == nil {
+			merged.Vectors = make([]Vector, total)
+		}
+		copy(merged.Vectors[offset:], part.Vectors)
+	}
+	if part.Int8Vectors != nil {
+		if merged.Int8Vectors == nil {
+			merged.Int8Vectors = make([][]int8, total)
+		}
+		copy(merged.Int8Vectors[offset:], part.Int8Vectors)
+	}
+	if part.BinaryVectors != nil {
+		if merged.BinaryVectors == nil {
+			merged.BinaryVectors = make([][]byte, total)
+		}
+		copy(merged.BinaryVectors[offset:], part.BinaryVectors)
+	}
+	merged.Truncated += part.Truncated
+	merged.Warnings = append(merged.Warnings, part.Warnings...)
+	merged.Usage.PromptTokens += part.Usage.PromptTokens
+	merged.Usage.TotalTokens += part.Usage.TotalTokens
+}
+
+// checkDimensions compares the response's vector size with the first size
+// the service observed, emitting DimensionDrift on a mismatch. It returns
+// ErrDimensionDrift only when WithFailOnDimensionDrift is set.
+func (s *Service) checkDimensions(ctx context.Context, requestID, provider string, resp *EmbeddingResponse) error {
+	dims := resp.Dimensions
+	if len(resp.Vectors) > 0 {
+		dims = len(resp.Vectors[0])
+	}
+	if dims <= 0 {
+		return nil
+	}
+
+	observed := int(s.observedDims.Load())
+	if observed == 0 {
+		if s.observedDims.CompareAndSwap(0, int64(dims)) {
+			return nil
+		}
+		observed = int(s.observedDims.Load())
+	}
+	if dims == observed {
+		return nil
+	}
+
+	emitDimensionDrift(ctx, requestID, provider, observed, dims)
+	if s.failOnDrift {
+		return fmt.Errorf("%w: expected %d dimensions, got %d", ErrDimensionDrift, observed, dims)
+	}
+	return nil
+}
+
+// instruct returns chunks formatted with the service's instruction, or
+// chunks itself if none is set.
+func (s *Service) instruct(chunks []string) []string {
+	if s.instruction == "" {
+		return chunks
+	}
+	template := s.instructionTemplate()
+	formatted := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		formatted[i] = strings.NewReplacer("{instruction}", s.instruction, "{text}", chunk).Replace(template)
+	}
+	return formatted
+}
+
+// instructionTemplate returns the instruction template with the default
+// applied.
+func (s *Service) instructionTemplate() string {
+	if s.instructTmpl == "" {
+		return DefaultInstructionTemplate
+	}
+	return s.instructTmpl
+}
+
+// sortByLength returns a copy of chunks sorted by length, shortest first,
+// and the original index of each sorted chunk.
+func sortByLength(chunks []string) ([]string, []int) {
+	order := make([]int, len(chunks))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return len(chunks[a]) - len(chunks[b])
+	})
+	return permute(chunks, order), order
+}
+
+// permute returns items reordered so position i holds items[order[i]].
+func permute[T any](items []T, order []int) []T {
+	result := make([]T, len(items))
+	for i, idx := range order {
+		result[i] = items[idx]
+	}
+	return result
+}
+
+// unsortResponse returns a copy of resp with its embeddings moved back to
+// their original positions. Embedding slices whose length does not match
+// order are left as returned.
+func unsortResponse(resp *EmbeddingResponse, order []int) *EmbeddingResponse {
+	restored := *resp
+	restored.Vectors = unsort(resp.Vectors, order)
+	restored.Int8Vectors = unsort(resp.Int8Vectors, order)
+	restored.BinaryVectors = unsort(resp.BinaryVectors, order)
+	return &restored
+}
+
+// unsortBatchError returns berr with its failures and partial vectors moved
+// back to their original positions. Each sorted failure range is split into
+// single-input failures, since its inputs need not be adjacent once restored.
+func unsortBatchError(berr *BatchError, order []int) *BatchError {
+	var failures []BatchFailure
+	for _, f := range berr.Failures {
+		for k := f.Start; k < f.End && k < len(order); k++ {
+			failures = append(failures, BatchFailure{Err: f.Err, Start: order[k], End: order[k] + 1})
+		}
+	}
+	slices.SortFunc(failures, func(a, b BatchFailure) int { return a.Start - b.Start })
+	return NewBatchError(failures, unsort(berr.Partial(), order))
+}
+
+func unsort[T any](sorted []T, order []int) []T {
+	if len(sorted) != len(order) {
+		return sorted
+	}
+	result := make([]T, len(sorted))
+	for i, idx := range order {
+		result[idx] = sorted[i]
+	}
+	return result
+}
+
+// requestedModel returns the provider's requested model, if it reports one.
+func requestedModel(provider Provider) string {
+	if mr, ok := provider.(ModelReporter); ok {
+		return mr.Model()
+	}
+	return ""
+}
+
+// limitInputs enforces maxInputChars on chunks according to the truncation
+// mode, truncating in place. Chunks are owned by the service, so callers of
+// EmbedChunks see the text that was actually embedded.
+func (s *Service) limitInputs(ctx context.Context, requestID, provider string, chunks []string) error {
+	if s.maxInputChars <= 0 {
+		return nil
+	}
+	for i, chunk := range chunks {
+		// Byte length bounds rune count, so short chunks skip counting.
+		if len(chunk) <= s.maxInputChars {
+			continue
+		}
+		n := utf8.RuneCountInString(chunk)
+		if n <= s.maxInputChars {
+			continue
+		}
+
+		switch s.truncation {
+		case TruncateTail:
+			runes := []rune(chunk)
+			chunks[i] = string(runes[:s.maxInputChars])
+		case TruncateHead:
+			runes := []rune(chunk)
+			chunks[i] = string(runes[n-s.maxInputChars:])
+		default:
+			return fmt.Errorf("%w: chunk %d has %d characters, limit is %d", ErrInputTooLong, i, n, s.maxInputChars)
+		}
+		emitInputTruncated(ctx, requestID, provider, i, n, s.maxInputChars)
+	}
+	return nil
 }
 
 // poolChunks combines chunk vectors back into per-text vectors.
-func (s *Service) poolChunks(texts []string, chunkVectors []Vector, mapping []int) []Vector {
+// Weights apply to mean pooling and are ignored by other modes.
+// Returns an error wrapping ErrDimensionMismatch if a text's chunk vectors
+// differ in length.
+func (s *Service) poolChunks(texts []string, chunkVectors []Vector, mapping []int, weights []float64) ([]Vector, error) {
 	result := make([]Vector, len(texts))
 
 	// Group vectors by original text index
 	grouped := make([][]Vector, len(texts))
+	groupedWeights := make([][]float64, len(texts))
+	weighted := false
 	for i, vec := range chunkVectors {
 		if i < len(mapping) {
 			textIdx := mapping[i]
 			grouped[textIdx] = append(grouped[textIdx], vec)
+			weight := 1.0
+			if i < len(weights) {
+				weight = weights[i]
+			}
+			weighted = weighted || weight != 1
+			groupedWeights[textIdx] = append(groupedWeights[textIdx], weight)
 		}
 	}
 
 	// Pool each group
 	for i, vecs := range grouped {
-		if len(vecs) > 0 {
-			result[i] = Pool(vecs, s.poolingMode)
+		if len(vecs) == 0 {
+			continue
+		}
+		mode := s.poolingMode
+		if mode == PoolAdaptive {
+			mode = adaptivePoolingMode(len(vecs), s.adaptiveLimit)
+		}
+		if weighted && mode == PoolMean && len(vecs) > 1 {
+			if err := checkDims(vecs); err != nil {
+				return nil, fmt.Errorf("text %d: %w", i, err)
+			}
+			result[i] = poolWeightedMean(vecs, groupedWeights[i])
+			continue
 		}
+		pooled, err := PoolStrict(vecs, mode)
+		if err != nil {
+			return nil, fmt.Errorf("text %d: %w", i, err)
+		}
+		result[i] = pooled
 	}
 
-	return result
+	return result, nil
 }
 
 // Dimensions returns the output vector dimensionality from the provider.
@@ -289,7 +1136,56 @@ func (s *Service) Dimensions() int {
 	return s.provider.Dimensions()
 }
 
+// AssertCompatibleDimensions returns an error wrapping ErrDimensionMismatch
+// if the services do not all report the same Dimensions. Call it at startup
+// before combining services with WithFallback, whose vectors would otherwise
+// be mixed with the primary's only once it fails over.
+func AssertCompatibleDimensions(services ...*Service) error {
+	if len(services) == 0 {
+		return nil
+	}
+	dims := services[0].Dimensions()
+	for i, svc := range services[1:] {
+		if d := svc.Dimensions(); d != dims {
+			return fmt.Errorf("%w: service %d reports %d dimensions, service 0 reports %d", ErrDimensionMismatch, i+1, d, dims)
+		}
+	}
+	return nil
+}
+
 // Provider returns the underlying embedding provider.
 func (s *Service) Provider() Provider {
 	return s.provider
 }
+
+// ReadOnly returns an Embedder view of the service for handing to code that
+// must not reconfigure it. The view is a distinct type, so it cannot be
+// asserted back to *Service to reach WithChunker, WithNormalize, and friends.
+func (s *Service) ReadOnly() Embedder {
+	return readOnlyService{s: s}
+}
+
+// readOnlyService exposes only the Embedder methods of a Service.
+type readOnlyService struct {
+	s *Service
+}
+
+func (r readOnlyService) Embed(ctx context.Context, text string) (Vector, error) {
+	return r.s.Embed(ctx, text)
+}
+
+func (r readOnlyService) EmbedQuery(ctx context.Context, text string) (Vector, error) {
+	return r.s.EmbedQuery(ctx, text)
+}
+
+func (r readOnlyService) Batch(ctx context.Context, texts []string) ([]Vector, error) {
+	return r.s.Batch(ctx, texts)
+}
+
+func (r readOnlyService) BatchQuery(ctx context.Context, texts []string) ([]Vector, error) {
+	return r.s.BatchQuery(ctx, texts)
+}
+
+func (r readOnlyService) Dimensions() int {
+	return r.s.Dimensions()
+}
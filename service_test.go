@@ -3,7 +3,17 @@ package vex
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/zoobzio/pipz"
 )
 
 // mockProvider is a simple test provider.
@@ -224,6 +234,53 @@ func TestService_BatchQuery(t *testing.T) {
 	})
 }
 
+func TestService_SupportsQueryMode(t *testing.T) {
+	t.Run("query provider", func(t *testing.T) {
+		provider := newMockQueryProvider(8)
+		provider.name = "query-mode"
+		events := recordEvents(t, QueryModeFallback, provider.name)
+		svc := NewService(provider)
+		if !svc.SupportsQueryMode() {
+			t.Error("expected query mode support")
+		}
+		if _, err := svc.EmbedQuery(context.Background(), "query"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := events.Events(t); len(got) != 0 {
+			t.Errorf("expected no fallback signal, got %d", len(got))
+		}
+	})
+
+	t.Run("document-only provider signals fallback once", func(t *testing.T) {
+		provider := newMockProvider(8)
+		provider.name = "query-fallback"
+		events := recordEvents(t, QueryModeFallback, provider.name)
+		svc := NewService(provider)
+		if svc.SupportsQueryMode() {
+			t.Error("expected no query mode support")
+		}
+
+		query, err := svc.EmbedQuery(context.Background(), "query")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := svc.BatchQuery(context.Background(), []string{"a", "b"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := events.Events(t); len(got) != 1 {
+			t.Errorf("expected 1 fallback signal, got %d", len(got))
+		}
+
+		doc, err := svc.Embed(context.Background(), "query")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !query.Equal(doc) {
+			t.Error("expected the fallback to return the document embedding")
+		}
+	})
+}
+
 func TestService_WithNormalize(t *testing.T) {
 	t.Run("can disable normalization", func(t *testing.T) {
 		provider := newMockProvider(256)
@@ -240,6 +297,79 @@ func TestService_WithNormalize(t *testing.T) {
 	})
 }
 
+func TestService_EmbedRaw(t *testing.T) {
+	t.Run("skips normalization when service normalizes", func(t *testing.T) {
+		provider := newMockProvider(256)
+		svc := NewService(provider)
+
+		raw, err := svc.EmbedRaw(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// The mock returns a non-unit vector
+		expected, err := provider.Embed(context.Background(), []string{"test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if raw.Norm() != expected.Vectors[0].Norm() {
+			t.Errorf("expected raw norm %f, got %f", expected.Vectors[0].Norm(), raw.Norm())
+		}
+	})
+
+	t.Run("leaves service normalization intact", func(t *testing.T) {
+		svc := NewService(newMockProvider(256))
+
+		if _, err := svc.EmbedRaw(context.Background(), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		vec, err := svc.Embed(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		norm := vec.Norm()
+		if norm < 0.99 || norm > 1.01 {
+			t.Errorf("expected normalized vector from Embed, got norm %f", norm)
+		}
+	})
+}
+
+func TestService_BatchRaw(t *testing.T) {
+	t.Run("pools chunks without normalizing", func(t *testing.T) {
+		provider := newMockProvider(4)
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true}
+		svc := NewService(provider).WithChunker(chunker)
+
+		vecs, err := svc.BatchRaw(context.Background(), []string{"One. Two.", "Three."})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(vecs) != 2 {
+			t.Fatalf("expected 2 vectors, got %d", len(vecs))
+		}
+
+		// Mock vectors are identical, so the pooled mean equals one raw vector
+		expected := Vector{0, 0.25, 0.5, 0.75}
+		for i, v := range vecs[0] {
+			if v != expected[i] {
+				t.Errorf("at index %d: expected %f, got %f", i, expected[i], v)
+			}
+		}
+	})
+
+	t.Run("handles empty input", func(t *testing.T) {
+		svc := NewService(newMockProvider(256))
+
+		vecs, err := svc.BatchRaw(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vecs != nil {
+			t.Error("expected nil for empty input")
+		}
+	})
+}
+
 func TestService_WithChunker(t *testing.T) {
 	t.Run("applies chunking", func(t *testing.T) {
 		provider := newMockProvider(256)
@@ -263,186 +393,1257 @@ func TestService_WithChunker(t *testing.T) {
 	})
 }
 
-func TestService_WithPooling(t *testing.T) {
-	t.Run("can change pooling mode", func(t *testing.T) {
-		provider := newMockProvider(256)
-		svc := NewService(provider).WithPooling(PoolMax)
+func TestService_EmbedChunks(t *testing.T) {
+	t.Run("returns a vector per chunk", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true}
+		svc := NewService(newMockProvider(8)).WithChunker(chunker)
 
-		// Just verify it runs without error
-		_, err := svc.Embed(context.Background(), "test")
+		vecs, chunks, err := svc.EmbedChunks(context.Background(), "First. Second. Third.")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
+		if len(vecs) != 3 || len(chunks) != 3 {
+			t.Fatalf("expected 3 vectors and chunks, got %d and %d", len(vecs), len(chunks))
+		}
+		if chunks[1] != "Second." {
+			t.Errorf("expected chunk 'Second.', got %q", chunks[1])
+		}
+		for i, vec := range vecs {
+			if norm := vec.Norm(); norm < 0.99 || norm > 1.01 {
+				t.Errorf("chunk %d: expected normalized vector, got norm %f", i, norm)
+			}
+		}
 	})
-}
 
-func TestService_Dimensions(t *testing.T) {
-	dims := 1024
-	provider := newMockProvider(dims)
-	svc := NewService(provider)
+	t.Run("skips normalization when disabled", func(t *testing.T) {
+		svc := NewService(newMockProvider(8)).WithNormalize(false)
 
-	if svc.Dimensions() != dims {
-		t.Errorf("expected %d, got %d", dims, svc.Dimensions())
-	}
-}
+		vecs, _, err := svc.EmbedChunks(context.Background(), "text")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if norm := vecs[0].Norm(); norm > 0.99 && norm < 1.01 {
+			t.Errorf("expected raw vector, got norm %f", norm)
+		}
+	})
 
-func TestService_Provider(t *testing.T) {
-	provider := newMockProvider(256)
-	svc := NewService(provider)
+	t.Run("propagates provider errors", func(t *testing.T) {
+		provider := newMockProvider(8)
+		provider.err = errors.New("provider error")
+		svc := NewService(provider)
 
-	if svc.Provider() != provider {
-		t.Error("expected same provider instance")
-	}
-}
+		if _, _, err := svc.EmbedChunks(context.Background(), "text"); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
 
-func TestService_GetPipeline(t *testing.T) {
-	provider := newMockProvider(256)
-	svc := NewService(provider)
+	t.Run("handles empty text", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true}
+		svc := NewService(newMockProvider(8)).WithChunker(chunker)
 
-	if svc.GetPipeline() == nil {
-		t.Error("expected non-nil pipeline")
-	}
+		vecs, chunks, err := svc.EmbedChunks(context.Background(), "   ")
+		if err != nil || vecs != nil || chunks != nil {
+			t.Errorf("expected nil results, got %v, %v, %v", vecs, chunks, err)
+		}
+	})
 }
 
-// mockEmptyProvider returns empty responses.
-type mockEmptyProvider struct {
-	*mockProvider
-}
+// quantizedProvider returns only quantized embeddings.
+type quantizedProvider struct{}
 
-func (p *mockEmptyProvider) Embed(_ context.Context, _ []string) (*EmbeddingResponse, error) {
-	p.callCount++
-	return &EmbeddingResponse{
-		Vectors: nil,
-		Model:   "mock",
-	}, nil
+func (*quantizedProvider) Name() string    { return "quantized" }
+func (*quantizedProvider) Dimensions() int { return 8 }
+func (*quantizedProvider) Embed(_ context.Context, texts []string) (*EmbeddingResponse, error) {
+	resp := &EmbeddingResponse{Dimensions: 8}
+	for range texts {
+		resp.Int8Vectors = append(resp.Int8Vectors, []int8{-128, 0, 5, 127, 1, 2, 3, 4})
+		resp.BinaryVectors = append(resp.BinaryVectors, []byte{0xA5})
+	}
+	return resp, nil
 }
 
-// mockEmptyQueryProvider returns empty responses and implements QueryProviderFactory.
-type mockEmptyQueryProvider struct {
-	*mockEmptyProvider
-}
+func TestService_BatchResponse(t *testing.T) {
+	t.Run("passes quantized vectors through", func(t *testing.T) {
+		svc := NewService(&quantizedProvider{}).WithNormalize(false)
 
-func (p *mockEmptyQueryProvider) ForQuery() Provider {
-	return p
-}
+		resp, err := svc.BatchResponse(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Int8Vectors) != 2 || resp.Int8Vectors[1][0] != -128 || resp.Int8Vectors[1][3] != 127 {
+			t.Errorf("unexpected int8 vectors: %v", resp.Int8Vectors)
+		}
+		if len(resp.BinaryVectors) != 2 || resp.BinaryVectors[0][0] != 0xA5 {
+			t.Errorf("unexpected binary vectors: %v", resp.BinaryVectors)
+		}
+		if resp.Vectors != nil {
+			t.Errorf("expected no float vectors, got %v", resp.Vectors)
+		}
+	})
 
-// mockErrorQueryProvider returns errors and implements QueryProviderFactory.
-type mockErrorQueryProvider struct {
-	*mockProvider
-	err error
-}
+	t.Run("normalizes float vectors when enabled", func(t *testing.T) {
+		svc := NewService(newMockProvider(8))
 
-func newMockErrorQueryProvider(dims int, err error) *mockErrorQueryProvider {
-	return &mockErrorQueryProvider{
-		mockProvider: newMockProvider(dims),
-		err:          err,
-	}
-}
+		resp, err := svc.BatchResponse(context.Background(), []string{"a"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if norm := resp.Vectors[0].Norm(); norm < 0.99 || norm > 1.01 {
+			t.Errorf("expected normalized vector, got norm %f", norm)
+		}
+	})
 
-func (p *mockErrorQueryProvider) Embed(_ context.Context, _ []string) (*EmbeddingResponse, error) {
-	p.callCount++
-	return nil, p.err
-}
+	t.Run("does not chunk texts", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true}
+		svc := NewService(newMockProvider(8)).WithChunker(chunker)
 
-func (p *mockErrorQueryProvider) ForQuery() Provider {
-	return p
-}
+		resp, err := svc.BatchResponse(context.Background(), []string{"First. Second."})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Vectors) != 1 {
+			t.Errorf("expected 1 vector, got %d", len(resp.Vectors))
+		}
+	})
 
-func TestService_Embed_EmptyResponse(t *testing.T) {
-	provider := &mockEmptyProvider{mockProvider: newMockProvider(256)}
-	svc := NewService(provider)
+	t.Run("carries provider warnings", func(t *testing.T) {
+		svc := NewService(&warningProvider{newMockProvider(8)})
 
-	vec, err := svc.Embed(context.Background(), "test")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if vec != nil {
-		t.Error("expected nil vector for empty response")
-	}
-}
+		resp, err := svc.BatchResponse(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(resp.Warnings, []string{"input 1 truncated"}) {
+			t.Errorf("expected provider warning, got %q", resp.Warnings)
+		}
+	})
 
-func TestService_EmbedQuery_Error(t *testing.T) {
-	expectedErr := errors.New("query provider error")
-	provider := newMockErrorQueryProvider(256, expectedErr)
-	svc := NewService(provider)
+	t.Run("handles empty input", func(t *testing.T) {
+		svc := NewService(newMockProvider(8))
 
-	_, err := svc.EmbedQuery(context.Background(), "query")
-	if err == nil {
-		t.Error("expected error, got nil")
-	}
+		resp, err := svc.BatchResponse(context.Background(), nil)
+		if err != nil || resp != nil {
+			t.Errorf("expected nil response, got %v, %v", resp, err)
+		}
+	})
 }
 
-func TestService_EmbedQuery_EmptyResponse(t *testing.T) {
-	provider := &mockEmptyQueryProvider{
-		mockEmptyProvider: &mockEmptyProvider{mockProvider: newMockProvider(256)},
-	}
-	svc := NewService(provider)
+// warningProvider reports a truncation warning for the last input.
+type warningProvider struct {
+	*mockProvider
+}
 
-	vec, err := svc.EmbedQuery(context.Background(), "query")
+func (p *warningProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	resp, err := p.mockProvider.Embed(ctx, texts)
 	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if vec != nil {
-		t.Error("expected nil vector for empty response")
+		return nil, err
 	}
+	resp.Warnings = []string{fmt.Sprintf("input %d truncated", len(texts)-1)}
+	return resp, nil
 }
 
-func TestService_Batch_EmptyResponse(t *testing.T) {
-	provider := &mockEmptyProvider{mockProvider: newMockProvider(256)}
-	svc := NewService(provider)
-
-	vecs, err := svc.Batch(context.Background(), []string{"test"})
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if vecs != nil {
-		t.Error("expected nil vectors for empty response")
-	}
+// lengthProvider embeds each text as a one-dimensional vector holding its
+// length, and records the texts it was sent.
+type lengthProvider struct {
+	sent []string
 }
 
-func TestService_BatchQuery_Error(t *testing.T) {
-	expectedErr := errors.New("query provider error")
-	provider := newMockErrorQueryProvider(256, expectedErr)
-	svc := NewService(provider)
+func (*lengthProvider) Name() string    { return "length" }
+func (*lengthProvider) Dimensions() int { return 1 }
 
-	_, err := svc.BatchQuery(context.Background(), []string{"query"})
-	if err == nil {
-		t.Error("expected error, got nil")
+func (p *lengthProvider) Embed(_ context.Context, texts []string) (*EmbeddingResponse, error) {
+	p.sent = append(p.sent, texts...)
+	vectors := make([]Vector, len(texts))
+	for i, text := range texts {
+		vectors[i] = Vector{float32(len(text))}
 	}
+	return &EmbeddingResponse{Vectors: vectors, Dimensions: 1}, nil
 }
 
-func TestService_BatchQuery_EmptyResponse(t *testing.T) {
-	provider := &mockEmptyQueryProvider{
-		mockEmptyProvider: &mockEmptyProvider{mockProvider: newMockProvider(256)},
-	}
-	svc := NewService(provider)
+func TestService_WithLengthSortedBatching(t *testing.T) {
+	texts := []string{"medium text", "a", "the longest text of them all", "short", "mid-size"}
 
-	vecs, err := svc.BatchQuery(context.Background(), []string{"query"})
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if vecs != nil {
-		t.Error("expected nil vectors for empty response")
-	}
-}
+	t.Run("sends sorted and returns original order", func(t *testing.T) {
+		provider := &lengthProvider{}
+		svc := NewService(provider).WithNormalize(false).WithLengthSortedBatching()
 
-func TestService_NewService_WithOptionsAndQueryProvider(t *testing.T) {
-	provider := newMockQueryProvider(256)
+		vectors, err := svc.Batch(context.Background(), texts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 
-	// Apply an option to verify it's applied to both pipelines
-	svc := NewService(provider, WithRetry(3))
+		if !slices.IsSortedFunc(provider.sent, func(a, b string) int { return len(a) - len(b) }) {
+			t.Errorf("expected provider to receive texts sorted by length, got %q", provider.sent)
+		}
+		for i, text := range texts {
+			if vectors[i][0] != float32(len(text)) {
+				t.Errorf("vector %d: expected %d, got %v", i, len(text), vectors[i][0])
+			}
+		}
+	})
 
-	// Both pipelines should be configured
-	if svc.GetPipeline() == nil {
-		t.Error("expected non-nil pipeline")
-	}
+	t.Run("chunks keep their order", func(t *testing.T) {
+		provider := &lengthProvider{}
+		svc := NewService(provider).
+			WithNormalize(false).
+			WithChunker(&Chunker{Strategy: ChunkSentence, TrimSpace: true}).
+			WithLengthSortedBatching()
 
-	// Query path should work
-	vec, err := svc.EmbedQuery(context.Background(), "test")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+		vectors, chunks, err := svc.EmbedChunks(context.Background(), "A fairly long first sentence. Short. Middling one here.")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []string{"A fairly long first sentence.", "Short.", "Middling one here."}
+		if !slices.Equal(chunks, want) {
+			t.Errorf("expected chunks %q, got %q", want, chunks)
+		}
+		for i, chunk := range chunks {
+			if vectors[i][0] != float32(len(chunk)) {
+				t.Errorf("chunk %d: expected %d, got %v", i, len(chunk), vectors[i][0])
+			}
+		}
+	})
+
+	t.Run("keeps instructions", func(t *testing.T) {
+		provider := &lengthProvider{}
+		svc := NewService(provider).WithInstruction("Find").WithLengthSortedBatching()
+
+		if _, err := svc.Batch(context.Background(), []string{"longer text", "short"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []string{"Instruct: Find\nQuery: short", "Instruct: Find\nQuery: longer text"}
+		if !slices.Equal(provider.sent, want) {
+			t.Errorf("expected %q, got %q", want, provider.sent)
+		}
+	})
+}
+
+func TestService_WithInstruction(t *testing.T) {
+	t.Run("formats every input", func(t *testing.T) {
+		provider := &lengthProvider{}
+		svc := NewService(provider).WithInstruction("Retrieve relevant passages")
+
+		if _, err := svc.Batch(context.Background(), []string{"first", "second"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []string{
+			"Instruct: Retrieve relevant passages\nQuery: first",
+			"Instruct: Retrieve relevant passages\nQuery: second",
+		}
+		if !slices.Equal(provider.sent, want) {
+			t.Errorf("expected %q, got %q", want, provider.sent)
+		}
+	})
+
+	t.Run("custom template", func(t *testing.T) {
+		provider := &lengthProvider{}
+		svc := NewService(provider).
+			WithInstruction("Represent the question").
+			WithInstructionTemplate("{instruction}: {text}")
+
+		if _, err := svc.Embed(context.Background(), "why is the sky blue {text}"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []string{"Represent the question: why is the sky blue {text}"}
+		if !slices.Equal(provider.sent, want) {
+			t.Errorf("expected %q, got %q", want, provider.sent)
+		}
+	})
+
+	t.Run("chunks are returned unformatted", func(t *testing.T) {
+		provider := &lengthProvider{}
+		svc := NewService(provider).
+			WithChunker(&Chunker{Strategy: ChunkSentence, TrimSpace: true}).
+			WithInstruction("Find").
+			WithInstructionTemplate("{instruction}: {text}")
+
+		_, chunks, err := svc.EmbedChunks(context.Background(), "One. Two.")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(chunks, []string{"One.", "Two."}) {
+			t.Errorf("expected unformatted chunks, got %q", chunks)
+		}
+		if !slices.Equal(provider.sent, []string{"Find: One.", "Find: Two."}) {
+			t.Errorf("expected formatted chunks sent, got %q", provider.sent)
+		}
+	})
+
+	t.Run("empty instruction sends text as is", func(t *testing.T) {
+		provider := &lengthProvider{}
+		svc := NewService(provider).WithInstruction("")
+
+		if _, err := svc.Embed(context.Background(), "plain"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(provider.sent, []string{"plain"}) {
+			t.Errorf("expected plain text, got %q", provider.sent)
+		}
+	})
+}
+
+func TestWithProviderOverride(t *testing.T) {
+	t.Run("calls the override", func(t *testing.T) {
+		bound := newMockProvider(8)
+		override := newMockProvider(8)
+		svc := NewService(bound)
+
+		ctx := WithProviderOverride(context.Background(), override)
+		if _, err := svc.Embed(ctx, "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := svc.EmbedQuery(ctx, "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if override.callCount != 2 || bound.callCount != 0 {
+			t.Errorf("expected override called twice and bound never, got %d and %d", override.callCount, bound.callCount)
+		}
+
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if bound.callCount != 1 {
+			t.Errorf("expected bound provider without override, got %d calls", bound.callCount)
+		}
+	})
+
+	t.Run("options wrap the override", func(t *testing.T) {
+		override := &retryTestProvider{failUntil: 1, dims: 8}
+		svc := NewService(newMockProvider(8), WithRetry(2))
+
+		ctx := WithProviderOverride(context.Background(), override)
+		if _, err := svc.Embed(ctx, "test"); err != nil {
+			t.Fatalf("expected retry to recover, got %v", err)
+		}
+		if override.calls != 2 {
+			t.Errorf("expected 2 calls to override, got %d", override.calls)
+		}
+	})
+
+	t.Run("fallback uses its own provider", func(t *testing.T) {
+		backup := newMockProvider(8)
+		override := &erroringProvider{err: errors.New("down")}
+		svc := NewService(newMockProvider(8), WithFallback(NewService(backup)))
+
+		ctx := WithProviderOverride(context.Background(), override)
+		if _, err := svc.Embed(ctx, "test"); err != nil {
+			t.Fatalf("expected fallback to succeed, got %v", err)
+		}
+		if override.calls != 1 || backup.callCount != 1 {
+			t.Errorf("expected override then backup called once, got %d and %d", override.calls, backup.callCount)
+		}
+	})
+
+	t.Run("hooks report the override", func(t *testing.T) {
+		override := newMockProvider(8)
+		override.name = "override-hooks"
+		events := recordEvents(t, EmbedCompleted, override.name)
+		svc := NewService(newMockProvider(8))
+
+		if _, err := svc.Embed(WithProviderOverride(context.Background(), override), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := events.Events(t); len(got) != 1 {
+			t.Errorf("expected 1 EmbedCompleted for the override, got %d", len(got))
+		}
+	})
+}
+
+func TestService_WithPooling(t *testing.T) {
+	t.Run("can change pooling mode", func(t *testing.T) {
+		provider := newMockProvider(256)
+		svc := NewService(provider).WithPooling(PoolMax)
+
+		// Just verify it runs without error
+		_, err := svc.Embed(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestService_WithAdaptivePooling(t *testing.T) {
+	svc := NewService(&lengthProvider{}).
+		WithNormalize(false).
+		WithChunker(&Chunker{Strategy: ChunkSentence, TrimSpace: true}).
+		WithAdaptivePooling(2)
+
+	// lengthProvider embeds each chunk as its length.
+	vectors, err := svc.Batch(context.Background(), []string{"A. BBB.", "A. BBB. CC."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vectors[0][0] != 3 {
+		t.Errorf("expected 2 chunks to be mean-pooled to 3, got %v", vectors[0][0])
+	}
+	if vectors[1][0] != 4 {
+		t.Errorf("expected 3 chunks to be max-pooled to 4, got %v", vectors[1][0])
+	}
+}
+
+// unitProvider returns unit vectors, alternating between two axes, and
+// keeps the last vectors it returned.
+type unitProvider struct {
+	last []Vector
+}
+
+func (*unitProvider) Name() string    { return "unit" }
+func (*unitProvider) Dimensions() int { return 2 }
+func (p *unitProvider) Embed(_ context.Context, texts []string) (*EmbeddingResponse, error) {
+	p.last = make([]Vector, len(texts))
+	for i := range texts {
+		p.last[i] = Vector{float32(i % 2), float32((i + 1) % 2)}
+	}
+	return &EmbeddingResponse{Vectors: p.last, Dimensions: 2}, nil
+}
+
+func TestService_SkipsRedundantNormalization(t *testing.T) {
+	t.Run("passes unit vectors through", func(t *testing.T) {
+		provider := &unitProvider{}
+		svc := NewService(provider)
+
+		vectors, err := svc.Batch(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, v := range vectors {
+			if &v[0] != &provider.last[i][0] {
+				t.Errorf("vector %d: expected provider output to be returned without renormalizing", i)
+			}
+		}
+	})
+
+	t.Run("normalizes pooled vectors", func(t *testing.T) {
+		provider := &unitProvider{}
+		svc := NewService(provider).WithChunker(&Chunker{Strategy: ChunkSentence, TrimSpace: true})
+
+		vectors, err := svc.Batch(context.Background(), []string{"First. Second."})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if norm := vectors[0].Norm(); math.Abs(norm-1) > 1e-6 {
+			t.Errorf("expected pooled vector to be normalized, got norm %v", norm)
+		}
+	})
+
+	t.Run("normalizes providers that fail the probe", func(t *testing.T) {
+		provider := newMockProvider(4)
+		svc := NewService(provider)
+
+		vector, err := svc.Embed(context.Background(), "text")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if norm := vector.Norm(); math.Abs(norm-1) > 1e-6 {
+			t.Errorf("expected normalized vector, got norm %v", norm)
+		}
+	})
+}
+
+func TestService_Dimensions(t *testing.T) {
+	dims := 1024
+	provider := newMockProvider(dims)
+	svc := NewService(provider)
+
+	if svc.Dimensions() != dims {
+		t.Errorf("expected %d, got %d", dims, svc.Dimensions())
+	}
+}
+
+func TestService_Provider(t *testing.T) {
+	provider := newMockProvider(256)
+	svc := NewService(provider)
+
+	if svc.Provider() != provider {
+		t.Error("expected same provider instance")
+	}
+}
+
+func TestService_GetPipeline(t *testing.T) {
+	provider := newMockProvider(256)
+	svc := NewService(provider)
+
+	if svc.GetPipeline() == nil {
+		t.Error("expected non-nil pipeline")
+	}
+}
+
+// mockEmptyProvider returns empty responses.
+type mockEmptyProvider struct {
+	*mockProvider
+}
+
+func (p *mockEmptyProvider) Embed(_ context.Context, _ []string) (*EmbeddingResponse, error) {
+	p.callCount++
+	return &EmbeddingResponse{
+		Vectors: nil,
+		Model:   "mock",
+	}, nil
+}
+
+// mockEmptyQueryProvider returns empty responses and implements QueryProviderFactory.
+type mockEmptyQueryProvider struct {
+	*mockEmptyProvider
+}
+
+func (p *mockEmptyQueryProvider) ForQuery() Provider {
+	return p
+}
+
+// mockErrorQueryProvider returns errors and implements QueryProviderFactory.
+type mockErrorQueryProvider struct {
+	*mockProvider
+	err error
+}
+
+func newMockErrorQueryProvider(dims int, err error) *mockErrorQueryProvider {
+	return &mockErrorQueryProvider{
+		mockProvider: newMockProvider(dims),
+		err:          err,
+	}
+}
+
+func (p *mockErrorQueryProvider) Embed(_ context.Context, _ []string) (*EmbeddingResponse, error) {
+	p.callCount++
+	return nil, p.err
+}
+
+func (p *mockErrorQueryProvider) ForQuery() Provider {
+	return p
+}
+
+func TestService_Embed_EmptyResponse(t *testing.T) {
+	provider := &mockEmptyProvider{mockProvider: newMockProvider(256)}
+	svc := NewService(provider)
+
+	vec, err := svc.Embed(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vec != nil {
+		t.Error("expected nil vector for empty response")
+	}
+}
+
+func TestService_EmbedQuery_Error(t *testing.T) {
+	expectedErr := errors.New("query provider error")
+	provider := newMockErrorQueryProvider(256, expectedErr)
+	svc := NewService(provider)
+
+	_, err := svc.EmbedQuery(context.Background(), "query")
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestService_EmbedQuery_EmptyResponse(t *testing.T) {
+	provider := &mockEmptyQueryProvider{
+		mockEmptyProvider: &mockEmptyProvider{mockProvider: newMockProvider(256)},
+	}
+	svc := NewService(provider)
+
+	vec, err := svc.EmbedQuery(context.Background(), "query")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vec != nil {
+		t.Error("expected nil vector for empty response")
+	}
+}
+
+func TestService_Batch_EmptyResponse(t *testing.T) {
+	provider := &mockEmptyProvider{mockProvider: newMockProvider(256)}
+	svc := NewService(provider)
+
+	vecs, err := svc.Batch(context.Background(), []string{"test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vecs != nil {
+		t.Error("expected nil vectors for empty response")
+	}
+}
+
+func TestService_BatchQuery_Error(t *testing.T) {
+	expectedErr := errors.New("query provider error")
+	provider := newMockErrorQueryProvider(256, expectedErr)
+	svc := NewService(provider)
+
+	_, err := svc.BatchQuery(context.Background(), []string{"query"})
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestService_BatchQuery_EmptyResponse(t *testing.T) {
+	provider := &mockEmptyQueryProvider{
+		mockEmptyProvider: &mockEmptyProvider{mockProvider: newMockProvider(256)},
+	}
+	svc := NewService(provider)
+
+	vecs, err := svc.BatchQuery(context.Background(), []string{"query"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vecs != nil {
+		t.Error("expected nil vectors for empty response")
+	}
+}
+
+func TestService_NewService_WithOptionsAndQueryProvider(t *testing.T) {
+	provider := newMockQueryProvider(256)
+
+	// Apply an option to verify it's applied to both pipelines
+	svc := NewService(provider, WithRetry(3))
+
+	// Both pipelines should be configured
+	if svc.GetPipeline() == nil {
+		t.Error("expected non-nil pipeline")
+	}
+
+	// Query path should work
+	vec, err := svc.EmbedQuery(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 	if vec == nil {
 		t.Error("expected vector, got nil")
 	}
 }
+
+func TestService_ReadOnly(t *testing.T) {
+	var _ Embedder = (*Service)(nil)
+
+	svc := NewService(newMockProvider(8))
+	ro := svc.ReadOnly()
+
+	t.Run("cannot be asserted back to Service", func(t *testing.T) {
+		if _, ok := ro.(*Service); ok {
+			t.Error("read-only view should not be a *Service")
+		}
+		if _, ok := ro.(interface{ WithNormalize(bool) *Service }); ok {
+			t.Error("read-only view should not expose builder methods")
+		}
+	})
+
+	t.Run("delegates to service", func(t *testing.T) {
+		vec, err := ro.Embed(context.Background(), "test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(vec) != 8 || ro.Dimensions() != 8 {
+			t.Errorf("expected 8 dimensions, got %d and %d", len(vec), ro.Dimensions())
+		}
+
+		vecs, err := ro.BatchQuery(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(vecs) != 2 {
+			t.Errorf("expected 2 vectors, got %d", len(vecs))
+		}
+	})
+}
+
+func TestService_MalformedResponses(t *testing.T) {
+	t.Run("rejects interleaved empty embeddings", func(t *testing.T) {
+		svc := NewService(&raggedProvider{dims: []int{4, 0, 4}})
+
+		_, err := svc.Batch(context.Background(), []string{"a", "b", "c"})
+		var emptyErr *EmptyEmbeddingError
+		if !errors.As(err, &emptyErr) {
+			t.Fatalf("expected EmptyEmbeddingError, got %v", err)
+		}
+		if emptyErr.Provider != "ragged" || emptyErr.Index != 1 {
+			t.Errorf("expected ragged index 1, got %s index %d", emptyErr.Provider, emptyErr.Index)
+		}
+	})
+
+	t.Run("rejects chunks of differing dimensions", func(t *testing.T) {
+		for _, mode := range []PoolingMode{PoolMean, PoolMax, PoolFirst} {
+			svc := NewService(&raggedProvider{dims: []int{4, 3}}).
+				WithChunker(&Chunker{Strategy: ChunkParagraph, TrimSpace: true}).
+				WithPooling(mode)
+
+			_, err := svc.Batch(context.Background(), []string{"first\n\nsecond"})
+			if !errors.Is(err, ErrDimensionMismatch) {
+				t.Errorf("pooling mode %d: expected ErrDimensionMismatch, got %v", mode, err)
+			}
+		}
+	})
+}
+
+// raggedProvider embeds the text at position i as a vector of dims[i] ones,
+// mimicking a gateway that corrupts individual embeddings.
+type raggedProvider struct {
+	dims []int
+}
+
+func (*raggedProvider) Name() string    { return "ragged" }
+func (*raggedProvider) Dimensions() int { return 4 }
+
+func (p *raggedProvider) Embed(_ context.Context, texts []string) (*EmbeddingResponse, error) {
+	vectors := make([]Vector, len(texts))
+	for i := range texts {
+		vectors[i] = make(Vector, p.dims[i])
+		for j := range vectors[i] {
+			vectors[i][j] = 1
+		}
+	}
+	return &EmbeddingResponse{Vectors: vectors, Dimensions: 4}, nil
+}
+
+func (p *lengthProvider) sentTexts() []string { return p.sent }
+
+// titledProvider records the texts and titles it was sent.
+type titledProvider struct {
+	lengthProvider
+	titles []string
+}
+
+func (p *titledProvider) EmbedTitled(ctx context.Context, texts, titles []string) (*EmbeddingResponse, error) {
+	p.titles = append(p.titles, titles...)
+	return p.Embed(ctx, texts)
+}
+
+func TestService_BatchDocuments(t *testing.T) {
+	docs := []Document{
+		{Title: "Guide", Text: "First part.\n\nSecond part."},
+		{Text: "Untitled."},
+	}
+	chunker := &Chunker{Strategy: ChunkParagraph, TrimSpace: true}
+
+	tests := []struct {
+		name     string
+		mode     TitleHandling
+		provider interface {
+			Provider
+			sentTexts() []string
+		}
+		want []string
+	}{
+		{"native falls back to prepend", TitleNative, &lengthProvider{},
+			[]string{"Guide", "First part.", "Second part.", "Untitled."}},
+		{"prepend", TitlePrepend, &lengthProvider{},
+			[]string{"Guide", "First part.", "Second part.", "Untitled."}},
+		{"prepend chunks", TitlePrependChunks, &lengthProvider{},
+			[]string{"Guide\n\nFirst part.", "Guide\n\nSecond part.", "Untitled."}},
+		{"ignore", TitleIgnore, &lengthProvider{},
+			[]string{"First part.", "Second part.", "Untitled."}},
+		{"ignore skips native", TitleIgnore, &titledProvider{},
+			[]string{"First part.", "Second part.", "Untitled."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(tt.provider).WithChunker(chunker).WithTitleHandling(tt.mode)
+
+			vectors, err := svc.BatchDocuments(context.Background(), docs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(vectors) != len(docs) {
+				t.Fatalf("expected %d vectors, got %d", len(docs), len(vectors))
+			}
+			if !slices.Equal(tt.provider.sentTexts(), tt.want) {
+				t.Errorf("expected provider to receive %q, got %q", tt.want, tt.provider.sentTexts())
+			}
+		})
+	}
+
+	t.Run("native", func(t *testing.T) {
+		provider := &titledProvider{}
+		svc := NewService(provider).WithChunker(chunker).WithLengthSortedBatching()
+
+		if _, err := svc.BatchDocuments(context.Background(), docs); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		wantTexts := []string{"Untitled.", "First part.", "Second part."}
+		wantTitles := []string{"", "Guide", "Guide"}
+		if !slices.Equal(provider.sent, wantTexts) || !slices.Equal(provider.titles, wantTitles) {
+			t.Errorf("expected %q with titles %q, got %q with %q", wantTexts, wantTitles, provider.sent, provider.titles)
+		}
+	})
+
+	t.Run("plain batches send no titles", func(t *testing.T) {
+		provider := &titledProvider{}
+		svc := NewService(provider)
+
+		if _, err := svc.Batch(context.Background(), []string{"text"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if provider.titles != nil {
+			t.Errorf("expected no titles, got %q", provider.titles)
+		}
+	})
+}
+
+// requestIDProvider records the request ID seen by each Embed call.
+type requestIDProvider struct {
+	*mockProvider
+	ids []string
+}
+
+func (p *requestIDProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	p.ids = append(p.ids, RequestIDFromContext(ctx))
+	return p.mockProvider.Embed(ctx, texts)
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("expected no request ID outside a request, got %q", id)
+	}
+
+	provider := &requestIDProvider{mockProvider: newMockProvider(8)}
+	svc := NewService(provider)
+	for range 2 {
+		if _, err := svc.Embed(context.Background(), "text"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(provider.ids) != 2 || provider.ids[0] == "" || provider.ids[0] == provider.ids[1] {
+		t.Errorf("expected a distinct request ID per request, got %q", provider.ids)
+	}
+}
+
+func TestAssertCompatibleDimensions(t *testing.T) {
+	small := NewService(newMockProvider(256))
+	large := NewService(newMockProvider(512))
+
+	if err := AssertCompatibleDimensions(small, NewService(newMockProvider(256))); err != nil {
+		t.Errorf("expected matching services to pass, got %v", err)
+	}
+	if err := AssertCompatibleDimensions(); err != nil {
+		t.Errorf("expected no services to pass, got %v", err)
+	}
+
+	err := AssertCompatibleDimensions(small, large)
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected ErrDimensionMismatch, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "512") || !strings.Contains(err.Error(), "256") {
+		t.Errorf("expected error to name both sizes, got %q", err)
+	}
+}
+
+// zeroProvider embeds the text "bad" as an all-zero vector.
+type zeroProvider struct {
+	*mockProvider
+}
+
+func (p *zeroProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	resp, err := p.mockProvider.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	for i, text := range texts {
+		if text == "bad" {
+			resp.Vectors[i] = make(Vector, p.dimensions)
+		}
+	}
+	return resp, nil
+}
+
+func TestService_WithZeroVectorPolicy(t *testing.T) {
+	texts := []string{"good", "bad", "good"}
+	newProvider := func(name string) *zeroProvider {
+		p := &zeroProvider{newMockProvider(4)}
+		p.name = name
+		return p
+	}
+
+	t.Run("allow returns zero vectors and signals", func(t *testing.T) {
+		provider := newProvider("zero-allow")
+		events := recordEvents(t, ZeroVectorDetected, provider.name)
+		svc := NewService(provider)
+
+		vectors, err := svc.Batch(context.Background(), texts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(vectors[1]) != 4 || vectors[1].Norm() != 0 {
+			t.Errorf("expected zero vector to be returned, got %v", vectors[1])
+		}
+		got := events.Events(t)
+		if len(got) != 1 {
+			t.Fatalf("expected 1 zero vector event, got %d", len(got))
+		}
+		if index, _ := InputIndexKey.From(got[0]); index != 1 {
+			t.Errorf("expected input index 1, got %d", index)
+		}
+	})
+
+	t.Run("error names the input", func(t *testing.T) {
+		svc := NewService(newProvider("zero-error")).WithZeroVectorPolicy(ZeroError)
+
+		_, err := svc.Batch(context.Background(), texts)
+		if !errors.Is(err, ErrZeroVector) || !strings.Contains(err.Error(), "input 1") {
+			t.Errorf("expected ErrZeroVector for input 1, got %v", err)
+		}
+	})
+
+	t.Run("drop returns nil", func(t *testing.T) {
+		svc := NewService(newProvider("zero-drop")).WithZeroVectorPolicy(ZeroDrop)
+
+		vectors, err := svc.Batch(context.Background(), texts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vectors[1] != nil || vectors[0] == nil || vectors[2] == nil {
+			t.Errorf("expected only input 1 to be dropped, got %v", vectors)
+		}
+	})
+}
+
+func TestWithTags(t *testing.T) {
+	var mu sync.Mutex
+	var seen []map[string]string
+	record := func(inner pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		read := pipz.Effect(pipz.NewIdentity("test:tags", "Reads request tags"), func(_ context.Context, req *EmbedRequest) error {
+			mu.Lock()
+			seen = append(seen, req.Tags)
+			mu.Unlock()
+			return nil
+		})
+		return pipz.NewSequence(pipz.NewIdentity("test:tagged", "Tag-reading pipeline"), read, inner)
+	}
+
+	svc := NewService(newMockQueryProvider(8))
+	if err := svc.WrapPipeline(record); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := WithTags(context.Background(), map[string]string{"tenant": "acme", "cache": "hot"})
+	ctx = WithTags(ctx, map[string]string{"cache": "cold"})
+	if _, err := svc.Batch(ctx, []string{"a", "b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.BatchQuery(ctx, []string{"q"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Embed(context.Background(), "untagged"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"tenant": "acme", "cache": "cold"}
+	if len(seen) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(seen))
+	}
+	for i, tags := range seen[:2] {
+		if !maps.Equal(tags, want) {
+			t.Errorf("request %d: expected tags %v, got %v", i, want, tags)
+		}
+	}
+	if seen[2] != nil {
+		t.Errorf("expected no tags without WithTags, got %v", seen[2])
+	}
+}
+
+func TestService_WrapPipeline(t *testing.T) {
+	countingWrapper := func(calls *atomic.Int32) func(pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+		return func(inner pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] {
+			count := pipz.Effect(pipz.NewIdentity("test:count", "Counts requests"), func(_ context.Context, _ *EmbedRequest) error {
+				calls.Add(1)
+				return nil
+			})
+			return pipz.NewSequence(pipz.NewIdentity("test:wrapped", "Counted pipeline"), count, inner)
+		}
+	}
+
+	t.Run("wraps document and query paths", func(t *testing.T) {
+		var calls atomic.Int32
+		svc := NewService(newMockQueryProvider(8))
+		if err := svc.WrapPipeline(countingWrapper(&calls)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := svc.Embed(context.Background(), "document"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := svc.EmbedQuery(context.Background(), "query"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("expected both paths to pass through the wrapper, got %d calls", calls.Load())
+		}
+		if svc.GetPipeline().Identity().Name() != "test:wrapped" {
+			t.Errorf("expected GetPipeline to return the wrapped pipeline, got %s", svc.GetPipeline().Identity().Name())
+		}
+	})
+
+	t.Run("rejects nil wrapper result", func(t *testing.T) {
+		svc := NewService(newMockProvider(8))
+		before := svc.pipes.Load()
+		err := svc.WrapPipeline(func(pipz.Chainable[*EmbedRequest]) pipz.Chainable[*EmbedRequest] { return nil })
+		if err == nil {
+			t.Fatal("expected error for nil pipeline")
+		}
+		if svc.pipes.Load() != before {
+			t.Error("expected pipeline to be unchanged")
+		}
+	})
+}
+
+// subBatchFailProvider embeds texts in sub-batches of two, failing any
+// sub-batch holding the text "fail" the way a splitting provider does.
+type subBatchFailProvider struct {
+	*mockProvider
+}
+
+func (p *subBatchFailProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	resp, err := p.mockProvider.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	var failures []BatchFailure
+	for start := 0; start < len(texts); start += 2 {
+		end := min(start+2, len(texts))
+		if slices.Contains(texts[start:end], "fail") {
+			failures = append(failures, BatchFailure{Err: errors.New("sub-batch rejected"), Start: start, End: end})
+			clear(resp.Vectors[start:end])
+		}
+	}
+	if failures != nil {
+		return nil, NewBatchError(failures, resp.Vectors)
+	}
+	return resp, nil
+}
+
+func TestService_WithStrictResults(t *testing.T) {
+	failedInputs := func(t *testing.T, err error) []int {
+		t.Helper()
+		var berr *BatchError
+		if !errors.As(err, &berr) {
+			t.Fatalf("expected BatchError, got %v", err)
+		}
+		var inputs []int
+		for _, f := range berr.Failures {
+			if f.End != f.Start+1 {
+				t.Errorf("expected single-text failure, got [%d:%d]", f.Start, f.End)
+			}
+			inputs = append(inputs, f.Start)
+		}
+		return inputs
+	}
+
+	t.Run("empty input", func(t *testing.T) {
+		legacy, err := NewService(newMockProvider(4)).Batch(context.Background(), nil)
+		if err != nil || legacy != nil {
+			t.Errorf("expected nil, nil in legacy mode, got %v, %v", legacy, err)
+		}
+		strict, err := NewService(newMockProvider(4)).WithStrictResults().Batch(context.Background(), nil)
+		if err != nil || strict == nil || len(strict) != 0 {
+			t.Errorf("expected empty non-nil slice, got %#v, %v", strict, err)
+		}
+	})
+
+	t.Run("empty provider response", func(t *testing.T) {
+		provider := &mockEmptyProvider{newMockProvider(4)}
+		legacy, err := NewService(provider).Batch(context.Background(), []string{"a", "b"})
+		if err != nil || legacy != nil {
+			t.Errorf("expected nil, nil in legacy mode, got %v, %v", legacy, err)
+		}
+		_, err = NewService(provider).WithStrictResults().Batch(context.Background(), []string{"a", "b"})
+		if !errors.Is(err, ErrEmptyProviderResponse) {
+			t.Errorf("expected ErrEmptyProviderResponse, got %v", err)
+		}
+	})
+
+	t.Run("skipped text", func(t *testing.T) {
+		texts := []string{"good", "bad", "good"}
+		legacy, err := NewService(&zeroProvider{newMockProvider(4)}).WithZeroVectorPolicy(ZeroDrop).Batch(context.Background(), texts)
+		if err != nil || legacy[1] != nil {
+			t.Errorf("expected silent nil in legacy mode, got %v, %v", legacy, err)
+		}
+
+		svc := NewService(&zeroProvider{newMockProvider(4)}).WithZeroVectorPolicy(ZeroDrop).WithStrictResults()
+		vectors, err := svc.Batch(context.Background(), texts)
+		if !slices.Equal(failedInputs(t, err), []int{1}) || !errors.Is(err, ErrZeroVector) {
+			t.Errorf("expected ErrZeroVector for input 1, got %v", err)
+		}
+		if len(vectors) != len(texts) || vectors[0] == nil || vectors[1] != nil || vectors[2] == nil {
+			t.Errorf("expected input 1 alone to be nil, got %v", vectors)
+		}
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		texts := []string{"a", "b", "c", "fail"}
+		legacy, err := NewService(&subBatchFailProvider{newMockProvider(4)}).Batch(context.Background(), texts)
+		var berr *BatchError
+		if legacy != nil || !errors.As(err, &berr) {
+			t.Errorf("expected nil vectors and a BatchError in legacy mode, got %v, %v", legacy, err)
+		}
+
+		svc := NewService(&subBatchFailProvider{newMockProvider(4)}).WithStrictResults()
+		vectors, err := svc.Batch(context.Background(), texts)
+		if got := failedInputs(t, err); !slices.Equal(got, []int{2, 3}) {
+			t.Errorf("expected inputs 2 and 3 to fail, got %v", got)
+		}
+		if len(vectors) != len(texts) || vectors[2] != nil || vectors[3] != nil {
+			t.Fatalf("expected nil vectors for failed inputs, got %v", vectors)
+		}
+		for _, v := range vectors[:2] {
+			if math.Abs(v.Norm()-1) > 1e-6 {
+				t.Errorf("expected normalized vector, got norm %v", v.Norm())
+			}
+		}
+		errors.As(err, &berr)
+		if len(berr.Partial()) != len(texts) || !berr.Partial()[0].Equal(vectors[0]) {
+			t.Errorf("expected Partial to match the returned vectors")
+		}
+	})
+
+	t.Run("partial failure with length sorting", func(t *testing.T) {
+		// Sorted by length the inputs are "a", "bb", "fail", "long text",
+		// so the failing sub-batch holds inputs 3 and 0.
+		texts := []string{"long text", "a", "bb", "fail"}
+		svc := NewService(&subBatchFailProvider{newMockProvider(4)}).WithLengthSortedBatching().WithStrictResults()
+		vectors, err := svc.Batch(context.Background(), texts)
+		if got := failedInputs(t, err); !slices.Equal(got, []int{0, 3}) {
+			t.Errorf("expected inputs 0 and 3 to fail, got %v", got)
+		}
+		if vectors[0] != nil || vectors[1] == nil || vectors[2] == nil || vectors[3] != nil {
+			t.Errorf("expected vectors for inputs 1 and 2 only, got %v", vectors)
+		}
+	})
+}
+
+// limitedProvider rejects requests of more than limit texts, like an API
+// with a per-request input cap that it does not split itself.
+type limitedProvider struct {
+	recordingProvider
+	limit int
+}
+
+func (p *limitedProvider) MaxBatchSize() int { return p.limit }
+
+func (p *limitedProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	if len(texts) > p.limit {
+		return nil, &ProviderError{Provider: "recording", StatusCode: 400, Message: "too many inputs"}
+	}
+	return p.recordingProvider.Embed(ctx, texts)
+}
+
+func TestService_SplitsOverBatchLimit(t *testing.T) {
+	// Fixed chunks of varying length give each chunk a distinct vector.
+	var b strings.Builder
+	for i := range 8 {
+		b.WriteString(strings.Repeat("x", 10+i))
+		b.WriteString(" ")
+	}
+	text := b.String()
+	chunker := &Chunker{Strategy: ChunkFixed, MaxSize: 12}
+	if n := len(chunker.Chunk(text)); n <= 3 {
+		t.Fatalf("expected the text to chunk into more than 3 pieces, got %d", n)
+	}
+
+	unlimited := NewService(&recordingProvider{dims: 4}).WithChunker(chunker).WithPooling(PoolMean)
+	want, err := unlimited.Embed(context.Background(), text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("uses the provider's limit", func(t *testing.T) {
+		provider := &limitedProvider{recordingProvider: recordingProvider{dims: 4}, limit: 3}
+		svc := NewService(provider).WithChunker(chunker).WithPooling(PoolMean)
+		got, err := svc.Embed(context.Background(), text)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !got.ApproxEqual(want, 1e-6) {
+			t.Errorf("expected %v pooled as one text, got %v", want, got)
+		}
+		for _, n := range provider.batches {
+			if n > 3 {
+				t.Errorf("expected sub-batches of at most 3, got %v", provider.batches)
+			}
+		}
+		if len(provider.batches) < 2 {
+			t.Errorf("expected several provider calls, got %v", provider.batches)
+		}
+	})
+
+	t.Run("WithMaxBatchSize overrides", func(t *testing.T) {
+		provider := &recordingProvider{dims: 4}
+		svc := NewService(provider).WithChunker(chunker).WithPooling(PoolMean).WithMaxBatchSize(2)
+		got, err := svc.Embed(context.Background(), text)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !got.ApproxEqual(want, 1e-6) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		for _, n := range provider.batches {
+			if n > 2 {
+				t.Errorf("expected sub-batches of at most 2, got %v", provider.batches)
+			}
+		}
+	})
+
+	t.Run("first failure cancels remaining sub-batches", func(t *testing.T) {
+		provider := &limitedProvider{recordingProvider: recordingProvider{dims: 4, err: errors.New("unavailable")}, limit: 3}
+		svc := NewService(provider).WithChunker(chunker)
+		var berr *BatchError
+		if _, err := svc.Embed(context.Background(), text); !errors.As(err, &berr) {
+			t.Fatalf("expected BatchError, got %v", err)
+		}
+		if len(provider.batches) != 1 {
+			t.Errorf("expected one provider call, got %v", provider.batches)
+		}
+		want := (len(chunker.Chunk(text)) + 2) / 3
+		if len(berr.Failures) != want {
+			t.Errorf("expected a failure for each of %d sub-batches, got %d", want, len(berr.Failures))
+		}
+		for _, f := range berr.Failures[1:] {
+			if !errors.Is(f.Err, context.Canceled) {
+				t.Errorf("expected pending sub-batch to be canceled, got %v", f.Err)
+			}
+		}
+	})
+}
+
+// completionProvider counts the calls to a slowProvider that finish.
+type completionProvider struct {
+	*slowProvider
+	completed atomic.Int32
+}
+
+func (p *completionProvider) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	resp, err := p.slowProvider.Embed(ctx, texts)
+	if err == nil {
+		p.completed.Add(1)
+	}
+	return resp, err
+}
+
+func TestService_SubBatchDeadline(t *testing.T) {
+	provider := &completionProvider{slowProvider: &slowProvider{delay: 20 * time.Millisecond, dims: 4}}
+	svc := NewService(provider).WithMaxBatchSize(1)
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := svc.Batch(ctx, texts)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if n := provider.completed.Load(); n >= int32(len(texts)) {
+		t.Errorf("expected the deadline to stop some sub-batches, all %d completed", n)
+	}
+	if elapsed > 150*time.Millisecond {
+		t.Errorf("expected pending sub-batches to be skipped, took %v", elapsed)
+	}
+	var berr *BatchError
+	if !errors.As(err, &berr) {
+		t.Fatalf("expected BatchError, got %v", err)
+	}
+	last := berr.Failures[len(berr.Failures)-1]
+	if last.End != len(texts) {
+		t.Errorf("expected the last sub-batch to be reported, got %+v", last)
+	}
+}
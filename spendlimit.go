@@ -0,0 +1,154 @@
+package vex
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zoobzio/pipz"
+)
+
+// spendBudget tracks provider-reported token usage in a sliding window,
+// plus estimates for requests still in flight. One budget is shared by the
+// document and query pipelines of a service.
+type spendBudget struct {
+	now      func() time.Time
+	spends   []spend // oldest first
+	window   time.Duration
+	limit    int64
+	reserved int64 // estimated tokens of in-flight requests
+	mu       sync.Mutex
+}
+
+type spend struct {
+	at     time.Time
+	tokens int64
+}
+
+func newSpendBudget(limit int64, window time.Duration) *spendBudget {
+	return &spendBudget{
+		now:    time.Now,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// used returns the tokens spent in the window ending now plus in-flight
+// reservations. The caller must hold b.mu.
+func (b *spendBudget) used(now time.Time) int64 {
+	cutoff := now.Add(-b.window)
+	expired := 0
+	for expired < len(b.spends) && !b.spends[expired].at.After(cutoff) {
+		expired++
+	}
+	b.spends = b.spends[expired:]
+
+	total := b.reserved
+	for _, s := range b.spends {
+		total += s.tokens
+	}
+	return total
+}
+
+// reserve admits a request estimated at estimate tokens, or returns
+// ErrSpendLimitExceeded if it would take the window past the limit.
+func (b *spendBudget) reserve(estimate int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	used := b.used(b.now())
+	if used+estimate > b.limit {
+		return fmt.Errorf("%w: %d of %d tokens used in the last %s", ErrSpendLimitExceeded, used, b.limit, b.window)
+	}
+	b.reserved += estimate
+	return nil
+}
+
+// settle releases a reservation and records the tokens actually spent.
+func (b *spendBudget) settle(estimate, tokens int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.reserved -= estimate
+	if tokens > 0 {
+		b.spends = append(b.spends, spend{at: b.now(), tokens: tokens})
+	}
+}
+
+// current returns the tokens counted against the limit right now.
+func (b *spendBudget) current() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used(b.now())
+}
+
+// spendLimit rejects requests once its budget is exhausted.
+type spendLimit struct {
+	identity  pipz.Identity
+	processor pipz.Chainable[*EmbedRequest]
+	budget    *spendBudget
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newSpendLimit(identity pipz.Identity, processor pipz.Chainable[*EmbedRequest], budget *spendBudget) *spendLimit {
+	return &spendLimit{
+		identity:  identity,
+		processor: processor,
+		budget:    budget,
+	}
+}
+
+// Process implements pipz.Chainable.
+func (l *spendLimit) Process(ctx context.Context, req *EmbedRequest) (*EmbedRequest, error) {
+	if req.stats != nil {
+		req.stats.spend.CompareAndSwap(nil, l.budget)
+	}
+
+	var estimate int64
+	for _, text := range req.Texts {
+		estimate += int64(estimateTokens(text))
+	}
+	if err := l.budget.reserve(estimate); err != nil {
+		req.Error = err
+		return req, err
+	}
+
+	out, err := l.processor.Process(ctx, req)
+	var spent int64
+	if err == nil && out != nil && out.Response != nil {
+		spent = int64(out.Response.Usage.TotalTokens)
+		if spent == 0 { // usage not reported
+			spent = estimate
+		}
+	}
+	l.budget.settle(estimate, spent)
+	return out, err
+}
+
+// Identity implements pipz.Chainable.
+func (l *spendLimit) Identity() pipz.Identity {
+	return l.identity
+}
+
+// Schema implements pipz.Chainable.
+func (l *spendLimit) Schema() pipz.Node {
+	return pipz.Node{
+		Identity: l.identity,
+		Type:     "spend-limit",
+		Flow:     pipz.RateLimiterFlow{Processor: l.processor.Schema()},
+		Metadata: map[string]any{
+			"max_tokens": l.budget.limit,
+			"window":     l.budget.window.String(),
+		},
+	}
+}
+
+// Close implements pipz.Chainable.
+func (l *spendLimit) Close() error {
+	l.closeOnce.Do(func() {
+		l.closeErr = l.processor.Close()
+	})
+	return l.closeErr
+}
@@ -0,0 +1,142 @@
+package vex
+
+import "sync/atomic"
+
+// ServiceStats is a snapshot of a Service's counters since creation or the
+// last ResetStats. ProviderCalls can exceed Requests without any retries,
+// since split and hedged requests make several calls each.
+type ServiceStats struct {
+	Requests         int64 `json:"requests"`
+	Failures         int64 `json:"failures"`
+	ProviderCalls    int64 `json:"provider_calls"`
+	ProviderFailures int64 `json:"provider_failures"`
+	Texts            int64 `json:"texts"`
+	Chunks           int64 `json:"chunks"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+
+	// Retries counts attempts re-sent by WithRetry, WithBackoff and
+	// WithJitteredBackoff after a failure.
+	Retries int64 `json:"retries"`
+
+	// CacheHits counts requests WithNegativeCache answered with a cached
+	// error instead of calling the provider.
+	CacheHits int64 `json:"cache_hits"`
+
+	// SpendWindowTokens is the usage WithSpendLimit currently counts against
+	// its limit: tokens reported in the window plus estimates for requests
+	// in flight. Zero without the option; not cleared by ResetStats.
+	SpendWindowTokens int64 `json:"spend_window_tokens"`
+}
+
+// serviceStats holds the live counters behind ServiceStats.
+// Counters are updated alongside the corresponding hook emissions.
+type serviceStats struct {
+	requests         atomic.Int64
+	failures         atomic.Int64
+	providerCalls    atomic.Int64
+	providerFailures atomic.Int64
+	texts            atomic.Int64
+	chunks           atomic.Int64
+	promptTokens     atomic.Int64
+	totalTokens      atomic.Int64
+	retries          atomic.Int64
+	cacheHits        atomic.Int64
+	spend            atomic.Pointer[spendBudget] // set on first request through WithSpendLimit
+}
+
+func (s *serviceStats) recordStarted() {
+	if s == nil {
+		return
+	}
+	s.requests.Add(1)
+}
+
+func (s *serviceStats) recordCompleted(texts, chunks int, resp *EmbeddingResponse) {
+	if s == nil {
+		return
+	}
+	s.texts.Add(int64(texts))
+	s.chunks.Add(int64(chunks))
+	s.promptTokens.Add(int64(resp.Usage.PromptTokens))
+	s.totalTokens.Add(int64(resp.Usage.TotalTokens))
+}
+
+func (s *serviceStats) recordFailed() {
+	if s == nil {
+		return
+	}
+	s.failures.Add(1)
+}
+
+func (s *serviceStats) recordProviderCall() {
+	if s == nil {
+		return
+	}
+	s.providerCalls.Add(1)
+}
+
+func (s *serviceStats) recordProviderFailed() {
+	if s == nil {
+		return
+	}
+	s.providerFailures.Add(1)
+}
+
+func (s *serviceStats) recordRetry() {
+	if s == nil {
+		return
+	}
+	s.retries.Add(1)
+}
+
+func (s *serviceStats) recordCacheHit() {
+	if s == nil {
+		return
+	}
+	s.cacheHits.Add(1)
+}
+
+func (s *serviceStats) snapshot() ServiceStats {
+	var spendWindow int64
+	if budget := s.spend.Load(); budget != nil {
+		spendWindow = budget.current()
+	}
+	return ServiceStats{
+		Requests:         s.requests.Load(),
+		Failures:         s.failures.Load(),
+		ProviderCalls:    s.providerCalls.Load(),
+		ProviderFailures: s.providerFailures.Load(),
+		Texts:            s.texts.Load(),
+		Chunks:           s.chunks.Load(),
+		PromptTokens:     s.promptTokens.Load(),
+		TotalTokens:      s.totalTokens.Load(),
+		Retries:          s.retries.Load(),
+		CacheHits:        s.cacheHits.Load(),
+
+		SpendWindowTokens: spendWindow,
+	}
+}
+
+func (s *serviceStats) reset() {
+	s.requests.Store(0)
+	s.failures.Store(0)
+	s.providerCalls.Store(0)
+	s.providerFailures.Store(0)
+	s.texts.Store(0)
+	s.chunks.Store(0)
+	s.promptTokens.Store(0)
+	s.totalTokens.Store(0)
+	s.retries.Store(0)
+	s.cacheHits.Store(0)
+}
+
+// Stats returns a snapshot of the service's counters.
+func (s *Service) Stats() ServiceStats {
+	return s.stats.snapshot()
+}
+
+// ResetStats zeroes the service's counters.
+func (s *Service) ResetStats() {
+	s.stats.reset()
+}
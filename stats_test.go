@@ -0,0 +1,116 @@
+package vex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestService_Stats(t *testing.T) {
+	t.Run("counts successful batches", func(t *testing.T) {
+		chunker := &Chunker{Strategy: ChunkSentence, TrimSpace: true}
+		svc := NewService(newMockProvider(8)).WithChunker(chunker)
+
+		if _, err := svc.Batch(context.Background(), []string{"One. Two.", "Three."}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := svc.Embed(context.Background(), "Four."); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := ServiceStats{
+			Requests:      2,
+			ProviderCalls: 2,
+			Texts:         3,
+			Chunks:        4,
+			PromptTokens:  20, // mock bills 5 tokens per chunk
+			TotalTokens:   20,
+		}
+		if stats := svc.Stats(); stats != expected {
+			t.Errorf("expected %+v, got %+v", expected, stats)
+		}
+	})
+
+	t.Run("counts failures and retries", func(t *testing.T) {
+		provider := &retryTestProvider{failUntil: 100, dims: 8}
+		svc := NewService(provider, WithRetry(3))
+
+		if _, err := svc.Embed(context.Background(), "test"); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		expected := ServiceStats{
+			Requests:         1,
+			Failures:         1,
+			ProviderCalls:    3,
+			ProviderFailures: 3,
+			Retries:          2,
+		}
+		if stats := svc.Stats(); stats != expected {
+			t.Errorf("expected %+v, got %+v", expected, stats)
+		}
+	})
+
+	t.Run("counts negative cache hits", func(t *testing.T) {
+		provider := &erroringProvider{err: &ProviderError{Provider: "erroring", StatusCode: 400}}
+		svc := NewService(provider, WithNegativeCache(time.Minute), WithRetry(3))
+
+		for range 3 {
+			svc.Embed(context.Background(), "bad") //nolint:errcheck // failure expected
+		}
+
+		stats := svc.Stats()
+		if stats.CacheHits != 2 || stats.ProviderCalls != 1 {
+			t.Errorf("expected 2 cache hits and 1 provider call, got %+v", stats)
+		}
+		if stats.Retries != 0 {
+			t.Errorf("expected no retries of a non-retryable error, got %d", stats.Retries)
+		}
+	})
+
+	t.Run("counts query path", func(t *testing.T) {
+		svc := NewService(newMockQueryProvider(8))
+
+		if _, err := svc.EmbedQuery(context.Background(), "query"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stats := svc.Stats(); stats.Requests != 1 || stats.ProviderCalls != 1 {
+			t.Errorf("expected 1 request and 1 provider call, got %+v", stats)
+		}
+	})
+
+	t.Run("resets counters", func(t *testing.T) {
+		provider := newMockProvider(8)
+		provider.err = errors.New("provider error")
+		svc := NewService(provider)
+
+		//nolint:errcheck // test helper
+		svc.Embed(context.Background(), "test")
+		svc.ResetStats()
+
+		if stats := svc.Stats(); stats != (ServiceStats{}) {
+			t.Errorf("expected zeroed stats, got %+v", stats)
+		}
+	})
+
+	t.Run("marshals to JSON", func(t *testing.T) {
+		svc := NewService(newMockProvider(8))
+		if _, err := svc.Embed(context.Background(), "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, err := json.Marshal(svc.Stats())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var fields map[string]int64
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fields["requests"] != 1 || fields["texts"] != 1 {
+			t.Errorf("unexpected JSON fields: %s", data)
+		}
+	})
+}
@@ -44,6 +44,38 @@ func BenchmarkVector_Dot(b *testing.B) {
 	}
 }
 
+func BenchmarkVector_DotFloat32(b *testing.B) {
+	v1 := make(vex.Vector, 1536)
+	v2 := make(vex.Vector, 1536)
+	for i := range v1 {
+		v1[i] = float32(i) / 1536.0
+		v2[i] = float32(1536-i) / 1536.0
+	}
+	vex.SetSimilarityPrecision(vex.PrecisionFloat32)
+	defer vex.SetSimilarityPrecision(vex.PrecisionFloat64)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = v1.Dot(v2)
+	}
+}
+
+func BenchmarkVector_CosineSimilarityFloat32(b *testing.B) {
+	v1 := make(vex.Vector, 1536)
+	v2 := make(vex.Vector, 1536)
+	for i := range v1 {
+		v1[i] = float32(i) / 1536.0
+		v2[i] = float32(1536-i) / 1536.0
+	}
+	vex.SetSimilarityPrecision(vex.PrecisionFloat32)
+	defer vex.SetSimilarityPrecision(vex.PrecisionFloat64)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = v1.CosineSimilarity(v2)
+	}
+}
+
 func BenchmarkVector_CosineSimilarity(b *testing.B) {
 	v1 := make(vex.Vector, 1536)
 	v2 := make(vex.Vector, 1536)
@@ -58,6 +90,21 @@ func BenchmarkVector_CosineSimilarity(b *testing.B) {
 	}
 }
 
+func BenchmarkVector_DotNormalized(b *testing.B) {
+	v1 := make(vex.Vector, 1536)
+	v2 := make(vex.Vector, 1536)
+	for i := range v1 {
+		v1[i] = float32(i) / 1536.0
+		v2[i] = float32(1536-i) / 1536.0
+	}
+	v1, v2 = v1.Normalize(), v2.Normalize()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = v1.DotNormalized(v2)
+	}
+}
+
 func BenchmarkVector_EuclideanDistance(b *testing.B) {
 	v1 := make(vex.Vector, 1536)
 	v2 := make(vex.Vector, 1536)
@@ -103,3 +150,19 @@ func BenchmarkPool_Max(b *testing.B) {
 		_ = vex.Pool(vectors, vex.PoolMax)
 	}
 }
+
+func BenchmarkSimilarityMatrix(b *testing.B) {
+	vectors := make([]vex.Vector, 500)
+	for i := range vectors {
+		vec := make(vex.Vector, 384)
+		for j := range vec {
+			vec[j] = float32((i*384+j)%997) / 997.0
+		}
+		vectors[i] = vec
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = vex.SimilarityMatrix(vectors, vex.Cosine)
+	}
+}
@@ -4,7 +4,9 @@ package testing
 import (
 	"context"
 	"crypto/sha256"
+	"maps"
 	"math"
+	"slices"
 	"testing"
 
 	"github.com/zoobzio/vex"
@@ -13,6 +15,7 @@ import (
 // MockProvider implements vex.Provider for testing.
 type MockProvider struct {
 	err           error
+	vectors       map[string]vex.Vector
 	name          string
 	dimensions    int
 	failAfter     int
@@ -27,6 +30,11 @@ type MockConfig struct {
 	Dimensions    int
 	FailAfter     int
 	Deterministic bool
+
+	// Vectors fixes the vector returned for particular texts, for tests and
+	// examples that need known similarities. Other texts get a generated
+	// vector. Fixed vectors are returned as given, without normalization.
+	Vectors map[string]vex.Vector
 }
 
 // NewMockProvider creates a new mock provider.
@@ -43,6 +51,7 @@ func NewMockProvider(config MockConfig) *MockProvider {
 		deterministic: config.Deterministic,
 		failAfter:     config.FailAfter,
 		err:           config.Error,
+		vectors:       maps.Clone(config.Vectors),
 	}
 }
 
@@ -95,6 +104,9 @@ func (p *MockProvider) Reset() {
 }
 
 func (p *MockProvider) generateVector(text string) vex.Vector {
+	if vec, ok := p.vectors[text]; ok {
+		return slices.Clone(vec)
+	}
 	vec := make(vex.Vector, p.dimensions)
 
 	if p.deterministic {
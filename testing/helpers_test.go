@@ -5,6 +5,8 @@ import (
 	"errors"
 	"math"
 	"testing"
+
+	"github.com/zoobzio/vex"
 )
 
 func TestMockProvider_Embed(t *testing.T) {
@@ -51,11 +53,32 @@ func TestMockProvider_Embed(t *testing.T) {
 			t.Fatalf("unexpected error: %v", err2)
 		}
 
-		for i := range resp1.Vectors[0] {
-			if resp1.Vectors[0][i] != resp2.Vectors[0][i] {
-				t.Errorf("deterministic mode produced different vectors")
-				break
-			}
+		if !resp1.Vectors[0].Equal(resp2.Vectors[0]) {
+			t.Errorf("deterministic mode produced different vectors")
+		}
+	})
+
+	t.Run("returns fixed vectors", func(t *testing.T) {
+		fixed := vex.Vector{0.6, 0.8, 0}
+		provider := NewMockProvider(MockConfig{
+			Dimensions: 3,
+			Vectors:    map[string]vex.Vector{"cat": fixed},
+		})
+
+		resp, err := provider.Embed(context.Background(), []string{"cat", "dog"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Vectors[0].Equal(fixed) {
+			t.Errorf("expected fixed vector %v, got %v", fixed, resp.Vectors[0])
+		}
+		if len(resp.Vectors[1]) != 3 {
+			t.Errorf("expected generated vector for other texts, got %v", resp.Vectors[1])
+		}
+
+		resp.Vectors[0][0] = 1
+		if fixed[0] != 0.6 {
+			t.Error("expected the fixed vector not to be aliased")
 		}
 	})
 
@@ -106,11 +129,8 @@ func TestGenerateTestVector(t *testing.T) {
 		v1 := GenerateTestVector(256, 123)
 		v2 := GenerateTestVector(256, 123)
 
-		for i := range v1 {
-			if v1[i] != v2[i] {
-				t.Errorf("same seed produced different vectors")
-				break
-			}
+		if !v1.Equal(v2) {
+			t.Errorf("same seed produced different vectors")
 		}
 	})
 
@@ -118,14 +138,7 @@ func TestGenerateTestVector(t *testing.T) {
 		v1 := GenerateTestVector(256, 1)
 		v2 := GenerateTestVector(256, 2)
 
-		same := true
-		for i := range v1 {
-			if v1[i] != v2[i] {
-				same = false
-				break
-			}
-		}
-		if same {
+		if v1.Equal(v2) {
 			t.Error("different seeds should produce different vectors")
 		}
 	})
@@ -136,14 +149,7 @@ func TestGenerateSimilarVectors(t *testing.T) {
 		v1, v2 := GenerateSimilarVectors(512, 0.8)
 
 		// Vectors should be different
-		same := true
-		for i := range v1 {
-			if v1[i] != v2[i] {
-				same = false
-				break
-			}
-		}
-		if same {
+		if v1.Equal(v2) {
 			t.Error("expected distinct vectors")
 		}
 
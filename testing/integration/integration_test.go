@@ -15,8 +15,10 @@ import (
 
 	"github.com/zoobzio/vex"
 	"github.com/zoobzio/vex/cohere"
+	"github.com/zoobzio/vex/gemini"
 	"github.com/zoobzio/vex/openai"
 	"github.com/zoobzio/vex/testing/integration/mocks"
+	"github.com/zoobzio/vex/voyage"
 )
 
 // TestOpenAI_WithMockServer tests OpenAI provider against a local mock.
@@ -100,6 +102,91 @@ func TestCohere_WithMockServer(t *testing.T) {
 	})
 }
 
+// TestVoyage_WithMockServer tests Voyage provider against a local mock.
+func TestVoyage_WithMockServer(t *testing.T) {
+	mock := mocks.NewVoyageMock()
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	provider := voyage.New(voyage.Config{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	svc := vex.NewService(provider)
+	ctx := context.Background()
+
+	t.Run("embeds batch", func(t *testing.T) {
+		texts := []string{"hello", "world"}
+		vecs, err := svc.Batch(ctx, texts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(vecs) != len(texts) {
+			t.Errorf("expected %d vectors, got %d", len(texts), len(vecs))
+		}
+		if len(vecs[0]) != 1024 {
+			t.Errorf("expected 1024 dimensions, got %d", len(vecs[0]))
+		}
+	})
+
+	t.Run("sends input type per mode", func(t *testing.T) {
+		if _, err := svc.EmbedQuery(ctx, "search"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		types := mock.ReceivedInputTypes()
+		if len(types) != 2 || types[0] != "document" || types[1] != "query" {
+			t.Errorf("expected [document query], got %v", types)
+		}
+	})
+}
+
+// TestGemini_WithMockServer tests Gemini provider against a local mock.
+func TestGemini_WithMockServer(t *testing.T) {
+	mock := mocks.NewGeminiMock()
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	provider := gemini.New(gemini.Config{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+	})
+
+	svc := vex.NewService(provider)
+	ctx := context.Background()
+
+	t.Run("embeds batch", func(t *testing.T) {
+		texts := []string{"hello", "world", "foo"}
+		vecs, err := svc.Batch(ctx, texts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(vecs) != len(texts) {
+			t.Errorf("expected %d vectors, got %d", len(texts), len(vecs))
+		}
+		if len(vecs[0]) != 768 {
+			t.Errorf("expected 768 dimensions, got %d", len(vecs[0]))
+		}
+	})
+
+	t.Run("sends task type per mode", func(t *testing.T) {
+		if _, err := svc.EmbedQuery(ctx, "search"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		types := mock.ReceivedTaskTypes()
+		if types[len(types)-1] != "RETRIEVAL_QUERY" {
+			t.Errorf("expected RETRIEVAL_QUERY, got %v", types)
+		}
+	})
+
+	t.Run("rejects missing key", func(t *testing.T) {
+		unauth := vex.NewService(gemini.New(gemini.Config{BaseURL: server.URL}))
+		if _, err := unauth.Embed(ctx, "test"); err == nil {
+			t.Error("expected error without API key")
+		}
+	})
+}
+
 // TestWithRetry_Integration tests retry logic with mock servers.
 func TestWithRetry_Integration(t *testing.T) {
 	callCount := 0
@@ -132,6 +219,45 @@ func TestWithRetry_Integration(t *testing.T) {
 	}
 }
 
+// TestWithFallback_Integration tests that a request failing over to the
+// fallback reaches both providers under the same request ID.
+func TestWithFallback_Integration(t *testing.T) {
+	primaryMock := mocks.NewOpenAIMock()
+	primaryMock.FailStatus = http.StatusServiceUnavailable
+	primaryServer := httptest.NewServer(primaryMock)
+	defer primaryServer.Close()
+
+	backupMock := mocks.NewCohereMock()
+	backupServer := httptest.NewServer(backupMock)
+	defer backupServer.Close()
+
+	backup := vex.NewService(cohere.New(cohere.Config{
+		APIKey:  "test-key",
+		BaseURL: backupServer.URL,
+	}))
+	svc := vex.NewService(openai.New(openai.Config{
+		APIKey:  "test-key",
+		BaseURL: primaryServer.URL,
+	}), vex.WithFallback(backup))
+
+	vec, err := svc.Embed(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("expected fallback to serve the request, got: %v", err)
+	}
+	if len(vec) != backupMock.Dimensions {
+		t.Errorf("expected %d dimensions from the fallback, got %d", backupMock.Dimensions, len(vec))
+	}
+
+	primaryIDs := primaryMock.ReceivedRequestIDs()
+	backupIDs := backupMock.ReceivedRequestIDs()
+	if len(primaryIDs) != 1 || len(backupIDs) != 1 {
+		t.Fatalf("expected one call to each provider, got %d primary and %d fallback", len(primaryIDs), len(backupIDs))
+	}
+	if primaryIDs[0] == "" || primaryIDs[0] != backupIDs[0] {
+		t.Errorf("expected both providers to receive the same request ID, got %q and %q", primaryIDs[0], backupIDs[0])
+	}
+}
+
 // TestWithTestcontainers demonstrates testcontainers usage.
 // This test requires Docker to be running.
 func TestWithTestcontainers(t *testing.T) {
@@ -8,6 +8,7 @@ import (
 
 // CohereMock handles Cohere embedding API requests.
 type CohereMock struct {
+	requestIDLog
 	Dimensions int
 	Model      string
 }
@@ -22,6 +23,7 @@ func NewCohereMock() *CohereMock {
 
 // ServeHTTP implements http.Handler for the Cohere mock.
 func (m *CohereMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.record(r)
 	auth := r.Header.Get("Authorization")
 	if !strings.HasPrefix(auth, "Bearer ") {
 		m.writeError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
@@ -0,0 +1,157 @@
+package mocks
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// GeminiMock handles Gemini embedContent and batchEmbedContents API requests.
+type GeminiMock struct {
+	requestIDLog
+	Dimensions int
+	Model      string
+	taskTypes  []string
+	mu         sync.Mutex
+}
+
+// NewGeminiMock creates a new Gemini mock with default settings.
+func NewGeminiMock() *GeminiMock {
+	return &GeminiMock{
+		Dimensions: 768,
+		Model:      "text-embedding-004",
+	}
+}
+
+// ServeHTTP implements http.Handler for the Gemini mock.
+func (m *GeminiMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.record(r)
+	if r.Header.Get("x-goog-api-key") == "" {
+		m.writeError(w, http.StatusUnauthorized, "API key not valid", "UNAUTHENTICATED")
+		return
+	}
+
+	prefix := "/models/" + m.Model + ":"
+	if r.Method != "POST" || !strings.HasPrefix(r.URL.Path, prefix) {
+		m.writeError(w, http.StatusNotFound, "Not found", "NOT_FOUND")
+		return
+	}
+	switch strings.TrimPrefix(r.URL.Path, prefix) {
+	case "batchEmbedContents":
+		m.serveBatch(w, r)
+	case "embedContent":
+		m.serveSingle(w, r)
+	default:
+		m.writeError(w, http.StatusNotFound, "Not found", "NOT_FOUND")
+	}
+}
+
+func (m *GeminiMock) serveSingle(w http.ResponseWriter, r *http.Request) {
+	var req geminiContentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		m.writeError(w, http.StatusBadRequest, "Invalid request body", "INVALID_ARGUMENT")
+		return
+	}
+	if strings.TrimSpace(joinParts(req.Content.Parts)) == "" {
+		m.writeError(w, http.StatusBadRequest, "Content must not be empty", "INVALID_ARGUMENT")
+		return
+	}
+	m.mu.Lock()
+	m.taskTypes = append(m.taskTypes, req.TaskType)
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(geminiSingleResponse{Embedding: geminiEmbedding{Values: m.generateVector(0)}})
+}
+
+func (m *GeminiMock) serveBatch(w http.ResponseWriter, r *http.Request) {
+	var req geminiBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		m.writeError(w, http.StatusBadRequest, "Invalid request body", "INVALID_ARGUMENT")
+		return
+	}
+
+	embeddings := make([]geminiEmbedding, len(req.Requests))
+	for i, sub := range req.Requests {
+		if sub.Model != "models/"+m.Model {
+			m.writeError(w, http.StatusBadRequest, "Model mismatch in batch request", "INVALID_ARGUMENT")
+			return
+		}
+		if strings.TrimSpace(joinParts(sub.Content.Parts)) == "" {
+			m.writeError(w, http.StatusBadRequest, "Content must not be empty", "INVALID_ARGUMENT")
+			return
+		}
+		m.mu.Lock()
+		m.taskTypes = append(m.taskTypes, sub.TaskType)
+		m.mu.Unlock()
+		embeddings[i] = geminiEmbedding{Values: m.generateVector(i)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(geminiBatchResponse{Embeddings: embeddings})
+}
+
+// ReceivedTaskTypes returns the taskType of each content received, in order.
+func (m *GeminiMock) ReceivedTaskTypes() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.taskTypes...)
+}
+
+func (m *GeminiMock) generateVector(seed int) []float64 {
+	vec := make([]float64, m.Dimensions)
+	for i := range vec {
+		vec[i] = float64((seed*m.Dimensions+i)%1000) / 1000.0
+	}
+	return vec
+}
+
+func (m *GeminiMock) writeError(w http.ResponseWriter, status int, message, code string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": message,
+			"status":  code,
+		},
+	})
+}
+
+func joinParts(parts []geminiPart) string {
+	texts := make([]string, len(parts))
+	for i, p := range parts {
+		texts[i] = p.Text
+	}
+	return strings.Join(texts, "")
+}
+
+type geminiBatchRequest struct {
+	Requests []geminiContentRequest `json:"requests"`
+}
+
+type geminiContentRequest struct {
+	Model    string        `json:"model"`
+	TaskType string        `json:"taskType"`
+	Content  geminiContent `json:"content"`
+}
+
+type geminiContent struct {
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiSingleResponse struct {
+	Embedding geminiEmbedding `json:"embedding"`
+}
+
+type geminiBatchResponse struct {
+	Embeddings []geminiEmbedding `json:"embeddings"`
+}
+
+type geminiEmbedding struct {
+	Values []float64 `json:"values"`
+}
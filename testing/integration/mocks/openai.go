@@ -9,8 +9,13 @@ import (
 
 // OpenAIMock handles OpenAI embedding API requests.
 type OpenAIMock struct {
+	requestIDLog
 	Dimensions int
 	Model      string
+
+	// FailStatus, when set, makes every request fail with this status after
+	// it is recorded, e.g. to exercise a fallback.
+	FailStatus int
 }
 
 // NewOpenAIMock creates a new OpenAI mock with default settings.
@@ -23,6 +28,12 @@ func NewOpenAIMock() *OpenAIMock {
 
 // ServeHTTP implements http.Handler for the OpenAI mock.
 func (m *OpenAIMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.record(r)
+	if m.FailStatus != 0 {
+		m.writeError(w, m.FailStatus, "Injected failure")
+		return
+	}
+
 	// Verify auth
 	auth := r.Header.Get("Authorization")
 	if !strings.HasPrefix(auth, "Bearer ") {
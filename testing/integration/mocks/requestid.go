@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"net/http"
+	"sync"
+)
+
+// requestIDLog records the X-Request-ID header of each request a mock
+// receives. Mocks embed it to expose ReceivedRequestIDs.
+type requestIDLog struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (l *requestIDLog) record(r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ids = append(l.ids, r.Header.Get("X-Request-ID"))
+}
+
+// ReceivedRequestIDs returns the X-Request-ID header of each request
+// received, in order, with "" for requests that carried none.
+func (l *requestIDLog) ReceivedRequestIDs() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.ids...)
+}
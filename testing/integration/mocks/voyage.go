@@ -0,0 +1,116 @@
+package mocks
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// VoyageMock handles Voyage AI embedding API requests.
+type VoyageMock struct {
+	requestIDLog
+	Dimensions int
+	Model      string
+	inputTypes []string
+	mu         sync.Mutex
+}
+
+// NewVoyageMock creates a new Voyage mock with default settings.
+func NewVoyageMock() *VoyageMock {
+	return &VoyageMock{
+		Dimensions: 1024,
+		Model:      "voyage-3",
+	}
+}
+
+// ServeHTTP implements http.Handler for the Voyage mock.
+func (m *VoyageMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.record(r)
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		m.writeError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
+		return
+	}
+
+	if r.Method != "POST" || r.URL.Path != "/embeddings" {
+		m.writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	var req voyageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		m.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	m.mu.Lock()
+	m.inputTypes = append(m.inputTypes, req.InputType)
+	m.mu.Unlock()
+
+	data := make([]voyageEmbedding, len(req.Input))
+	for i := range req.Input {
+		data[i] = voyageEmbedding{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: m.generateVector(i),
+		}
+	}
+
+	resp := voyageResponse{
+		Object: "list",
+		Data:   data,
+		Model:  m.Model,
+		Usage: voyageUsage{
+			TotalTokens: len(req.Input) * 5,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ReceivedInputTypes returns the input_type of each request received, in order.
+func (m *VoyageMock) ReceivedInputTypes() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.inputTypes...)
+}
+
+func (m *VoyageMock) generateVector(seed int) []float64 {
+	vec := make([]float64, m.Dimensions)
+	for i := range vec {
+		vec[i] = float64((seed*m.Dimensions+i)%1000) / 1000.0
+	}
+	return vec
+}
+
+func (m *VoyageMock) writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"detail": message,
+	})
+}
+
+type voyageRequest struct {
+	Model     string   `json:"model"`
+	Input     []string `json:"input"`
+	InputType string   `json:"input_type"`
+}
+
+type voyageResponse struct {
+	Object string            `json:"object"`
+	Data   []voyageEmbedding `json:"data"`
+	Model  string            `json:"model"`
+	Usage  voyageUsage       `json:"usage"`
+}
+
+type voyageEmbedding struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float64 `json:"embedding"`
+}
+
+type voyageUsage struct {
+	TotalTokens int `json:"total_tokens"`
+}
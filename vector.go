@@ -1,6 +1,29 @@
 package vex
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync/atomic"
+)
+
+// similarityPrecision holds the SimilarityPrecision used by Norm, Dot,
+// CosineSimilarity, and EuclideanDistance.
+var similarityPrecision atomic.Int32
+
+// SetSimilarityPrecision selects the accumulator type for Norm, Dot,
+// CosineSimilarity, and EuclideanDistance process-wide. The default,
+// PrecisionFloat64, is the most accurate; PrecisionFloat32 is faster for bulk
+// scoring and usually within 1e-4 at typical embedding sizes. Results are
+// returned as float64 either way.
+func SetSimilarityPrecision(p SimilarityPrecision) {
+	similarityPrecision.Store(int32(p))
+}
+
+// useFloat32 reports whether float32 accumulation is selected.
+func useFloat32() bool {
+	return similarityPrecision.Load() == int32(PrecisionFloat32)
+}
 
 // Normalize returns a unit vector (L2 normalized).
 func (v Vector) Normalize() Vector {
@@ -15,8 +38,38 @@ func (v Vector) Normalize() Vector {
 	return result
 }
 
+// Truncate returns the first dims components of v, re-normalized to unit
+// length, or nil if dims is not in [1, len(v)]. It is only meaningful for
+// models trained with Matryoshka representation learning (MRL), whose
+// leading components carry most of the meaning; e.g. storing 256 of 1536
+// components for a coarse first pass and the full vector for reranking.
+// Truncating other models' vectors discards information arbitrarily.
+func (v Vector) Truncate(dims int) Vector {
+	if dims <= 0 || dims > len(v) {
+		return nil
+	}
+	return slices.Clone(v[:dims]).Normalize()
+}
+
+// isZero reports whether every element of v is zero.
+func (v Vector) isZero() bool {
+	for _, val := range v {
+		if val != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // Norm returns the L2 norm (magnitude) of the vector.
 func (v Vector) Norm() float64 {
+	if useFloat32() {
+		var sum float32
+		for _, val := range v {
+			sum += val * val
+		}
+		return math.Sqrt(float64(sum))
+	}
 	var sum float64
 	for _, val := range v {
 		sum += float64(val) * float64(val)
@@ -29,6 +82,13 @@ func (v Vector) Dot(other Vector) float64 {
 	if len(v) != len(other) {
 		return 0
 	}
+	if useFloat32() {
+		var sum float32
+		for i := range v {
+			sum += v[i] * other[i]
+		}
+		return float64(sum)
+	}
 	var sum float64
 	for i := range v {
 		sum += float64(v[i]) * float64(other[i])
@@ -51,11 +111,26 @@ func (v Vector) CosineSimilarity(other Vector) float64 {
 	return dot / (normA * normB)
 }
 
+// DotNormalized computes cosine similarity assuming both operands are unit
+// vectors, e.g. stored with WithNormalize. It skips the norm computations of
+// CosineSimilarity, so it is only correct for normalized inputs.
+func (v Vector) DotNormalized(other Vector) float64 {
+	return v.Dot(other)
+}
+
 // EuclideanDistance computes the Euclidean distance to another vector.
 func (v Vector) EuclideanDistance(other Vector) float64 {
 	if len(v) != len(other) {
 		return math.MaxFloat64
 	}
+	if useFloat32() {
+		var sum float32
+		for i := range v {
+			diff := v[i] - other[i]
+			sum += diff * diff
+		}
+		return math.Sqrt(float64(sum))
+	}
 	var sum float64
 	for i := range v {
 		diff := float64(v[i]) - float64(other[i])
@@ -64,6 +139,34 @@ func (v Vector) EuclideanDistance(other Vector) float64 {
 	return math.Sqrt(sum)
 }
 
+// Equal reports whether v and other have the same length and identical
+// elements. Like ==, it treats NaN as unequal to itself.
+func (v Vector) Equal(other Vector) bool {
+	if len(v) != len(other) {
+		return false
+	}
+	for i := range v {
+		if v[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ApproxEqual reports whether v and other have the same length and every
+// pair of elements differs by at most tolerance.
+func (v Vector) ApproxEqual(other Vector, tolerance float64) bool {
+	if len(v) != len(other) {
+		return false
+	}
+	for i := range v {
+		if !(math.Abs(float64(v[i])-float64(other[i])) <= tolerance) {
+			return false
+		}
+	}
+	return true
+}
+
 // Similarity computes similarity using the specified metric.
 func (v Vector) Similarity(other Vector, metric SimilarityMetric) float64 {
 	switch metric {
@@ -80,25 +183,92 @@ func (v Vector) Similarity(other Vector, metric SimilarityMetric) float64 {
 	}
 }
 
+// SimilarityMatrix returns the pairwise similarities of vectors under metric,
+// where m[i][j] is vectors[i].Similarity(vectors[j], metric). The matrix is
+// symmetric, so only the upper triangle is computed. Cells for pairs whose
+// dimensions differ are 0. Returns nil for empty input.
+func SimilarityMatrix(vectors []Vector, metric SimilarityMetric) [][]float64 {
+	n := len(vectors)
+	if n == 0 {
+		return nil
+	}
+	cells := make([]float64, n*n)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = cells[i*n : (i+1)*n : (i+1)*n]
+	}
+	for i, a := range vectors {
+		for j := i; j < n; j++ {
+			b := vectors[j]
+			if len(a) != len(b) {
+				continue
+			}
+			sim := a.Similarity(b, metric)
+			m[i][j] = sim
+			m[j][i] = sim
+		}
+	}
+	return m
+}
+
 // Pool combines multiple vectors using the specified pooling mode.
+// Returns nil for empty input or when vector lengths differ; use PoolStrict
+// to learn which vector was at fault.
 func Pool(vectors []Vector, mode PoolingMode) Vector {
-	if len(vectors) == 0 {
+	pooled, err := PoolStrict(vectors, mode)
+	if err != nil {
 		return nil
 	}
+	return pooled
+}
+
+// PoolStrict is like Pool but returns an error wrapping ErrDimensionMismatch,
+// naming the offending vector, when vector lengths differ.
+func PoolStrict(vectors []Vector, mode PoolingMode) (Vector, error) {
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+	if err := checkDims(vectors); err != nil {
+		return nil, err
+	}
 	if len(vectors) == 1 {
-		return vectors[0]
+		return vectors[0], nil
+	}
+
+	if mode == PoolAdaptive {
+		mode = adaptivePoolingMode(len(vectors), DefaultAdaptivePoolThreshold)
 	}
 
 	switch mode {
 	case PoolFirst:
-		return vectors[0]
+		return vectors[0], nil
 	case PoolMax:
-		return poolMax(vectors)
+		return poolMax(vectors), nil
 	case PoolMean:
-		return poolMean(vectors)
+		return poolMean(vectors), nil
 	default:
-		return poolMean(vectors)
+		return poolMean(vectors), nil
+	}
+}
+
+// adaptivePoolingMode returns the mode PoolAdaptive applies to n vectors.
+func adaptivePoolingMode(n, threshold int) PoolingMode {
+	if n > threshold {
+		return PoolMax
+	}
+	return PoolMean
+}
+
+// checkDims returns an error wrapping ErrDimensionMismatch if any vector's
+// length differs from the first's.
+func checkDims(vectors []Vector) error {
+	dims := len(vectors[0])
+	for i, vec := range vectors[1:] {
+		if len(vec) != dims {
+			return fmt.Errorf("%w: vector %d has %d dimensions, vector 0 has %d", ErrDimensionMismatch, i+1, len(vec), dims)
+		}
 	}
+	return nil
 }
 
 func poolMean(vectors []Vector) Vector {
@@ -118,6 +288,24 @@ func poolMean(vectors []Vector) Vector {
 	return result
 }
 
+// poolWeightedMean averages vectors with the given per-vector weights.
+func poolWeightedMean(vectors []Vector, weights []float64) Vector {
+	dims := len(vectors[0])
+	sums := make([]float64, dims)
+	var total float64
+	for i, vec := range vectors {
+		total += weights[i]
+		for j, val := range vec {
+			sums[j] += weights[i] * float64(val)
+		}
+	}
+	result := make(Vector, dims)
+	for i := range result {
+		result[i] = float32(sums[i] / total)
+	}
+	return result
+}
+
 func poolMax(vectors []Vector) Vector {
 	dims := len(vectors[0])
 	result := make(Vector, dims)
@@ -131,3 +319,67 @@ func poolMax(vectors []Vector) Vector {
 	}
 	return result
 }
+
+// Centroid returns the element-wise mean of vectors.
+// Unlike Pool, a single input is copied rather than returned as-is.
+// Returns nil for empty input or when vector lengths differ.
+func Centroid(vectors []Vector) Vector {
+	if len(vectors) == 0 || checkDims(vectors) != nil {
+		return nil
+	}
+	return poolMean(vectors)
+}
+
+// DistanceToCentroid computes the Euclidean distance to the centroid of vectors.
+// Returns math.MaxFloat64 if the centroid is undefined or dimensions differ.
+func (v Vector) DistanceToCentroid(vectors []Vector) float64 {
+	centroid := Centroid(vectors)
+	if centroid == nil {
+		return math.MaxFloat64
+	}
+	return v.EuclideanDistance(centroid)
+}
+
+// PoolQueryWeighted combines chunk vectors weighted by their similarity to a query.
+// Weights are the softmax of each chunk's similarity to query under metric, so
+// chunks most relevant to the query dominate the result.
+// Returns nil for empty input or when chunk lengths differ.
+func PoolQueryWeighted(chunks []Vector, query Vector, metric SimilarityMetric) Vector {
+	if len(chunks) == 0 {
+		return nil
+	}
+	dims := len(chunks[0])
+	for _, vec := range chunks[1:] {
+		if len(vec) != dims {
+			return nil
+		}
+	}
+
+	// Softmax over similarities, shifted by the max for numerical stability
+	scores := make([]float64, len(chunks))
+	maxScore := math.Inf(-1)
+	for i, vec := range chunks {
+		scores[i] = vec.Similarity(query, metric)
+		if scores[i] > maxScore {
+			maxScore = scores[i]
+		}
+	}
+	var total float64
+	for i, score := range scores {
+		scores[i] = math.Exp(score - maxScore)
+		total += scores[i]
+	}
+
+	sums := make([]float64, dims)
+	for i, vec := range chunks {
+		weight := scores[i] / total
+		for j, val := range vec {
+			sums[j] += weight * float64(val)
+		}
+	}
+	result := make(Vector, dims)
+	for i := range result {
+		result[i] = float32(sums[i])
+	}
+	return result
+}
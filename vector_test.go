@@ -1,7 +1,10 @@
 package vex
 
 import (
+	"errors"
 	"math"
+	"math/rand/v2"
+	"strings"
 	"testing"
 )
 
@@ -42,6 +45,50 @@ func TestVector_Normalize(t *testing.T) {
 	})
 }
 
+func TestVector_Truncate(t *testing.T) {
+	t.Run("keeps leading components re-normalized", func(t *testing.T) {
+		vec := Vector{3, 4, 12}.Normalize()
+		truncated := vec.Truncate(2)
+
+		if !truncated.ApproxEqual(Vector{0.6, 0.8}, 1e-6) {
+			t.Errorf("expected [0.6 0.8], got %v", truncated)
+		}
+	})
+
+	t.Run("full length returns a copy", func(t *testing.T) {
+		vec := Vector{0.6, 0.8}
+		truncated := vec.Truncate(2)
+		if !truncated.ApproxEqual(vec, 1e-6) {
+			t.Errorf("expected %v, got %v", vec, truncated)
+		}
+		truncated[0] = 0
+		if vec[0] != 0.6 {
+			t.Error("expected truncation not to alias the original")
+		}
+	})
+
+	t.Run("zero prefix stays zero", func(t *testing.T) {
+		vec := Vector{0, 0, 1}
+		truncated := vec.Truncate(2)
+		if !truncated.Equal(Vector{0, 0}) {
+			t.Errorf("expected zero vector, got %v", truncated)
+		}
+		truncated[0] = 1
+		if vec[0] != 0 {
+			t.Error("expected truncation not to alias the original")
+		}
+	})
+
+	t.Run("rejects out of range dims", func(t *testing.T) {
+		vec := Vector{1, 2, 3}
+		for _, dims := range []int{0, -1, 4} {
+			if got := vec.Truncate(dims); got != nil {
+				t.Errorf("dims %d: expected nil, got %v", dims, got)
+			}
+		}
+	})
+}
+
 func TestVector_Norm(t *testing.T) {
 	t.Run("calculates correct L2 norm", func(t *testing.T) {
 		vec := Vector{3, 4}
@@ -140,6 +187,18 @@ func TestVector_CosineSimilarity(t *testing.T) {
 	})
 }
 
+func TestVector_DotNormalized(t *testing.T) {
+	a := Vector{3, 4, 0}.Normalize()
+	b := Vector{4, 3, 12}.Normalize()
+
+	if got, want := a.DotNormalized(b), a.CosineSimilarity(b); math.Abs(got-want) > 1e-6 {
+		t.Errorf("expected %f for unit vectors, got %f", want, got)
+	}
+	if got := (Vector{1, 2}).DotNormalized(Vector{1}); got != 0 {
+		t.Errorf("expected 0 for mismatched lengths, got %f", got)
+	}
+}
+
 func TestVector_EuclideanDistance(t *testing.T) {
 	t.Run("calculates correct distance", func(t *testing.T) {
 		v1 := Vector{0, 0}
@@ -208,6 +267,66 @@ func TestVector_Similarity(t *testing.T) {
 	})
 }
 
+func TestSimilarityMatrix(t *testing.T) {
+	t.Run("empty input", func(t *testing.T) {
+		if m := SimilarityMatrix(nil, Cosine); m != nil {
+			t.Errorf("expected nil, got %v", m)
+		}
+	})
+
+	t.Run("matches pairwise similarity", func(t *testing.T) {
+		vectors := []Vector{{1, 2, 3}, {4, 5, 6}, {-1, 0, 2}}
+		for _, metric := range []SimilarityMetric{Cosine, DotProduct, Euclidean} {
+			m := SimilarityMatrix(vectors, metric)
+			for i := range vectors {
+				for j := range vectors {
+					want := vectors[i].Similarity(vectors[j], metric)
+					if math.Abs(m[i][j]-want) > 1e-9 {
+						t.Errorf("metric %d: m[%d][%d] = %f, expected %f", metric, i, j, m[i][j], want)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("dimension mismatch", func(t *testing.T) {
+		vectors := []Vector{{1, 0}, {1, 0, 0}}
+		for _, metric := range []SimilarityMetric{Cosine, DotProduct, Euclidean} {
+			m := SimilarityMatrix(vectors, metric)
+			if m[0][1] != 0 || m[1][0] != 0 {
+				t.Errorf("metric %d: expected 0 for mismatched pair, got %f and %f", metric, m[0][1], m[1][0])
+			}
+			if m[0][0] == 0 || m[1][1] == 0 {
+				t.Errorf("metric %d: expected self-similarity on the diagonal", metric)
+			}
+		}
+	})
+}
+
+func TestSetSimilarityPrecision(t *testing.T) {
+	t.Cleanup(func() { SetSimilarityPrecision(PrecisionFloat64) })
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	a, b := make(Vector, 1536), make(Vector, 1536)
+	for i := range a {
+		a[i] = rng.Float32()*2 - 1
+		b[i] = rng.Float32()*2 - 1
+	}
+
+	SetSimilarityPrecision(PrecisionFloat64)
+	want := []float64{a.Norm(), a.Dot(b), a.CosineSimilarity(b), a.EuclideanDistance(b)}
+
+	SetSimilarityPrecision(PrecisionFloat32)
+	got := []float64{a.Norm(), a.Dot(b), a.CosineSimilarity(b), a.EuclideanDistance(b)}
+
+	names := []string{"Norm", "Dot", "CosineSimilarity", "EuclideanDistance"}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-4*math.Max(1, math.Abs(want[i])) {
+			t.Errorf("%s: float32 result %v differs from float64 result %v", names[i], got[i], want[i])
+		}
+	}
+}
+
 func TestPool(t *testing.T) {
 	t.Run("returns nil for empty input", func(t *testing.T) {
 		result := Pool([]Vector{}, PoolMean)
@@ -218,13 +337,8 @@ func TestPool(t *testing.T) {
 
 	t.Run("returns single vector unchanged", func(t *testing.T) {
 		vec := Vector{1, 2, 3}
-		result := Pool([]Vector{vec}, PoolMean)
-
-		for i := range vec {
-			if result[i] != vec[i] {
-				t.Errorf("single vector should be returned unchanged")
-				break
-			}
+		if result := Pool([]Vector{vec}, PoolMean); !result.Equal(vec) {
+			t.Errorf("single vector should be returned unchanged, got %v", result)
 		}
 	})
 
@@ -235,11 +349,8 @@ func TestPool(t *testing.T) {
 		}
 		expected := Vector{1, 3, 5}
 
-		result := Pool(vectors, PoolMean)
-		for i := range expected {
-			if result[i] != expected[i] {
-				t.Errorf("at index %d: expected %f, got %f", i, expected[i], result[i])
-			}
+		if result := Pool(vectors, PoolMean); !result.Equal(expected) {
+			t.Errorf("expected %v, got %v", expected, result)
 		}
 	})
 
@@ -250,11 +361,8 @@ func TestPool(t *testing.T) {
 		}
 		expected := Vector{4, 5, 6}
 
-		result := Pool(vectors, PoolMax)
-		for i := range expected {
-			if result[i] != expected[i] {
-				t.Errorf("at index %d: expected %f, got %f", i, expected[i], result[i])
-			}
+		if result := Pool(vectors, PoolMax); !result.Equal(expected) {
+			t.Errorf("expected %v, got %v", expected, result)
 		}
 	})
 
@@ -264,12 +372,199 @@ func TestPool(t *testing.T) {
 			{4, 5, 6},
 		}
 
-		result := Pool(vectors, PoolFirst)
-		for i := range vectors[0] {
-			if result[i] != vectors[0][i] {
-				t.Errorf("expected first vector")
-				break
+		if result := Pool(vectors, PoolFirst); !result.Equal(vectors[0]) {
+			t.Errorf("expected first vector, got %v", result)
+		}
+	})
+
+	t.Run("mismatched dimensions", func(t *testing.T) {
+		vectors := []Vector{
+			{1, 2, 3},
+			{},
+			{4, 5},
+		}
+
+		if result := Pool(vectors, PoolMean); result != nil {
+			t.Errorf("expected nil, got %v", result)
+		}
+		_, err := PoolStrict(vectors, PoolMax)
+		if !errors.Is(err, ErrDimensionMismatch) {
+			t.Fatalf("expected ErrDimensionMismatch, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "vector 1") {
+			t.Errorf("expected error to name vector 1, got %q", err)
+		}
+	})
+
+	t.Run("adaptive switches to max above threshold", func(t *testing.T) {
+		few := make([]Vector, DefaultAdaptivePoolThreshold)
+		for i := range few {
+			few[i] = Vector{float32(i)}
+		}
+		if got := Pool(few, PoolAdaptive); got[0] != Pool(few, PoolMean)[0] {
+			t.Errorf("expected mean at threshold, got %v", got)
+		}
+		many := make([]Vector, 0, len(few)+1)
+		many = append(many, few...)
+		many = append(many, Vector{100})
+		if got := Pool(many, PoolAdaptive); got[0] != 100 {
+			t.Errorf("expected max above threshold, got %v", got)
+		}
+	})
+}
+
+func TestCentroid(t *testing.T) {
+	t.Run("averages vectors", func(t *testing.T) {
+		vectors := []Vector{
+			{0, 2, 4},
+			{2, 4, 6},
+			{4, 6, 8},
+		}
+		expected := Vector{2, 4, 6}
+
+		if result := Centroid(vectors); !result.Equal(expected) {
+			t.Errorf("expected %v, got %v", expected, result)
+		}
+	})
+
+	t.Run("returns nil for empty input", func(t *testing.T) {
+		if Centroid(nil) != nil {
+			t.Error("expected nil for empty input")
+		}
+	})
+
+	t.Run("copies single vector", func(t *testing.T) {
+		vec := Vector{1, 2, 3}
+		result := Centroid([]Vector{vec})
+
+		result[0] = 99
+		if vec[0] != 1 {
+			t.Error("centroid of single vector should not alias the input")
+		}
+	})
+
+	t.Run("returns nil for differing lengths", func(t *testing.T) {
+		vectors := []Vector{
+			{1, 2, 3},
+			{4, 5},
+		}
+		if Centroid(vectors) != nil {
+			t.Error("expected nil for vectors of differing lengths")
+		}
+	})
+}
+
+func TestVector_DistanceToCentroid(t *testing.T) {
+	t.Run("calculates distance to centroid", func(t *testing.T) {
+		vectors := []Vector{
+			{0, 0},
+			{2, 0},
+		}
+		vec := Vector{1, 1}
+
+		dist := vec.DistanceToCentroid(vectors)
+		if math.Abs(dist-1.0) > 0.0001 {
+			t.Errorf("expected distance 1.0, got %f", dist)
+		}
+	})
+
+	t.Run("returns MaxFloat64 for undefined centroid", func(t *testing.T) {
+		vec := Vector{1, 1}
+		if vec.DistanceToCentroid(nil) != math.MaxFloat64 {
+			t.Error("expected MaxFloat64 for empty input")
+		}
+		if vec.DistanceToCentroid([]Vector{{1, 2}, {1}}) != math.MaxFloat64 {
+			t.Error("expected MaxFloat64 for differing lengths")
+		}
+	})
+}
+
+func TestPoolQueryWeighted(t *testing.T) {
+	t.Run("chunk matching the query dominates", func(t *testing.T) {
+		chunks := []Vector{
+			{10, 0, 0},
+			{0, 10, 0},
+			{0, 0, 10},
+		}
+		query := Vector{0, 10, 0}
+
+		result := PoolQueryWeighted(chunks, query, DotProduct)
+		if result[1] < 9.9 {
+			t.Errorf("expected matching chunk to dominate, got %v", result)
+		}
+		if result[0] > 0.1 || result[2] > 0.1 {
+			t.Errorf("expected non-matching chunks to be suppressed, got %v", result)
+		}
+	})
+
+	t.Run("equal similarity yields mean", func(t *testing.T) {
+		chunks := []Vector{
+			{1, 0},
+			{0, 1},
+		}
+		query := Vector{1, 1}
+
+		result := PoolQueryWeighted(chunks, query, Cosine)
+		for i, v := range result {
+			if math.Abs(float64(v)-0.5) > 0.0001 {
+				t.Errorf("at index %d: expected 0.5, got %f", i, v)
 			}
 		}
 	})
+
+	t.Run("returns nil for empty input", func(t *testing.T) {
+		if PoolQueryWeighted(nil, Vector{1}, Cosine) != nil {
+			t.Error("expected nil for empty input")
+		}
+	})
+
+	t.Run("returns nil for differing lengths", func(t *testing.T) {
+		chunks := []Vector{{1, 2}, {1}}
+		if PoolQueryWeighted(chunks, Vector{1, 2}, Cosine) != nil {
+			t.Error("expected nil for chunks of differing lengths")
+		}
+	})
+}
+
+func TestVector_Equal(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Vector
+		want bool
+	}{
+		{"identical", Vector{1, 2, 3}, Vector{1, 2, 3}, true},
+		{"both empty", Vector{}, nil, true},
+		{"differing element", Vector{1, 2, 3}, Vector{1, 2, 3.0001}, false},
+		{"differing length", Vector{1, 2}, Vector{1, 2, 0}, false},
+		{"NaN", Vector{float32(math.NaN())}, Vector{float32(math.NaN())}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVector_ApproxEqual(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      Vector
+		tolerance float64
+		want      bool
+	}{
+		{"within tolerance", Vector{1, 2, 3}, Vector{1.001, 2, 2.999}, 0.01, true},
+		{"outside tolerance", Vector{1, 2, 3}, Vector{1, 2.1, 3}, 0.01, false},
+		{"zero tolerance is exact", Vector{1, 2}, Vector{1, 2}, 0, true},
+		{"differing length", Vector{1, 2}, Vector{1, 2, 0}, 1, false},
+		{"NaN", Vector{float32(math.NaN())}, Vector{0}, math.Inf(1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.ApproxEqual(tt.b, tt.tolerance); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
 }
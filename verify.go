@@ -0,0 +1,191 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// Thresholds used by VerifyStored to spot corpus-wide problems.
+const (
+	// verifyBatchSize caps the sampled texts re-embedded per Batch call.
+	verifyBatchSize = 64
+
+	// normDriftTolerance is how far a stored vector's norm may differ from
+	// the fresh vector's before it counts as normalization drift.
+	normDriftTolerance = 1e-3
+
+	// modelChangeSpread is the similarity standard deviation below which a
+	// uniformly low similarity is read as a model change rather than
+	// scattered corruption.
+	modelChangeSpread = 0.05
+
+	// modelChangeMinSample is the smallest sample on which a model change
+	// is reported.
+	modelChangeMinSample = 5
+)
+
+// StoredVector is a persisted embedding and the text it was computed from.
+type StoredVector struct {
+	ID     string
+	Text   string
+	Vector Vector
+}
+
+// VectorReader iterates over a stored corpus. Next returns io.EOF once
+// every item has been read.
+type VectorReader interface {
+	Next(ctx context.Context) (StoredVector, error)
+}
+
+// SimilarityDistribution summarizes cosine similarities. Percentiles use
+// the nearest-rank method.
+type SimilarityDistribution struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	P10    float64 `json:"p10"`
+	P50    float64 `json:"p50"`
+}
+
+// StoredVectorIssue flags a sampled item whose stored vector disagrees with
+// a fresh embedding of its text.
+type StoredVectorIssue struct {
+	ID         string  `json:"id"`
+	Similarity float64 `json:"similarity"` // Zero when dimensions differ
+	StoredNorm float64 `json:"stored_norm"`
+	FreshNorm  float64 `json:"fresh_norm"`
+}
+
+// VerifyReport is the result of VerifyStored.
+type VerifyReport struct {
+	Scanned    int                    `json:"scanned"`
+	Sampled    int                    `json:"sampled"`
+	Similarity SimilarityDistribution `json:"similarity"`
+
+	// Flagged lists sampled items below the similarity tolerance.
+	Flagged []StoredVectorIssue `json:"flagged,omitempty"`
+
+	// NormDrift counts sampled items whose stored norm differs from the
+	// fresh vector's, e.g. vectors re-normalized or stored unnormalized.
+	NormDrift int `json:"norm_drift"`
+
+	// ModelChangeSuspected is set when similarities are below tolerance
+	// but tightly clustered, the signature of vectors produced by another
+	// model rather than of corruption in individual items.
+	ModelChangeSuspected bool `json:"model_change_suspected"`
+}
+
+// VerifyStored checks a stored corpus for bit rot, normalization drift and
+// model changes. It samples items from reader at sampleRate (in (0, 1]),
+// re-embeds their Text with svc and compares each stored vector with the
+// fresh one by cosine similarity, flagging items below tolerance. Reader
+// errors other than io.EOF and embedding failures abort verification.
+func VerifyStored(ctx context.Context, reader VectorReader, svc *Service, sampleRate, tolerance float64) (VerifyReport, error) {
+	if sampleRate <= 0 || sampleRate > 1 {
+		return VerifyReport{}, fmt.Errorf("vex: sample rate %v must be in (0, 1]", sampleRate)
+	}
+
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())) //nolint:gosec // sampling needs no cryptographic randomness
+	var report VerifyReport
+	var similarities []float64
+	batch := make([]StoredVector, 0, verifyBatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		texts := make([]string, len(batch))
+		for i, item := range batch {
+			texts[i] = item.Text
+		}
+		fresh, err := svc.Batch(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("re-embedding sample: %w", err)
+		}
+		for i, item := range batch {
+			sim := item.Vector.CosineSimilarity(fresh[i])
+			storedNorm, freshNorm := item.Vector.Norm(), fresh[i].Norm()
+			similarities = append(similarities, sim)
+			if math.Abs(storedNorm-freshNorm) > normDriftTolerance {
+				report.NormDrift++
+			}
+			if sim < tolerance {
+				report.Flagged = append(report.Flagged, StoredVectorIssue{
+					ID:         item.ID,
+					Similarity: sim,
+					StoredNorm: storedNorm,
+					FreshNorm:  freshNorm,
+				})
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		item, err := reader.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return VerifyReport{}, fmt.Errorf("reading stored vectors: %w", err)
+		}
+		report.Scanned++
+		if sampleRate < 1 && rng.Float64() >= sampleRate {
+			continue
+		}
+		batch = append(batch, item)
+		if len(batch) == verifyBatchSize {
+			if err := flush(); err != nil {
+				return VerifyReport{}, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return VerifyReport{}, err
+	}
+
+	report.Sampled = len(similarities)
+	report.Similarity = similarityDistribution(similarities)
+	report.ModelChangeSuspected = report.Sampled >= modelChangeMinSample &&
+		report.Similarity.Max < tolerance &&
+		report.Similarity.StdDev < modelChangeSpread
+	return report, nil
+}
+
+// similarityDistribution summarizes values, which it sorts in place.
+func similarityDistribution(values []float64) SimilarityDistribution {
+	if len(values) == 0 {
+		return SimilarityDistribution{}
+	}
+	slices.Sort(values)
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	rank := func(p int) float64 {
+		r := int(math.Ceil(float64(p) / 100 * float64(len(values))))
+		return values[max(r-1, 0)]
+	}
+	return SimilarityDistribution{
+		Count:  len(values),
+		Min:    values[0],
+		Max:    values[len(values)-1],
+		Mean:   mean,
+		StdDev: math.Sqrt(variance / float64(len(values))),
+		P10:    rank(10),
+		P50:    rank(50),
+	}
+}
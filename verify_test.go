@@ -0,0 +1,130 @@
+package vex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// sliceReader is an in-memory VectorReader.
+type sliceReader struct {
+	items []StoredVector
+	err   error
+}
+
+func (r *sliceReader) Next(_ context.Context) (StoredVector, error) {
+	if len(r.items) == 0 {
+		if r.err != nil {
+			return StoredVector{}, r.err
+		}
+		return StoredVector{}, io.EOF
+	}
+	item := r.items[0]
+	r.items = r.items[1:]
+	return item, nil
+}
+
+func TestVerifyStored(t *testing.T) {
+	const dims = 8
+	svc := NewService(newMockProvider(dims))
+	good, err := svc.Embed(context.Background(), "reference")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scaled := make(Vector, dims)
+	corrupted := make(Vector, dims)
+	for i, v := range good {
+		scaled[i] = v * 2
+		corrupted[i] = v
+	}
+	corrupted[dims-1] = -corrupted[dims-1]
+	corrupted[dims-2] = -corrupted[dims-2]
+
+	t.Run("flags corrupted items and norm drift", func(t *testing.T) {
+		reader := &sliceReader{items: []StoredVector{
+			{ID: "ok-1", Text: "a", Vector: good},
+			{ID: "bad", Text: "b", Vector: corrupted},
+			{ID: "scaled", Text: "c", Vector: scaled},
+			{ID: "ok-2", Text: "d", Vector: good},
+		}}
+		report, err := VerifyStored(context.Background(), reader, svc, 1, 0.99)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if report.Scanned != 4 || report.Sampled != 4 {
+			t.Errorf("expected 4 scanned and sampled, got %d and %d", report.Scanned, report.Sampled)
+		}
+		if len(report.Flagged) != 1 || report.Flagged[0].ID != "bad" {
+			t.Errorf("expected only the corrupted item flagged, got %+v", report.Flagged)
+		}
+		if report.NormDrift != 1 {
+			t.Errorf("expected 1 norm drift, got %d", report.NormDrift)
+		}
+		if report.ModelChangeSuspected {
+			t.Error("did not expect a model change")
+		}
+		if report.Similarity.Max < 0.9999 || report.Similarity.Min >= 0.99 {
+			t.Errorf("unexpected similarity distribution: %+v", report.Similarity)
+		}
+	})
+
+	t.Run("detects model change", func(t *testing.T) {
+		// Every stored vector is the same unrelated direction, as if the
+		// corpus was embedded by a different model.
+		stale := make(Vector, dims)
+		stale[0] = 1
+		items := make([]StoredVector, 10)
+		for i := range items {
+			items[i] = StoredVector{ID: "stale", Text: "x", Vector: stale}
+		}
+		report, err := VerifyStored(context.Background(), &sliceReader{items: items}, svc, 1, 0.9)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(report.Flagged) != 10 {
+			t.Errorf("expected all items flagged, got %d", len(report.Flagged))
+		}
+		if !report.ModelChangeSuspected {
+			t.Errorf("expected model change, got %+v", report.Similarity)
+		}
+	})
+
+	t.Run("samples a fraction", func(t *testing.T) {
+		items := make([]StoredVector, 1000)
+		for i := range items {
+			items[i] = StoredVector{ID: "ok", Text: "x", Vector: good}
+		}
+		report, err := VerifyStored(context.Background(), &sliceReader{items: items}, svc, 0.1, 0.99)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Scanned != 1000 {
+			t.Errorf("expected 1000 scanned, got %d", report.Scanned)
+		}
+		if report.Sampled < 50 || report.Sampled > 150 {
+			t.Errorf("expected roughly 100 sampled, got %d", report.Sampled)
+		}
+		if len(report.Flagged) != 0 {
+			t.Errorf("expected nothing flagged, got %d", len(report.Flagged))
+		}
+	})
+
+	t.Run("returns reader error", func(t *testing.T) {
+		readErr := errors.New("disk failure")
+		reader := &sliceReader{items: []StoredVector{{ID: "ok", Text: "a", Vector: good}}, err: readErr}
+		if _, err := VerifyStored(context.Background(), reader, svc, 1, 0.99); !errors.Is(err, readErr) {
+			t.Errorf("expected reader error, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid sample rate", func(t *testing.T) {
+		for _, rate := range []float64{0, -0.5, 1.5} {
+			if _, err := VerifyStored(context.Background(), &sliceReader{}, svc, rate, 0.99); err == nil {
+				t.Errorf("expected error for sample rate %v", rate)
+			}
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package vex
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// modulePath is this module's import path, used to find its version in
+// build info.
+const modulePath = "github.com/zoobzio/vex"
+
+// develVersion is reported when no version can be determined, e.g. in tests
+// or binaries built from a local checkout.
+const develVersion = "devel"
+
+// version overrides the detected version when set at link time:
+//
+//	go build -ldflags "-X github.com/zoobzio/vex.version=v1.2.3"
+var version string
+
+// buildVersion reads the module version from the running binary's build
+// info once; it cannot change at runtime.
+var buildVersion = sync.OnceValue(func() string {
+	return versionFromBuildInfo(debug.ReadBuildInfo())
+})
+
+// Version returns the library version: the value stamped with -ldflags if
+// any, otherwise the vex module version recorded in the binary's build
+// info, otherwise "devel".
+func Version() string {
+	if version != "" {
+		return version
+	}
+	return buildVersion()
+}
+
+// versionFromBuildInfo returns the vex module version from info, whether vex
+// is the main module or a dependency, honoring replace directives.
+func versionFromBuildInfo(info *debug.BuildInfo, ok bool) string {
+	if !ok || info == nil {
+		return develVersion
+	}
+	if info.Main.Path == modulePath {
+		return moduleVersion(&info.Main)
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return moduleVersion(dep)
+		}
+	}
+	return develVersion
+}
+
+// moduleVersion returns m's version, preferring its replacement's, and
+// mapping the "(devel)" placeholder to develVersion.
+func moduleVersion(m *debug.Module) string {
+	if m.Replace != nil {
+		m = m.Replace
+	}
+	if m.Version == "" || m.Version == "(devel)" {
+		return develVersion
+	}
+	return m.Version
+}
@@ -0,0 +1,96 @@
+package vex
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	t.Run("defaults to build info", func(t *testing.T) {
+		if got, want := Version(), buildVersion(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+		if Version() == "" {
+			t.Error("expected non-empty version")
+		}
+	})
+
+	t.Run("ldflags override", func(t *testing.T) {
+		old := version
+		version = "v9.9.9"
+		defer func() { version = old }()
+
+		if got := Version(); got != "v9.9.9" {
+			t.Errorf("expected v9.9.9, got %q", got)
+		}
+	})
+}
+
+func TestVersionFromBuildInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		info *debug.BuildInfo
+		ok   bool
+		want string
+	}{
+		{"unavailable", nil, false, "devel"},
+		{
+			"dependency",
+			&debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/app", Version: "v0.1.0"},
+				Deps: []*debug.Module{{Path: modulePath, Version: "v1.4.2"}},
+			},
+			true,
+			"v1.4.2",
+		},
+		{
+			"replaced dependency",
+			&debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/app"},
+				Deps: []*debug.Module{{Path: modulePath, Version: "v1.4.2", Replace: &debug.Module{Path: "../vex"}}},
+			},
+			true,
+			"devel",
+		},
+		{
+			"main module",
+			&debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v1.5.0"}},
+			true,
+			"v1.5.0",
+		},
+		{
+			"local checkout",
+			&debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "(devel)"}},
+			true,
+			"devel",
+		},
+		{
+			"not linked",
+			&debug.BuildInfo{Main: debug.Module{Path: "example.com/app", Version: "v0.1.0"}},
+			true,
+			"devel",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionFromBuildInfo(tt.info, tt.ok); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFingerprint_LibraryVersion(t *testing.T) {
+	svc := NewService(newMockProvider(3))
+	if got := svc.FingerprintDetails().LibraryVersion; got != Version() {
+		t.Errorf("expected library version %q, got %q", Version(), got)
+	}
+
+	before := svc.Fingerprint()
+	old := version
+	version = "v9.9.9"
+	defer func() { version = old }()
+	if svc.Fingerprint() != before {
+		t.Error("expected fingerprint to ignore the library version")
+	}
+}
@@ -0,0 +1,176 @@
+package voyage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/zoobzio/vex"
+)
+
+// ModelMultimodal3 embeds interleaved text and images into one vector space.
+const ModelMultimodal3 = "voyage-multimodal-3"
+
+// DimensionsVoyageMultimodal3 is the output dimensionality of
+// voyage-multimodal-3.
+const DimensionsVoyageMultimodal3 = 1024
+
+// MultimodalPart is one element of a MultimodalInput. Set exactly one of
+// Text, Image or ImageURL.
+type MultimodalPart struct {
+	Text     string
+	Image    []byte // Encoded PNG, JPEG, WebP or GIF, sent base64-encoded
+	ImageURL string // Publicly fetchable image URL
+}
+
+// MultimodalInput is a sequence of text and image parts embedded together as
+// a single vector, e.g. a caption followed by its image.
+type MultimodalInput []MultimodalPart
+
+// TextPart returns a text MultimodalPart.
+func TextPart(text string) MultimodalPart {
+	return MultimodalPart{Text: text}
+}
+
+// ImagePart returns a MultimodalPart for encoded image bytes.
+func ImagePart(image []byte) MultimodalPart {
+	return MultimodalPart{Image: image}
+}
+
+// ImageURLPart returns a MultimodalPart for an image at url.
+func ImageURLPart(url string) MultimodalPart {
+	return MultimodalPart{ImageURL: url}
+}
+
+// EmbedMultimodal generates one embedding per input from the
+// /multimodalembeddings endpoint. It uses the configured model if it is a
+// multimodal one and ModelMultimodal3 otherwise, along with the configured
+// input type and truncation. Usage reports the tokens billed, which include
+// images, and the image pixels processed.
+func (p *Provider) EmbedMultimodal(ctx context.Context, inputs []MultimodalInput) (*vex.EmbeddingResponse, error) {
+	model := p.model
+	if !strings.HasPrefix(model, "voyage-multimodal") {
+		model = ModelMultimodal3
+	}
+	if len(inputs) == 0 {
+		return &vex.EmbeddingResponse{Model: model, Dimensions: DimensionsVoyageMultimodal3}, nil
+	}
+
+	reqBody := multimodalRequest{
+		Model:      model,
+		Inputs:     make([]multimodalInput, len(inputs)),
+		InputType:  string(p.inputType),
+		Truncation: p.truncation,
+	}
+	for i, input := range inputs {
+		content, err := toContent(input)
+		if err != nil {
+			return nil, fmt.Errorf("voyage: input %d: %w", i, err)
+		}
+		reqBody.Inputs[i] = multimodalInput{Content: content}
+	}
+
+	body, err := p.post(ctx, "/multimodalembeddings", reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var embResp multimodalResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(embResp.Data) != len(inputs) {
+		return nil, fmt.Errorf("voyage: expected %d embeddings, got %d", len(inputs), len(embResp.Data))
+	}
+	vectors := make([]vex.Vector, len(embResp.Data))
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("invalid index %d from API", d.Index)
+		}
+		vectors[d.Index] = toFloat32(d.Embedding)
+	}
+	if err := vex.ValidateVectors("voyage", vectors); err != nil {
+		return nil, err
+	}
+
+	return &vex.EmbeddingResponse{
+		Vectors:    vectors,
+		Model:      embResp.Model,
+		Dimensions: len(vectors[0]),
+		Usage: vex.Usage{
+			PromptTokens: embResp.Usage.TotalTokens,
+			TotalTokens:  embResp.Usage.TotalTokens,
+			ImagePixels:  embResp.Usage.ImagePixels,
+		},
+	}, nil
+}
+
+// toContent converts input to API content parts.
+func toContent(input MultimodalInput) ([]contentPart, error) {
+	if len(input) == 0 {
+		return nil, errors.New("no content")
+	}
+	content := make([]contentPart, len(input))
+	for i, part := range input {
+		set := 0
+		if part.Text != "" {
+			set++
+		}
+		if len(part.Image) > 0 {
+			set++
+		}
+		if part.ImageURL != "" {
+			set++
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("part %d must set exactly one of Text, Image, or ImageURL", i)
+		}
+		switch {
+		case part.Text != "":
+			content[i] = contentPart{Type: "text", Text: part.Text}
+		case part.ImageURL != "":
+			content[i] = contentPart{Type: "image_url", ImageURL: part.ImageURL}
+		default:
+			uri := "data:" + http.DetectContentType(part.Image) + ";base64," + base64.StdEncoding.EncodeToString(part.Image)
+			content[i] = contentPart{Type: "image_base64", ImageBase64: uri}
+		}
+	}
+	return content, nil
+}
+
+// Multimodal API types
+
+type multimodalRequest struct {
+	Model      string            `json:"model"`
+	Inputs     []multimodalInput `json:"inputs"`
+	InputType  string            `json:"input_type,omitempty"`
+	Truncation *bool             `json:"truncation,omitempty"`
+}
+
+type multimodalInput struct {
+	Content []contentPart `json:"content"`
+}
+
+type contentPart struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	ImageBase64 string `json:"image_base64,omitempty"`
+}
+
+type multimodalResponse struct {
+	Model string          `json:"model"`
+	Data  []embeddingData `json:"data"`
+	Usage multimodalUsage `json:"usage"`
+}
+
+type multimodalUsage struct {
+	TextTokens  int `json:"text_tokens"`
+	ImagePixels int `json:"image_pixels"`
+	TotalTokens int `json:"total_tokens"`
+}
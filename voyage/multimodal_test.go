@@ -0,0 +1,98 @@
+package voyage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tinyPNG encodes a 1x1 gray PNG.
+func tinyPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.RGBA{R: 128, G: 128, B: 128, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProvider_EmbedMultimodal(t *testing.T) {
+	t.Run("sends mixed content and maps usage", func(t *testing.T) {
+		var req multimodalRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/multimodalembeddings" {
+				t.Errorf("expected /multimodalembeddings, got %s", r.URL.Path)
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"model": "voyage-multimodal-3",
+				"data": [{"index": 1, "embedding": [0.3, 0.4]}, {"index": 0, "embedding": [0.1, 0.2]}],
+				"usage": {"text_tokens": 5, "image_pixels": 2000000, "total_tokens": 3576}}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+		resp, err := p.EmbedMultimodal(context.Background(), []MultimodalInput{
+			{TextPart("A gray square"), ImagePart(tinyPNG(t))},
+			{ImageURLPart("https://example.com/cat.jpg")},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if req.Model != ModelMultimodal3 || req.InputType != string(InputTypeDocument) {
+			t.Errorf("expected %s document request, got %s %s", ModelMultimodal3, req.Model, req.InputType)
+		}
+		first := req.Inputs[0].Content
+		if len(first) != 2 || first[0].Type != "text" || first[0].Text != "A gray square" {
+			t.Fatalf("unexpected first input: %+v", first)
+		}
+		if first[1].Type != "image_base64" || !strings.HasPrefix(first[1].ImageBase64, "data:image/png;base64,") {
+			t.Errorf("expected base64 PNG data URI, got %+v", first[1])
+		}
+		second := req.Inputs[1].Content
+		if second[0].Type != "image_url" || second[0].ImageURL != "https://example.com/cat.jpg" {
+			t.Errorf("expected image URL part, got %+v", second[0])
+		}
+
+		if resp.Vectors[0][0] != 0.1 || resp.Vectors[1][0] != 0.3 || resp.Dimensions != 2 {
+			t.Errorf("unexpected vectors: %v", resp.Vectors)
+		}
+		if resp.Usage.TotalTokens != 3576 || resp.Usage.ImagePixels != 2000000 {
+			t.Errorf("unexpected usage: %+v", resp.Usage)
+		}
+	})
+
+	t.Run("rejects ambiguous parts", func(t *testing.T) {
+		p := New(Config{APIKey: "test-key", BaseURL: "http://unused"})
+		inputs := [][]MultimodalInput{
+			{{}},
+			{{{Text: "caption", ImageURL: "https://example.com/cat.jpg"}}},
+			{{MultimodalPart{}}},
+		}
+		for _, in := range inputs {
+			if _, err := p.EmbedMultimodal(context.Background(), in); err == nil {
+				t.Errorf("expected error for %+v", in)
+			}
+		}
+	})
+
+	t.Run("handles empty input", func(t *testing.T) {
+		p := New(Config{APIKey: "test"})
+		resp, err := p.EmbedMultimodal(context.Background(), nil)
+		if err != nil || resp.Vectors != nil {
+			t.Errorf("expected empty response, got %v, %v", resp, err)
+		}
+	})
+}
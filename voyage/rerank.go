@@ -0,0 +1,173 @@
+package voyage
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/zoobzio/vex"
+	"github.com/zoobzio/vex/internal/httputil"
+)
+
+// Rerank model identifiers.
+const (
+	RerankModel2     = "rerank-2"
+	RerankModel2Lite = "rerank-2-lite"
+)
+
+// Reranker implements vex.Reranker for the Voyage AI rerank API.
+type Reranker struct {
+	httpClient *http.Client
+	apiKey     string
+	model      string
+	baseURL    string
+	truncation *bool
+}
+
+// RerankerConfig holds configuration for the Voyage AI reranker.
+type RerankerConfig struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+	Timeout time.Duration
+
+	// Truncation controls whether query and documents that exceed the
+	// model's context are truncated. When false they are rejected with a
+	// ProviderError wrapping vex.ErrContextLengthExceeded. Optional; nil
+	// leaves the API default, which truncates.
+	Truncation *bool
+
+	// HTTPClient is used for all requests when set, e.g. for proxies, mTLS,
+	// or instrumented transports. Timeout is applied if the client has none.
+	HTTPClient *http.Client
+}
+
+// NewReranker creates a new Voyage AI reranker.
+func NewReranker(config RerankerConfig) *Reranker {
+	if config.Model == "" {
+		config.Model = RerankModel2
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.voyageai.com/v1"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &Reranker{
+		apiKey:     config.APIKey,
+		model:      config.Model,
+		baseURL:    config.BaseURL,
+		truncation: config.Truncation,
+		httpClient: httputil.NewClient(config.HTTPClient, config.Timeout),
+	}
+}
+
+// Model returns the rerank model identifier.
+func (r *Reranker) Model() string {
+	return r.model
+}
+
+// Rerank scores documents against query and returns the topK most relevant
+// in descending order of relevance score. Implements vex.Reranker.
+func (r *Reranker) Rerank(ctx context.Context, query string, documents []string, topK int) ([]vex.RerankResult, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	reqBody := rerankRequest{
+		Query:      query,
+		Documents:  documents,
+		Model:      r.model,
+		Truncation: r.truncation,
+	}
+	if topK > 0 && topK < len(documents) {
+		reqBody.TopK = topK
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", r.baseURL+"/rerank", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := httputil.CheckRedirect(req, resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, providerError(resp, body)
+	}
+
+	var rerankResp rerankResponse
+	if err := json.Unmarshal(body, &rerankResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	results := make([]vex.RerankResult, 0, len(rerankResp.Data))
+	for _, d := range rerankResp.Data {
+		if d.Index < 0 || d.Index >= len(documents) {
+			return nil, fmt.Errorf("invalid index %d from API", d.Index)
+		}
+		results = append(results, vex.RerankResult{
+			Index:          d.Index,
+			RelevanceScore: d.RelevanceScore,
+			Document:       documents[d.Index],
+		})
+	}
+
+	// The API returns results sorted, but callers rely on the order.
+	slices.SortStableFunc(results, func(a, b vex.RerankResult) int {
+		return cmp.Compare(b.RelevanceScore, a.RelevanceScore)
+	})
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// API types
+
+type rerankRequest struct {
+	Query      string   `json:"query"`
+	Documents  []string `json:"documents"`
+	Model      string   `json:"model"`
+	TopK       int      `json:"top_k,omitempty"`
+	Truncation *bool    `json:"truncation,omitempty"`
+}
+
+type rerankResponse struct {
+	Object string       `json:"object"`
+	Model  string       `json:"model"`
+	Data   []rerankData `json:"data"`
+	Usage  usage        `json:"usage"`
+}
+
+type rerankData struct {
+	RelevanceScore float64 `json:"relevance_score"`
+	Index          int     `json:"index"`
+}
@@ -0,0 +1,152 @@
+package voyage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zoobzio/vex"
+)
+
+func TestReranker_Rerank(t *testing.T) {
+	t.Run("orders by score and maps indices", func(t *testing.T) {
+		var req rerankRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/rerank" {
+				t.Errorf("expected /rerank, got %s", r.URL.Path)
+			}
+			if r.Header.Get("Authorization") != "Bearer test-key" {
+				t.Errorf("unexpected authorization header %q", r.Header.Get("Authorization"))
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			// Deliberately unsorted to check the client orders results.
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{
+				"object": "list",
+				"model": "rerank-2",
+				"data": [
+					{"index": 0, "relevance_score": 0.12},
+					{"index": 2, "relevance_score": 0.91},
+					{"index": 1, "relevance_score": 0.47}
+				],
+				"usage": {"total_tokens": 30}
+			}`))
+		}))
+		defer server.Close()
+
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: server.URL})
+		docs := []string{"cats", "dogs", "embeddings"}
+		results, err := r.Rerank(context.Background(), "vector search", docs, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if req.Query != "vector search" || len(req.Documents) != 3 || req.Model != RerankModel2 {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		if req.TopK != 0 {
+			t.Errorf("expected top_k omitted, got %d", req.TopK)
+		}
+
+		want := []vex.RerankResult{
+			{Index: 2, RelevanceScore: 0.91, Document: "embeddings"},
+			{Index: 1, RelevanceScore: 0.47, Document: "dogs"},
+			{Index: 0, RelevanceScore: 0.12, Document: "cats"},
+		}
+		if len(results) != len(want) {
+			t.Fatalf("expected %d results, got %d", len(want), len(results))
+		}
+		for i := range want {
+			if results[i] != want[i] {
+				t.Errorf("result %d: expected %+v, got %+v", i, want[i], results[i])
+			}
+		}
+	})
+
+	t.Run("sends and enforces top_k", func(t *testing.T) {
+		var req rerankRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("failed to decode request: %v", err)
+			}
+			// Returns more than requested; the client must still cap.
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"data": [
+				{"index": 1, "relevance_score": 0.8},
+				{"index": 0, "relevance_score": 0.5},
+				{"index": 2, "relevance_score": 0.1}
+			]}`))
+		}))
+		defer server.Close()
+
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: server.URL, Model: RerankModel2Lite})
+		results, err := r.Rerank(context.Background(), "q", []string{"a", "b", "c"}, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if req.TopK != 2 || req.Model != RerankModel2Lite {
+			t.Errorf("expected top_k 2 with rerank-2-lite, got %d with %q", req.TopK, req.Model)
+		}
+		if len(results) != 2 || results[0].Index != 1 || results[1].Index != 0 {
+			t.Errorf("unexpected results: %+v", results)
+		}
+	})
+
+	t.Run("rejects out of range index", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"data": [{"index": 5, "relevance_score": 0.8}]}`))
+		}))
+		defer server.Close()
+
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: server.URL})
+		if _, err := r.Rerank(context.Background(), "q", []string{"a"}, 0); err == nil {
+			t.Error("expected error for invalid index")
+		}
+	})
+
+	t.Run("returns provider error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"detail": "Rate limit exceeded"}`))
+		}))
+		defer server.Close()
+
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: server.URL})
+		_, err := r.Rerank(context.Background(), "q", []string{"a"}, 0)
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected ProviderError, got %v", err)
+		}
+		if perr.StatusCode != http.StatusTooManyRequests || perr.Message != "Rate limit exceeded" || !perr.Retryable() {
+			t.Errorf("unexpected error: %+v", perr)
+		}
+	})
+
+	t.Run("empty documents", func(t *testing.T) {
+		r := NewReranker(RerankerConfig{APIKey: "test-key", BaseURL: "http://unused"})
+		results, err := r.Rerank(context.Background(), "q", nil, 3)
+		if err != nil || results != nil {
+			t.Errorf("expected nil results, got %v, %v", results, err)
+		}
+	})
+}
+
+func TestReranker_Defaults(t *testing.T) {
+	r := NewReranker(RerankerConfig{APIKey: "test"})
+	if r.Model() != RerankModel2 {
+		t.Errorf("expected default model %q, got %q", RerankModel2, r.Model())
+	}
+	if r.baseURL != "https://api.voyageai.com/v1" {
+		t.Errorf("expected default base URL, got %q", r.baseURL)
+	}
+
+	var _ vex.Reranker = r
+}
@@ -7,19 +7,40 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/zoobzio/vex"
+	"github.com/zoobzio/vex/internal/httputil"
 )
 
 // Default dimensions for Voyage models.
 const (
-	DimensionsVoyage3      = 1024
-	DimensionsVoyage3Lite  = 512
-	DimensionsVoyageLarge2 = 1536
+	DimensionsVoyage3        = 1024
+	DimensionsVoyage3Lite    = 512
+	DimensionsVoyage3Large   = 1024 // Default; 256, 512 and 2048 via OutputDimension
+	DimensionsVoyageCode3    = 1024 // Default; 256, 512 and 2048 via OutputDimension
+	DimensionsVoyageFinance2 = 1024
+	DimensionsVoyageLaw2     = 1024
+	DimensionsVoyageLarge2   = 1536
 )
 
+// Input token limits for Voyage models.
+const (
+	MaxTokensVoyage3        = 32000
+	MaxTokensVoyage3Lite    = 32000
+	MaxTokensVoyage3Large   = 32000
+	MaxTokensVoyageCode3    = 32000
+	MaxTokensVoyageFinance2 = 32000
+	MaxTokensVoyageLaw2     = 16000
+	MaxTokensVoyageLarge2   = 16000
+)
+
+// MaxBatchSize is the maximum number of texts per embeddings request.
+const MaxBatchSize = 1000
+
 // InputType specifies the type of text being embedded.
 type InputType string
 
@@ -29,14 +50,29 @@ const (
 	InputTypeQuery    InputType = "query"
 )
 
+// Output data type constants for Config.OutputDtype.
+const (
+	OutputDtypeFloat   = "float"
+	OutputDtypeInt8    = "int8"
+	OutputDtypeUint8   = "uint8"
+	OutputDtypeBinary  = "binary"
+	OutputDtypeUbinary = "ubinary"
+)
+
 // Provider implements vex.Provider for Voyage AI embeddings API.
 type Provider struct {
-	httpClient *http.Client
-	apiKey     string
-	model      string
-	baseURL    string
-	inputType  InputType
-	dimensions int
+	httpClient      *http.Client
+	apiKey          string
+	model           string
+	baseURL         string
+	inputType       InputType
+	outputDtype     string
+	truncation      *bool
+	configErr       error
+	dimensions      int
+	extra           map[string]any
+	headers         map[string]string
+	outputDimension int
 }
 
 // Config holds configuration for the Voyage AI embedding provider.
@@ -47,6 +83,37 @@ type Config struct {
 	InputType  InputType
 	Dimensions int
 	Timeout    time.Duration
+
+	// OutputDimension requests shorter or longer vectors from models that
+	// support it (256, 512, 1024 or 2048 for voyage-3-large and
+	// voyage-code-3). Optional; when set it also sets Dimensions.
+	OutputDimension int
+
+	// OutputDtype requests quantized embeddings. OutputDtypeInt8 and
+	// OutputDtypeUint8 populate EmbeddingResponse.Int8Vectors (uint8 shifted
+	// by -128); OutputDtypeBinary and OutputDtypeUbinary populate
+	// BinaryVectors with packed bits. Optional, defaults to float Vectors.
+	// Other values make Embed fail.
+	OutputDtype string
+
+	// Truncation controls whether overlong inputs are truncated to fit the
+	// model's context. When false they are rejected with a ProviderError
+	// wrapping vex.ErrContextLengthExceeded. Optional; nil leaves the API
+	// default, which truncates.
+	Truncation *bool
+
+	// Extra holds additional request body fields for API parameters not
+	// covered by Config. Fields the provider sets itself are not overridden.
+	// Optional.
+	Extra map[string]any
+
+	// Headers are set on every request after the provider's own, so a
+	// header named here replaces the provider's value. Optional.
+	Headers map[string]string
+
+	// HTTPClient is used for all requests when set, e.g. for proxies, mTLS,
+	// or instrumented transports. Timeout is applied if the client has none.
+	HTTPClient *http.Client
 }
 
 // New creates a new Voyage AI embedding provider.
@@ -60,6 +127,9 @@ func New(config Config) *Provider {
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
+	if config.OutputDimension > 0 {
+		config.Dimensions = config.OutputDimension
+	}
 	if config.Dimensions == 0 {
 		config.Dimensions = dimensionsForModel(config.Model)
 	}
@@ -67,15 +137,26 @@ func New(config Config) *Provider {
 		config.InputType = InputTypeDocument
 	}
 
+	var configErr error
+	switch config.OutputDtype {
+	case "", OutputDtypeFloat, OutputDtypeInt8, OutputDtypeUint8, OutputDtypeBinary, OutputDtypeUbinary:
+	default:
+		configErr = fmt.Errorf("voyage: invalid output dtype %q, must be float, int8, uint8, binary, or ubinary", config.OutputDtype)
+	}
+
 	return &Provider{
-		apiKey:     config.APIKey,
-		model:      config.Model,
-		baseURL:    config.BaseURL,
-		dimensions: config.Dimensions,
-		inputType:  config.InputType,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+		configErr:       configErr,
+		apiKey:          config.APIKey,
+		model:           config.Model,
+		baseURL:         config.BaseURL,
+		dimensions:      config.Dimensions,
+		outputDimension: config.OutputDimension,
+		outputDtype:     config.OutputDtype,
+		truncation:      config.Truncation,
+		inputType:       config.InputType,
+		extra:           maps.Clone(config.Extra),
+		headers:         maps.Clone(config.Headers),
+		httpClient:      httputil.NewClient(config.HTTPClient, config.Timeout),
 	}
 }
 
@@ -89,6 +170,24 @@ func (p *Provider) Dimensions() int {
 	return p.dimensions
 }
 
+// MaxTokens returns the per-input token limit for the configured model.
+// Implements vex.TokenLimiter.
+func (p *Provider) MaxTokens() int {
+	return maxTokensForModel(p.model)
+}
+
+// MaxBatchSize returns the per-request input limit, which the Service
+// splits larger requests to respect. Implements vex.BatchLimiter.
+func (*Provider) MaxBatchSize() int {
+	return MaxBatchSize
+}
+
+// Model returns the requested model identifier.
+// Implements vex.ModelReporter.
+func (p *Provider) Model() string {
+	return p.model
+}
+
 // WithInputType returns a new provider with the specified input type.
 func (p *Provider) WithInputType(inputType InputType) *Provider {
 	newP := *p
@@ -104,6 +203,9 @@ func (p *Provider) ForQuery() vex.Provider {
 
 // Embed generates embeddings for the given texts.
 func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingResponse, error) {
+	if p.configErr != nil {
+		return nil, p.configErr
+	}
 	if len(texts) == 0 {
 		return &vex.EmbeddingResponse{
 			Vectors:    nil,
@@ -116,20 +218,84 @@ func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingRes
 		Model:     p.model,
 		Input:     texts,
 		InputType: string(p.inputType),
+
+		OutputDimension: p.outputDimension,
+		OutputDtype:     p.outputDtype,
+		Truncation:      p.truncation,
+	}
+
+	body, err := p.post(ctx, "/embeddings", reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var embResp embeddingResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	result := &vex.EmbeddingResponse{
+		Model:      embResp.Model,
+		Dimensions: p.dimensions,
+		Usage: vex.Usage{
+			PromptTokens: embResp.Usage.TotalTokens,
+			TotalTokens:  embResp.Usage.TotalTokens,
+		},
+	}
+
+	n := len(embResp.Data)
+	switch p.outputDtype {
+	case OutputDtypeInt8, OutputDtypeUint8:
+		result.Int8Vectors = make([][]int8, n)
+	case OutputDtypeBinary, OutputDtypeUbinary:
+		result.BinaryVectors = make([][]byte, n)
+	default:
+		result.Vectors = make([]vex.Vector, n)
+	}
+
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= n {
+			return nil, fmt.Errorf("invalid index %d from API", d.Index)
+		}
+		switch p.outputDtype {
+		case OutputDtypeInt8, OutputDtypeUint8:
+			result.Int8Vectors[d.Index] = toInt8(d.Embedding, p.outputDtype == OutputDtypeUint8)
+		case OutputDtypeBinary, OutputDtypeUbinary:
+			result.BinaryVectors[d.Index] = toBits(d.Embedding, p.outputDtype == OutputDtypeBinary)
+		default:
+			result.Vectors[d.Index] = toFloat32(d.Embedding)
+		}
+	}
+
+	if err := vex.ValidateVectors("voyage", result.Vectors); err != nil {
+		return nil, err
+	}
+	if len(result.Vectors) > 0 && len(result.Vectors[0]) != p.dimensions {
+		// Dimensions() is guessed from the model name; a wrong guess must not
+		// reach vector store schemas sized from it.
+		return nil, fmt.Errorf("%w: voyage model %s returned %d dimensions, expected %d; set Config.Dimensions",
+			vex.ErrDimensionMismatch, embResp.Model, len(result.Vectors[0]), p.dimensions)
 	}
+	return result, nil
+}
 
-	jsonBody, err := json.Marshal(reqBody)
+// post sends reqBody, with the provider's extra fields, to path and returns
+// the response body. Non-200 responses are returned as *vex.ProviderError.
+func (p *Provider) post(ctx context.Context, path string, reqBody any) ([]byte, error) {
+	jsonBody, err := httputil.MarshalBody(reqBody, p.extra)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/embeddings", bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+path, bytes.NewReader(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	httputil.SetRequestID(req)
 	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	httputil.SetHeaders(req, p.headers)
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
@@ -137,46 +303,45 @@ func (p *Provider) Embed(ctx context.Context, texts []string) (*vex.EmbeddingRes
 	}
 	defer resp.Body.Close()
 
+	if err := httputil.CheckRedirect(req, resp); err != nil {
+		return nil, err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var errResp errorResponse
-		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Detail != "" {
-			return nil, fmt.Errorf("voyage error (%d): %s", resp.StatusCode, errResp.Detail)
-		}
-		return nil, fmt.Errorf("voyage error: status %d", resp.StatusCode)
+		return nil, providerError(resp, body)
 	}
+	return body, nil
+}
 
-	var embResp embeddingResponse
-	if err := json.Unmarshal(body, &embResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// providerError builds the ProviderError for a non-200 response.
+func providerError(resp *http.Response, body []byte) *vex.ProviderError {
+	perr := &vex.ProviderError{
+		Provider:   "voyage",
+		StatusCode: resp.StatusCode,
+		RetryAfter: httputil.RetryAfter(resp.Header, time.Now()),
 	}
-
-	vectors := make([]vex.Vector, len(embResp.Data))
-	for _, d := range embResp.Data {
-		if d.Index < 0 || d.Index >= len(vectors) {
-			return nil, fmt.Errorf("invalid index %d from API", d.Index)
-		}
-		vectors[d.Index] = toFloat32(d.Embedding)
+	var errResp errorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		perr.Message = errResp.Detail
 	}
-
-	dims := p.dimensions
-	if len(vectors) > 0 && len(vectors[0]) > 0 {
-		dims = len(vectors[0])
+	if isContextLengthError(perr) {
+		perr.Err = vex.ErrContextLengthExceeded
 	}
+	return perr
+}
 
-	return &vex.EmbeddingResponse{
-		Vectors:    vectors,
-		Model:      embResp.Model,
-		Dimensions: dims,
-		Usage: vex.Usage{
-			PromptTokens: embResp.Usage.TotalTokens,
-			TotalTokens:  embResp.Usage.TotalTokens,
-		},
-	}, nil
+// isContextLengthError reports whether perr is Voyage's rejection of an
+// input that does not fit the model's context, returned when truncation is
+// disabled.
+func isContextLengthError(perr *vex.ProviderError) bool {
+	msg := strings.ToLower(perr.Message)
+	return perr.StatusCode == http.StatusBadRequest &&
+		(strings.Contains(msg, "context length") || strings.Contains(msg, "too many tokens"))
 }
 
 func dimensionsForModel(model string) int {
@@ -185,6 +350,14 @@ func dimensionsForModel(model string) int {
 		return DimensionsVoyage3
 	case "voyage-3-lite":
 		return DimensionsVoyage3Lite
+	case "voyage-3-large":
+		return DimensionsVoyage3Large
+	case "voyage-code-3":
+		return DimensionsVoyageCode3
+	case "voyage-finance-2":
+		return DimensionsVoyageFinance2
+	case "voyage-law-2":
+		return DimensionsVoyageLaw2
 	case "voyage-large-2":
 		return DimensionsVoyageLarge2
 	default:
@@ -192,6 +365,27 @@ func dimensionsForModel(model string) int {
 	}
 }
 
+func maxTokensForModel(model string) int {
+	switch model {
+	case "voyage-3":
+		return MaxTokensVoyage3
+	case "voyage-3-lite":
+		return MaxTokensVoyage3Lite
+	case "voyage-3-large":
+		return MaxTokensVoyage3Large
+	case "voyage-code-3":
+		return MaxTokensVoyageCode3
+	case "voyage-finance-2":
+		return MaxTokensVoyageFinance2
+	case "voyage-law-2":
+		return MaxTokensVoyageLaw2
+	case "voyage-large-2":
+		return MaxTokensVoyageLarge2
+	default:
+		return MaxTokensVoyage3
+	}
+}
+
 // toFloat32 converts a float64 slice to a vex.Vector (float32).
 func toFloat32(f64 []float64) vex.Vector {
 	result := make(vex.Vector, len(f64))
@@ -201,12 +395,43 @@ func toFloat32(f64 []float64) vex.Vector {
 	return result
 }
 
+// toInt8 converts integer embedding values to int8. Voyage returns uint8
+// embeddings as the int8 value plus 128, so unsigned values are shifted back.
+func toInt8(values []float64, unsigned bool) []int8 {
+	result := make([]int8, len(values))
+	for i, v := range values {
+		if unsigned {
+			v -= 128
+		}
+		result[i] = int8(v)
+	}
+	return result
+}
+
+// toBits converts bit-packed embedding values to bytes. Voyage encodes
+// signed binary embeddings as the packed byte minus 128, so signed values are
+// shifted up to recover the bits.
+func toBits(values []float64, signed bool) []byte {
+	result := make([]byte, len(values))
+	for i, v := range values {
+		if signed {
+			v += 128
+		}
+		result[i] = byte(v)
+	}
+	return result
+}
+
 // API types
 
 type embeddingRequest struct {
 	Model     string   `json:"model"`
 	InputType string   `json:"input_type,omitempty"`
 	Input     []string `json:"input"`
+
+	OutputDimension int    `json:"output_dimension,omitempty"`
+	OutputDtype     string `json:"output_dtype,omitempty"`
+	Truncation      *bool  `json:"truncation,omitempty"`
 }
 
 type embeddingResponse struct {
@@ -3,11 +3,15 @@ package voyage
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"github.com/zoobzio/vex"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
-
-	"github.com/zoobzio/vex"
+	"time"
 )
 
 func TestProvider_Name(t *testing.T) {
@@ -24,6 +28,10 @@ func TestProvider_Dimensions(t *testing.T) {
 	}{
 		{"voyage-3", DimensionsVoyage3},
 		{"voyage-3-lite", DimensionsVoyage3Lite},
+		{"voyage-3-large", DimensionsVoyage3Large},
+		{"voyage-code-3", DimensionsVoyageCode3},
+		{"voyage-finance-2", DimensionsVoyageFinance2},
+		{"voyage-law-2", DimensionsVoyageLaw2},
 		{"voyage-large-2", DimensionsVoyageLarge2},
 		{"unknown", DimensionsVoyage3}, // defaults
 	}
@@ -62,8 +70,9 @@ func TestProvider_Embed(t *testing.T) {
 		defer server.Close()
 
 		p := New(Config{
-			APIKey:  "test-key",
-			BaseURL: server.URL,
+			APIKey:     "test-key",
+			BaseURL:    server.URL,
+			Dimensions: 3,
 		})
 
 		resp, err := p.Embed(context.Background(), []string{"hello", "world"})
@@ -107,8 +116,18 @@ func TestProvider_Embed(t *testing.T) {
 		})
 
 		_, err := p.Embed(context.Background(), []string{"test"})
-		if err == nil {
-			t.Error("expected error for invalid API key")
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected ProviderError, got %v", err)
+		}
+		if perr.Provider != "voyage" || perr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("unexpected provider error fields: %+v", perr)
+		}
+		if perr.Message != "Invalid API key" {
+			t.Errorf("expected message 'Invalid API key', got %q", perr.Message)
+		}
+		if perr.Retryable() {
+			t.Error("expected 401 to be non-retryable")
 		}
 	})
 
@@ -128,7 +147,7 @@ func TestProvider_Embed(t *testing.T) {
 		}))
 		defer server.Close()
 
-		p := New(Config{APIKey: "test", BaseURL: server.URL})
+		p := New(Config{APIKey: "test", BaseURL: server.URL, Dimensions: 2})
 		resp, err := p.Embed(context.Background(), []string{"a", "b"})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
@@ -152,7 +171,7 @@ func TestProvider_Embed(t *testing.T) {
 		}))
 		defer server.Close()
 
-		p := New(Config{APIKey: "test", BaseURL: server.URL})
+		p := New(Config{APIKey: "test", BaseURL: server.URL, Dimensions: 2})
 		_, err := p.Embed(context.Background(), []string{"test"})
 		if err == nil {
 			t.Error("expected error for invalid index")
@@ -172,7 +191,7 @@ func TestProvider_Embed(t *testing.T) {
 		}))
 		defer server.Close()
 
-		p := New(Config{APIKey: "test", BaseURL: server.URL})
+		p := New(Config{APIKey: "test", BaseURL: server.URL, Dimensions: 2})
 		_, err := p.Embed(context.Background(), []string{"test"})
 		if err == nil {
 			t.Error("expected error for negative index")
@@ -180,6 +199,223 @@ func TestProvider_Embed(t *testing.T) {
 	})
 }
 
+func TestProvider_OutputDimension(t *testing.T) {
+	var req embeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(embeddingResponse{
+			Model: "voyage-3-large",
+			Data:  []embeddingData{{Index: 0, Embedding: make([]float64, 256)}},
+		})
+	}))
+	defer server.Close()
+
+	p := New(Config{APIKey: "test-key", BaseURL: server.URL, Model: "voyage-3-large", OutputDimension: 256})
+	if p.Dimensions() != 256 {
+		t.Errorf("expected Dimensions() to report 256, got %d", p.Dimensions())
+	}
+
+	resp, err := p.Embed(context.Background(), []string{"hello"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.OutputDimension != 256 {
+		t.Errorf("expected output_dimension 256, got %d", req.OutputDimension)
+	}
+	if req.OutputDtype != "" {
+		t.Errorf("expected output_dtype omitted, got %q", req.OutputDtype)
+	}
+	if resp.Dimensions != 256 {
+		t.Errorf("expected response dimensions 256, got %d", resp.Dimensions)
+	}
+
+	if New(Config{APIKey: "test"}).outputDimension != 0 {
+		t.Error("expected output_dimension unset by default")
+	}
+}
+
+func TestProvider_DimensionMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		resp := embeddingResponse{
+			Model: "voyage-law-2",
+			Data:  []embeddingData{{Embedding: make([]float64, 1536)}},
+		}
+		resp.Data[0].Embedding[0] = 1
+		//nolint:errcheck // test helper
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"guessed from model", Config{Model: "voyage-law-2"}, true},
+		{"unknown model", Config{Model: "voyage-next"}, true},
+		{"explicit dimensions", Config{Model: "voyage-next", Dimensions: 1536}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.APIKey = "test-key"
+			tt.config.BaseURL = server.URL
+			_, err := New(tt.config).Embed(context.Background(), []string{"hello"})
+			if tt.wantErr != errors.Is(err, vex.ErrDimensionMismatch) {
+				t.Errorf("expected mismatch error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProvider_OutputDtype(t *testing.T) {
+	tests := []struct {
+		dtype     string
+		embedding []float64
+		int8s     []int8
+		bits      []byte
+	}{
+		{OutputDtypeInt8, []float64{-128, 0, 127}, []int8{-128, 0, 127}, nil},
+		{OutputDtypeUint8, []float64{0, 128, 255}, []int8{-128, 0, 127}, nil},
+		{OutputDtypeBinary, []float64{-128, 0, 127}, nil, []byte{0x00, 0x80, 0xff}},
+		{OutputDtypeUbinary, []float64{0, 128, 255}, nil, []byte{0x00, 0x80, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dtype, func(t *testing.T) {
+			var req embeddingRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatalf("failed to decode request: %v", err)
+				}
+				//nolint:errcheck // test helper
+				json.NewEncoder(w).Encode(embeddingResponse{
+					Data: []embeddingData{
+						{Index: 1, Embedding: tt.embedding},
+						{Index: 0, Embedding: tt.embedding},
+					},
+				})
+			}))
+			defer server.Close()
+
+			p := New(Config{APIKey: "test-key", BaseURL: server.URL, OutputDtype: tt.dtype})
+			resp, err := p.Embed(context.Background(), []string{"a", "b"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if req.OutputDtype != tt.dtype {
+				t.Errorf("expected output_dtype %q, got %q", tt.dtype, req.OutputDtype)
+			}
+			if resp.Vectors != nil {
+				t.Errorf("expected no float vectors, got %d", len(resp.Vectors))
+			}
+			if resp.Dimensions != DimensionsVoyage3 {
+				t.Errorf("expected dimensions %d, got %d", DimensionsVoyage3, resp.Dimensions)
+			}
+			if tt.int8s != nil {
+				if len(resp.Int8Vectors) != 2 {
+					t.Fatalf("expected 2 int8 vectors, got %d", len(resp.Int8Vectors))
+				}
+				for i, v := range resp.Int8Vectors {
+					if !slices.Equal(v, tt.int8s) {
+						t.Errorf("int8 vector %d: expected %v, got %v", i, tt.int8s, v)
+					}
+				}
+			}
+			if tt.bits != nil {
+				if len(resp.BinaryVectors) != 2 {
+					t.Fatalf("expected 2 binary vectors, got %d", len(resp.BinaryVectors))
+				}
+				for i, v := range resp.BinaryVectors {
+					if !slices.Equal(v, tt.bits) {
+						t.Errorf("binary vector %d: expected %v, got %v", i, tt.bits, v)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("rejects unknown dtype", func(t *testing.T) {
+		p := New(Config{APIKey: "test-key", BaseURL: "http://unused", OutputDtype: "float16"})
+		if _, err := p.Embed(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), "float16") {
+			t.Errorf("expected invalid dtype error, got %v", err)
+		}
+	})
+}
+
+func TestProvider_Truncation(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		truncation *bool
+		want       string
+	}{
+		{"default", nil, ""},
+		{"enabled", ptr(true), "true"},
+		{"disabled", ptr(false), "false"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw map[string]json.RawMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+					t.Fatalf("failed to decode request: %v", err)
+				}
+				//nolint:errcheck // test helper
+				w.Write([]byte(`{"data": [{"embedding": [0.1], "index": 0}]}`))
+			}))
+			defer server.Close()
+
+			p := New(Config{APIKey: "test-key", BaseURL: server.URL, Dimensions: 1, Truncation: tt.truncation})
+			if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := string(raw["truncation"]); got != tt.want {
+				t.Errorf("expected truncation %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("classifies context length error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"detail": "Request to model 'voyage-3' failed. The example at index 0 in your batch has too many tokens and does not fit into the model's context length of 32000 tokens."}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL, Truncation: ptr(false)})
+		_, err := p.Embed(context.Background(), []string{"very long"})
+		if !errors.Is(err, vex.ErrContextLengthExceeded) {
+			t.Errorf("expected ErrContextLengthExceeded, got %v", err)
+		}
+		var perr *vex.ProviderError
+		if !errors.As(err, &perr) || perr.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400 ProviderError, got %v", err)
+		}
+	})
+
+	t.Run("leaves other errors unclassified", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			//nolint:errcheck // test helper
+			w.Write([]byte(`{"detail": "Invalid input_type."}`))
+		}))
+		defer server.Close()
+
+		p := New(Config{APIKey: "test-key", BaseURL: server.URL})
+		_, err := p.Embed(context.Background(), []string{"hello"})
+		if err == nil || errors.Is(err, vex.ErrContextLengthExceeded) {
+			t.Errorf("expected unclassified error, got %v", err)
+		}
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func TestProvider_WithInputType(t *testing.T) {
 	p := New(Config{APIKey: "test", InputType: InputTypeDocument})
 
@@ -220,6 +456,114 @@ func TestProvider_ImplementsQueryProviderFactory(_ *testing.T) {
 	var _ vex.QueryProviderFactory = p
 }
 
+func TestProvider_MaxBatchSize(t *testing.T) {
+	var p vex.BatchLimiter = New(Config{APIKey: "test"})
+	if p.MaxBatchSize() != MaxBatchSize {
+		t.Errorf("expected %d, got %d", MaxBatchSize, p.MaxBatchSize())
+	}
+}
+
+func TestProvider_Model(t *testing.T) {
+	p := New(Config{APIKey: "test"})
+	if p.Model() != "voyage-3" {
+		t.Errorf("expected model 'voyage-3', got %q", p.Model())
+	}
+}
+
+// countingTransport records round trips and fails them without network access.
+type countingTransport struct {
+	calls int
+}
+
+var errIntercepted = errors.New("intercepted")
+
+func (c *countingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	c.calls++
+	return nil, errIntercepted
+}
+
+func TestConfig_HTTPClient(t *testing.T) {
+	t.Run("uses custom round tripper", func(t *testing.T) {
+		transport := &countingTransport{}
+		p := New(Config{
+			APIKey:     "test",
+			HTTPClient: &http.Client{Transport: transport},
+		})
+
+		_, err := p.Embed(context.Background(), []string{"test"})
+		if !errors.Is(err, errIntercepted) {
+			t.Errorf("expected intercepted error, got %v", err)
+		}
+		if transport.calls != 1 {
+			t.Errorf("expected 1 round trip, got %d", transport.calls)
+		}
+	})
+
+	t.Run("applies timeout when client has none", func(t *testing.T) {
+		p := New(Config{
+			APIKey:     "test",
+			Timeout:    5 * time.Second,
+			HTTPClient: &http.Client{},
+		})
+		if p.httpClient.Timeout != 5*time.Second {
+			t.Errorf("expected 5s timeout, got %v", p.httpClient.Timeout)
+		}
+	})
+}
+
+func TestConfig_Headers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Tenant-ID"); got != "acme" {
+			t.Errorf("expected custom header, got %q", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("expected custom header to override Content-Type, got %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("expected provider authentication to be kept, got %q", got)
+		}
+		//nolint:errcheck // test helper
+		w.Write([]byte(`{"data": [{"index": 0, "embedding": [0.1]}]}`))
+	}))
+	defer server.Close()
+
+	p := New(Config{
+		APIKey:     "test-key",
+		BaseURL:    server.URL,
+		Dimensions: 1,
+		Headers: map[string]string{
+			"X-Tenant-ID":  "acme",
+			"Content-Type": "application/json; charset=utf-8",
+		},
+	})
+	if _, err := p.Embed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestProvider_MaxTokens(t *testing.T) {
+	tests := []struct {
+		model    string
+		expected int
+	}{
+		{"voyage-3", 32000},
+		{"voyage-3-lite", 32000},
+		{"voyage-3-large", 32000},
+		{"voyage-code-3", 32000},
+		{"voyage-finance-2", 32000},
+		{"voyage-law-2", 16000},
+		{"voyage-large-2", 16000},
+		{"unknown-model", 32000}, // defaults to voyage-3
+	}
+
+	for _, tt := range tests {
+		p := New(Config{APIKey: "test", Model: tt.model})
+		if p.MaxTokens() != tt.expected {
+			t.Errorf("model %s: expected %d max tokens, got %d", tt.model, tt.expected, p.MaxTokens())
+		}
+	}
+}
+
 func TestConfig_Defaults(t *testing.T) {
 	p := New(Config{APIKey: "test"})
 
@@ -233,3 +577,39 @@ func TestConfig_Defaults(t *testing.T) {
 		t.Errorf("expected default input type 'document'")
 	}
 }
+
+func TestProvider_RetryResendsBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+		bodies = append(bodies, string(body))
+
+		switch len(bodies) {
+		case 1:
+			// A 307 redirect must replay the body on the same attempt.
+			http.Redirect(w, r, r.URL.String(), http.StatusTemporaryRedirect)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"data":[{"index":0,"embedding":[0.6,0.8]}]}`)) //nolint:errcheck // test helper
+		}
+	}))
+	defer server.Close()
+
+	svc := vex.NewService(New(Config{APIKey: "test", BaseURL: server.URL, Dimensions: 2}), vex.WithRetry(2))
+	if _, err := svc.Embed(context.Background(), "hello world"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(bodies))
+	}
+	for i, body := range bodies {
+		if body == "" || body != bodies[0] {
+			t.Errorf("request %d: expected full body %q, got %q", i, bodies[0], body)
+		}
+	}
+}